@@ -0,0 +1,144 @@
+package color
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AccessLogEntry 一次转发的访问日志
+type AccessLogEntry struct {
+	Time      time.Time
+	Method    string
+	Path      string
+	Color     string
+	Target    string
+	Status    int
+	Duration  time.Duration
+	TTFB      time.Duration // 首字节时间：从收到请求到写出响应头，未写出响应时为 0
+	Bytes     int64
+	RequestID string // 仅在启用 WithRequestID 时填充
+	Error     string // 传输层返回的错误
+}
+
+// AccessLogger 访问日志输出
+// 配置后内置 HTTP 传输层的诊断日志（转发错误、连接淘汰、DNS 变化等）也通过 LogTransport 输出
+type AccessLogger interface {
+	// LogAccess 每次转发结束后调用，包括转发失败返回 502 的请求
+	LogAccess(e AccessLogEntry)
+	// LogTransport 传输层诊断日志
+	LogTransport(msg string)
+}
+
+// WithAccessLog 启用访问日志，每次转发结束后输出一条记录
+func WithAccessLog(logger AccessLogger) Option {
+	return func(c *Config) {
+		c.AccessLogger = logger
+	}
+}
+
+// TextAccessLogger 文本格式访问日志，传输层日志保持原有格式
+type TextAccessLogger struct {
+	logger *log.Logger
+}
+
+// NewTextAccessLogger 创建文本访问日志，w 为 nil 时使用标准库 log 的默认输出
+func NewTextAccessLogger(w io.Writer) *TextAccessLogger {
+	if w == nil {
+		return &TextAccessLogger{logger: log.Default()}
+	}
+	return &TextAccessLogger{logger: log.New(w, "", log.LstdFlags)}
+}
+
+func (l *TextAccessLogger) LogAccess(e AccessLogEntry) {
+	line := fmt.Sprintf("[ColorProxy] %s %s color=%s target=%s status=%d duration=%v ttfb=%v bytes=%d",
+		e.Method, e.Path, e.Color, e.Target, e.Status, e.Duration, e.TTFB, e.Bytes)
+	if e.RequestID != "" {
+		line += " request_id=" + e.RequestID
+	}
+	if e.Error != "" {
+		line += " error=" + e.Error
+	}
+	l.logger.Print(line)
+}
+
+func (l *TextAccessLogger) LogTransport(msg string) {
+	l.logger.Print(msg)
+}
+
+// JSONAccessLogger 每行一个 JSON 对象的访问日志，duration 与 ttfb 以毫秒输出
+type JSONAccessLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONAccessLogger 创建 JSON 访问日志
+func NewJSONAccessLogger(w io.Writer) *JSONAccessLogger {
+	return &JSONAccessLogger{w: w}
+}
+
+func (l *JSONAccessLogger) LogAccess(e AccessLogEntry) {
+	l.write(struct {
+		Time      time.Time `json:"time"`
+		Type      string    `json:"type"`
+		Method    string    `json:"method"`
+		Path      string    `json:"path"`
+		Color     string    `json:"color"`
+		Target    string    `json:"target"`
+		Status    int       `json:"status"`
+		Duration  float64   `json:"duration_ms"`
+		TTFB      float64   `json:"ttfb_ms"`
+		Bytes     int64     `json:"bytes"`
+		RequestID string    `json:"request_id,omitempty"`
+		Error     string    `json:"error,omitempty"`
+	}{e.Time, "access", e.Method, e.Path, e.Color, e.Target, e.Status,
+		float64(e.Duration) / float64(time.Millisecond), float64(e.TTFB) / float64(time.Millisecond),
+		e.Bytes, e.RequestID, e.Error})
+}
+
+func (l *JSONAccessLogger) LogTransport(msg string) {
+	l.write(struct {
+		Time time.Time `json:"time"`
+		Type string    `json:"type"`
+		Msg  string    `json:"msg"`
+	}{time.Now(), "transport", msg})
+}
+
+func (l *JSONAccessLogger) write(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(append(b, '\n'))
+}
+
+// logAccess 输出一次转发的访问日志
+func (p *Proxy) logAccess(r *http.Request, sw *statusWriter, color, target string, start time.Time, err error) {
+	if p.config.AccessLogger == nil {
+		return
+	}
+	e := AccessLogEntry{
+		Time:     start,
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Color:    color,
+		Target:   target,
+		Status:   sw.status,
+		Duration: time.Since(start),
+		TTFB:     sw.ttfb(start),
+		Bytes:    sw.bytes,
+	}
+	if h := p.config.RequestIDHeader; h != "" {
+		e.RequestID = r.Header.Get(h)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	p.config.AccessLogger.LogAccess(e)
+}
@@ -0,0 +1,33 @@
+package color
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONAccessLoggerWritesTTFB(t *testing.T) {
+	var buf bytes.Buffer
+	NewJSONAccessLogger(&buf).LogAccess(AccessLogEntry{Status: 200, Duration: 30 * time.Millisecond, TTFB: 12 * time.Millisecond})
+
+	var got struct {
+		Duration float64 `json:"duration_ms"`
+		TTFB     float64 `json:"ttfb_ms"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	if got.Duration != 30 || got.TTFB != 12 {
+		t.Errorf("duration_ms = %v, ttfb_ms = %v, want 30 and 12", got.Duration, got.TTFB)
+	}
+}
+
+func TestTextAccessLoggerWritesTTFB(t *testing.T) {
+	var buf bytes.Buffer
+	NewTextAccessLogger(&buf).LogAccess(AccessLogEntry{Status: 200, TTFB: 12 * time.Millisecond})
+	if !strings.Contains(buf.String(), "ttfb=12ms") {
+		t.Errorf("log line %q does not contain ttfb=12ms", buf.String())
+	}
+}
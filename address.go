@@ -0,0 +1,83 @@
+package color
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// normalizeAddress 校验并规范化注册的地址
+// HTTP 后端需为带 http/https scheme 与 host 的 URL，去掉末尾的 "/"；gRPC 后端使用 host:port 形式
+func normalizeAddress(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return "", fmt.Errorf("address is empty")
+	}
+
+	if !strings.Contains(addr, "://") {
+		// gRPC：host:port
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || host == "" || !validPort(port) {
+			return "", fmt.Errorf("invalid address %q: must be an http(s) URL or host:port", addr)
+		}
+		return addr, nil
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %q: %v", addr, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid address %q: scheme must be http or https", addr)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("invalid address %q: host is required", addr)
+	}
+	// 未加方括号的 host 不能再含 ":"（如 host:80:80），IPv6 需写成 [::1]:8080
+	if !strings.HasPrefix(u.Host, "[") && strings.Contains(u.Hostname(), ":") {
+		return "", fmt.Errorf("invalid address %q: invalid host", addr)
+	}
+	if port := u.Port(); port != "" && !validPort(port) {
+		return "", fmt.Errorf("invalid address %q: invalid port", addr)
+	}
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", fmt.Errorf("invalid address %q: query, fragment and userinfo are not allowed", addr)
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// parseTrustedProxies 解析受信任的上游代理列表，单个 IP 视为 /32（IPv6 为 /128）
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, s := range proxies {
+		s = strings.TrimSpace(s)
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
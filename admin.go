@@ -0,0 +1,105 @@
+package color
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// isAdminRequest 判断请求是否携带有效的 admin token
+// 默认读取 Authorization: Bearer <token>；配置了 AdminTokenHeader 时读取该 header 的原始值
+// 未配置 admin token 时不认为任何请求是 admin
+func (p *Proxy) isAdminRequest(req *http.Request) bool {
+	if p.config.AdminToken == "" {
+		return false
+	}
+
+	var token string
+	if name := p.customTokenHeader(); name != "" {
+		token = req.Header.Get(name)
+	} else {
+		var ok bool
+		if token, ok = strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); !ok {
+			return false
+		}
+	}
+	return p.validAdminToken(token)
+}
+
+// customTokenHeader 返回自定义的 admin token header，使用 Authorization: Bearer 时返回空字符串
+func (p *Proxy) customTokenHeader() string {
+	if name := p.config.AdminTokenHeader; name != "" && !strings.EqualFold(name, "Authorization") {
+		return name
+	}
+	return ""
+}
+
+func (p *Proxy) validAdminToken(token string) bool {
+	if token == "" || p.config.AdminToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(p.config.AdminToken)) == 1
+}
+
+// stripAdminCredentials 删除请求中的 admin 凭证，避免转发给后端：
+// 自定义 header 直接删除；Authorization 只在携带的正是 admin token 时删除，用户自己的凭证原样转发
+func (p *Proxy) stripAdminCredentials(req *http.Request) {
+	if name := p.customTokenHeader(); name != "" {
+		req.Header.Del(name)
+		return
+	}
+	if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok && p.validAdminToken(token) {
+		req.Header.Del("Authorization")
+	}
+}
+
+// requireAdmin 管理端点认证：配置了 admin token 时，缺少或错误的 token 返回 401
+// 未配置 admin token 时不做校验，保持原有行为
+func (p *Proxy) requireAdmin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if p.config.AdminToken == "" || p.isAdminRequest(r) {
+			h(w, r)
+			return
+		}
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		p.writeError(w, http.StatusUnauthorized, "unauthorized", "missing or invalid admin token", nil)
+	}
+}
+
+// overrideTarget 返回受信任请求通过 header 指定的目标地址
+// 仅当配置了 header 名、请求携带该 header 且通过 admin 认证时生效；其他情况返回空字符串
+func (p *Proxy) overrideTarget(req *http.Request) string {
+	name := p.config.TargetOverrideHeader
+	if name == "" {
+		return ""
+	}
+	target := req.Header.Get(name)
+	if target == "" || !p.isAdminRequest(req) {
+		return ""
+	}
+	return target
+}
+
+// proxyOverride 直接转发到受信任请求指定的目标，绕过 color 与路由策略
+func (p *Proxy) proxyOverride(w http.ResponseWriter, r *http.Request, target string) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		p.writeError(w, 400, "invalid override target", "", jsonMap{"target": target})
+		return
+	}
+
+	// 覆盖 header 与 admin 凭证只用于代理本身，不转发给后端
+	r.Header.Del(p.config.TargetOverrideHeader)
+	p.stripAdminCredentials(r)
+
+	p.config.Logger.Info("target override: %s %s -> %s", r.Method, r.URL.Path, target)
+
+	sw := &statusWriter{ResponseWriter: w}
+	if err := p.http.Proxy(r.Context(), target, r, sw); err != nil {
+		if !sw.wroteHeader {
+			p.config.Logger.Error("proxy failed for override target=%s: %v", target, err)
+			p.writeError(sw, 502, "proxy failed", err.Error(), nil)
+		}
+	}
+}
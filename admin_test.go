@@ -0,0 +1,192 @@
+package color
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/labstack/echo/v4"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+func TestOverrideStripsCustomAdminHeader(t *testing.T) {
+	var got http.Header
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer up.Close()
+
+	p := newTestProxy(t, WithAdminToken("secret"), WithAdminTokenHeader("X-Admin-Token"), WithTargetOverrideHeader("X-Target"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("X-Admin-Token", "secret")
+	req.Header.Set("X-Target", up.URL)
+	req.Header.Set("Authorization", "Bearer user-token")
+	if rec := serve(p, req); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	if v := got.Get("X-Admin-Token"); v != "" {
+		t.Errorf("admin token header forwarded: %q", v)
+	}
+	if v := got.Get("X-Target"); v != "" {
+		t.Errorf("override header forwarded: %q", v)
+	}
+	if v := got.Get("Authorization"); v != "Bearer user-token" {
+		t.Errorf("user Authorization = %q, want it forwarded unchanged", v)
+	}
+}
+
+func TestOverrideStripsAdminBearer(t *testing.T) {
+	var got http.Header
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer up.Close()
+
+	p := newTestProxy(t, WithAdminToken("secret"), WithTargetOverrideHeader("X-Target"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Target", up.URL)
+	if rec := serve(p, req); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if v := got.Get("Authorization"); v != "" {
+		t.Errorf("admin bearer token forwarded: %q", v)
+	}
+}
+
+func TestAdminUIRequiresToken(t *testing.T) {
+	p := newTestProxy(t, WithAdminToken("secret"), WithAdminUI(true))
+
+	if rec := serve(p, httptest.NewRequest(http.MethodGet, "/colorproxy/ui", nil)); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("without token: status = %d, want 401", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/colorproxy/ui", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	if rec := serve(p, req); rec.Code != http.StatusOK {
+		t.Fatalf("with token: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestAdminPathNotProxiedWithColorHeader(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("proxied"))
+	}))
+	defer up.Close()
+
+	p := newTestProxy(t)
+	register(t, p, &backend.Route{Color: "red", Address: up.URL, Token: "t"})
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	p.AttachGin(engine)
+	e := echo.New()
+	p.AttachEcho(e)
+
+	for name, h := range map[string]http.Handler{
+		"net/http": p.Handler(),
+		"gin":      engine,
+		"echo":     e,
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/colorproxy/routes", nil)
+			req.Header.Set("color", "red")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			var body struct {
+				Routes []*backend.Route `json:"routes"`
+				Count  int              `json:"count"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("status = %d body = %q, want the route list: %v", rec.Code, rec.Body, err)
+			}
+			if rec.Code != http.StatusOK || body.Count != 1 || body.Routes[0].Color != "red" {
+				t.Errorf("status = %d routes = %+v, want the registered red route", rec.Code, body.Routes)
+			}
+		})
+	}
+}
+
+// adminCall 以可选的认证 header 调用管理端点
+func adminCall(h http.Handler, path, body, header, value string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminTokenGuardsRegisterAndHeartbeat(t *testing.T) {
+	// 注册与心跳使用相同的请求体
+	const body = `{"color":"blue","address":"http://10.0.0.1:8080","token":"t1"}`
+	for _, tc := range []struct {
+		name   string
+		opts   []Option
+		header string
+		value  string
+	}{
+		{"bearer", []Option{WithAdminToken("secret")}, "Authorization", "Bearer secret"},
+		{"custom header", []Option{WithAdminToken("secret"), WithAdminTokenHeader("X-Admin-Token")}, "X-Admin-Token", "secret"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestProxy(t, tc.opts...)
+			gin.SetMode(gin.TestMode)
+			engine := gin.New()
+			p.AttachGin(engine)
+
+			for name, h := range map[string]http.Handler{"net/http": p.Handler(), "gin": engine} {
+				for _, path := range []string{"/colorproxy/register", "/colorproxy/heartbeat"} {
+					for _, bad := range []struct{ header, value string }{{"", ""}, {tc.header, "wrong"}} {
+						rec := adminCall(h, path, body, bad.header, bad.value)
+						if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+							t.Errorf("%s %s with %q=%q: status = %d, WWW-Authenticate = %q, want 401 with a challenge",
+								name, path, bad.header, bad.value, rec.Code, rec.Header().Get("WWW-Authenticate"))
+						}
+					}
+				}
+
+				if rec := adminCall(h, "/colorproxy/register", body, tc.header, tc.value); rec.Code != http.StatusOK {
+					t.Errorf("%s register with a valid token: status = %d, body = %s", name, rec.Code, rec.Body)
+				}
+				if rec := adminCall(h, "/colorproxy/heartbeat", body, tc.header, tc.value); rec.Code != http.StatusOK {
+					t.Errorf("%s heartbeat with a valid token: status = %d, body = %s", name, rec.Code, rec.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminTokenLeavesBusinessRoutesOpen(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("proxied"))
+	}))
+	defer up.Close()
+
+	p := newTestProxy(t, WithAdminToken("secret"))
+	register(t, p, &backend.Route{Color: "red", Address: up.URL, Token: "t"})
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	p.AttachGin(engine)
+
+	for name, h := range map[string]http.Handler{"net/http": p.Handler(), "gin": engine} {
+		req := httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+		req.Header.Set("color", "red")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || rec.Body.String() != "proxied" {
+			t.Errorf("%s: status = %d body = %q, want the business request proxied without an admin token", name, rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package color
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// 常见虚拟网卡关键词，未指定网卡时跳过这些网卡
+var virtualKeywords = []string{
+	"virtual", "vmware", "hyper-v", "docker", "veth", "wsl",
+	"vbox", "loopback", "tunnel", "tap", "tun", "ethernet default switch",
+}
+
+// netInterface 网卡及其地址，与 net.Interfaces 解耦便于替换
+type netInterface struct {
+	Name  string
+	Flags net.Flags
+	Addrs []net.Addr
+}
+
+// systemInterfaces 读取本机网卡列表
+func systemInterfaces() ([]netInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]netInterface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		list = append(list, netInterface{Name: iface.Name, Flags: iface.Flags, Addrs: addrs})
+	}
+	return list, nil
+}
+
+// listInterfaces 网卡列表来源，测试中替换为固定列表
+var listInterfaces = systemInterfaces
+
+// getLocalIP 返回最可能的真实 IP：优先 IPv4，没有可用 IPv4 时使用全局单播 IPv6
+// name 非空时只使用该网卡（不再按虚拟网卡关键词过滤）；exclude 中的网段被跳过
+func getLocalIP(name string, exclude []*net.IPNet) string {
+	ifaces, err := listInterfaces()
+	if err != nil {
+		return ""
+	}
+	return selectLocalIP(ifaces, name, exclude)
+}
+
+func selectLocalIP(ifaces []netInterface, name string, exclude []*net.IPNet) string {
+	var ipv6 string
+	for _, iface := range ifaces {
+		if name != "" && iface.Name != name {
+			continue
+		}
+		// 跳过关闭的网卡与回环网卡，未指定网卡时同时跳过虚拟网卡
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if name == "" && isVirtualInterface(iface.Name) {
+			continue
+		}
+
+		for _, addr := range iface.Addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || !advertisable(ipNet.IP, exclude) {
+				continue
+			}
+			if ipv4 := ipNet.IP.To4(); ipv4 != nil {
+				return ipv4.String()
+			}
+			if ipv6 == "" {
+				ipv6 = ipNet.IP.String()
+			}
+		}
+	}
+	return ipv6
+}
+
+// advertisable 排除回环、链路本地（IPv6 需要 zone，无法跨主机使用）与 exclude 中的地址
+func advertisable(ip net.IP, exclude []*net.IPNet) bool {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range exclude {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVirtualInterface(name string) bool {
+	lower := strings.ToLower(name)
+	for _, kw := range virtualKeywords {
+		if strings.Contains(lower, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCIDRs 解析网段列表
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, s := range cidrs {
+		_, n, err := net.ParseCIDR(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q: %w", s, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// resolveAdvertiseAddress 自注册地址只有端口时补全为 http://<ip>:<port>，IPv6 地址加方括号
+// 使用 AdvertiseAddress，否则按 AdvertiseInterface 与 AdvertiseExclude 选择本机 IP
+func resolveAdvertiseAddress(cfg *Config, port string) (string, error) {
+	host := cfg.AdvertiseAddress
+	if host == "" {
+		exclude, err := parseCIDRs(cfg.AdvertiseExclude)
+		if err != nil {
+			return "", err
+		}
+		host = getLocalIP(cfg.AdvertiseInterface, exclude)
+	}
+	if host == "" {
+		if cfg.AdvertiseInterface != "" {
+			return "", fmt.Errorf("no usable address on interface %q", cfg.AdvertiseInterface)
+		}
+		return "", fmt.Errorf("no usable local address")
+	}
+	return "http://" + net.JoinHostPort(strings.Trim(host, "[]"), port), nil
+}
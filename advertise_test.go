@@ -0,0 +1,103 @@
+package color
+
+import (
+	"net"
+	"testing"
+)
+
+func ipNet(t *testing.T, cidr string) *net.IPNet {
+	t.Helper()
+	ip, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.IP = ip
+	return n
+}
+
+// multiNIC 回环、docker 网桥、私有网段与公网网卡并存的主机
+func multiNIC(t *testing.T) []netInterface {
+	up := net.FlagUp
+	return []netInterface{
+		{Name: "lo", Flags: up | net.FlagLoopback, Addrs: []net.Addr{ipNet(t, "127.0.0.1/8"), ipNet(t, "::1/128")}},
+		{Name: "docker0", Flags: up, Addrs: []net.Addr{ipNet(t, "172.17.0.1/16")}},
+		{Name: "eth0", Flags: up, Addrs: []net.Addr{ipNet(t, "fe80::1/64"), ipNet(t, "10.0.0.5/24")}},
+		{Name: "eth1", Flags: up, Addrs: []net.Addr{ipNet(t, "192.0.2.10/24")}},
+		{Name: "eth2", Flags: 0, Addrs: []net.Addr{ipNet(t, "198.51.100.1/24")}},
+	}
+}
+
+// ipv6Only 只有全局单播 IPv6 的主机
+func ipv6Only(t *testing.T) []netInterface {
+	return []netInterface{
+		{Name: "lo", Flags: net.FlagUp | net.FlagLoopback, Addrs: []net.Addr{ipNet(t, "::1/128")}},
+		{Name: "eth0", Flags: net.FlagUp, Addrs: []net.Addr{ipNet(t, "fe80::1/64"), ipNet(t, "2001:db8::5/64")}},
+	}
+}
+
+func TestSelectLocalIPMultiNIC(t *testing.T) {
+	ifaces := multiNIC(t)
+	cases := []struct {
+		name    string
+		iface   string
+		exclude []string
+		want    string
+	}{
+		{"skips loopback and docker bridge", "", nil, "10.0.0.5"},
+		{"excluded private range", "", []string{"10.0.0.0/8"}, "192.0.2.10"},
+		{"named interface", "eth1", nil, "192.0.2.10"},
+		// 指定网卡时不再按虚拟网卡关键词过滤
+		{"named virtual interface", "docker0", nil, "172.17.0.1"},
+		{"named interface that is down", "eth2", nil, ""},
+		{"everything excluded", "", []string{"10.0.0.0/8", "192.0.2.0/24"}, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			exclude, err := parseCIDRs(c.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := selectLocalIP(ifaces, c.iface, exclude); got != c.want {
+				t.Errorf("selectLocalIP = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestSelectLocalIPIPv6Only(t *testing.T) {
+	if got := selectLocalIP(ipv6Only(t), "", nil); got != "2001:db8::5" {
+		t.Errorf("selectLocalIP = %q, want the global IPv6 address", got)
+	}
+
+	// 有 IPv4 时优先 IPv4，即使 IPv6 所在网卡排在前面
+	ifaces := append(ipv6Only(t), netInterface{Name: "eth1", Flags: net.FlagUp, Addrs: []net.Addr{ipNet(t, "192.0.2.10/24")}})
+	if got := selectLocalIP(ifaces, "", nil); got != "192.0.2.10" {
+		t.Errorf("selectLocalIP with IPv4 available = %q, want 192.0.2.10", got)
+	}
+}
+
+func TestResolveAdvertiseAddress(t *testing.T) {
+	orig := listInterfaces
+	t.Cleanup(func() { listInterfaces = orig })
+
+	listInterfaces = func() ([]netInterface, error) { return ipv6Only(t), nil }
+	if got, err := resolveAdvertiseAddress(&Config{}, "8080"); err != nil || got != "http://[2001:db8::5]:8080" {
+		t.Errorf("IPv6-only host: address = %q, %v, want a bracketed IPv6 URL", got, err)
+	}
+
+	listInterfaces = func() ([]netInterface, error) { return multiNIC(t), nil }
+	cfg := &Config{AdvertiseInterface: "eth1"}
+	if got, err := resolveAdvertiseAddress(cfg, "8080"); err != nil || got != "http://192.0.2.10:8080" {
+		t.Errorf("interface eth1: address = %q, %v", got, err)
+	}
+	cfg = &Config{AdvertiseInterface: "eth2"}
+	if _, err := resolveAdvertiseAddress(cfg, "8080"); err == nil {
+		t.Error("interface without a usable address: want an error")
+	}
+
+	for _, addr := range []string{"2001:db8::7", "[2001:db8::7]"} {
+		if got, err := resolveAdvertiseAddress(&Config{AdvertiseAddress: addr}, "9090"); err != nil || got != "http://[2001:db8::7]:9090" {
+			t.Errorf("AdvertiseAddress %q: address = %q, %v, want http://[2001:db8::7]:9090", addr, got, err)
+		}
+	}
+}
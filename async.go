@@ -0,0 +1,88 @@
+package color
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultAsyncWorkers = 64
+	defaultAsyncQueue   = 64
+)
+
+// WithAsyncWorkers 异步任务（流量镜像等）的 worker 数与排队上限，默认 64 个 worker、排队 64 个
+// 所有 worker 忙且队列已满时丢弃新任务，丢弃次数计入 Stats 的 async_dropped 与错误原因 async_dropped
+func WithAsyncWorkers(n, queue int) Option {
+	return func(c *Config) {
+		c.AsyncWorkers = n
+		c.AsyncQueue = queue
+	}
+}
+
+// asyncTask 异步任务：run 由 worker 执行；任务被丢弃（队列已满或代理关闭）时调用 discard 释放资源
+type asyncTask struct {
+	run     func()
+	discard func()
+}
+
+// asyncPool 固定数量 worker 的任务池，避免每个异步任务单独启动 goroutine
+type asyncPool struct {
+	tasks   chan asyncTask
+	dropped atomic.Uint64
+}
+
+func newAsyncPool(queue int) *asyncPool {
+	return &asyncPool{tasks: make(chan asyncTask, queue)}
+}
+
+// start 启动 n 个 worker，ctx 取消后退出并丢弃队列中剩余的任务
+func (a *asyncPool) start(ctx context.Context, wg *sync.WaitGroup, n int) {
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					a.drain()
+					return
+				case task := <-a.tasks:
+					task.run()
+				}
+			}
+		}()
+	}
+}
+
+// submit 提交任务，队列已满时丢弃任务并返回 false
+func (a *asyncPool) submit(run, discard func()) bool {
+	select {
+	case a.tasks <- asyncTask{run: run, discard: discard}:
+		return true
+	default:
+		a.dropped.Add(1)
+		discard()
+		return false
+	}
+}
+
+func (a *asyncPool) drain() {
+	for {
+		select {
+		case task := <-a.tasks:
+			task.discard()
+		default:
+			return
+		}
+	}
+}
+
+// runAsync 把任务交给异步任务池，被丢弃时记录 async_dropped
+func (p *Proxy) runAsync(color string, run, discard func()) bool {
+	if p.async.submit(run, discard) {
+		return true
+	}
+	p.observeError(color, "async_dropped")
+	return false
+}
@@ -0,0 +1,48 @@
+package color
+
+import (
+	"testing"
+)
+
+func TestAsyncPoolDropsWhenQueueFull(t *testing.T) {
+	p := newTestProxy(t, WithMirror("blue", "shadow", 1), WithAsyncWorkers(1, 1))
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	if !p.runAsync("blue", func() { close(started); <-block }, func() {}) {
+		t.Fatal("first task dropped")
+	}
+	<-started
+	defer close(block)
+
+	// worker 忙时第二个任务排队，第三个任务被丢弃
+	if !p.runAsync("blue", func() {}, func() {}) {
+		t.Fatal("queued task dropped")
+	}
+	discarded := false
+	if p.runAsync("blue", func() { t.Error("dropped task ran") }, func() { discarded = true }) {
+		t.Fatal("task accepted with a full queue")
+	}
+	if !discarded {
+		t.Error("discard not called for the dropped task")
+	}
+
+	stats := p.Stats()
+	if stats.AsyncDropped != 1 || stats.AsyncQueued != 1 {
+		t.Errorf("async_dropped = %d, async_queued = %d, want 1, 1", stats.AsyncDropped, stats.AsyncQueued)
+	}
+	if stats.Errors["async_dropped"] != 1 {
+		t.Errorf("errors[async_dropped] = %d, want 1", stats.Errors["async_dropped"])
+	}
+}
+
+func TestAsyncPoolDiscardsQueuedTasksOnShutdown(t *testing.T) {
+	pool := newAsyncPool(2)
+	discarded := 0
+	pool.submit(func() { t.Error("task ran without workers") }, func() { discarded++ })
+	pool.submit(func() { t.Error("task ran without workers") }, func() { discarded++ })
+	pool.drain()
+	if discarded != 2 {
+		t.Errorf("discarded = %d, want 2", discarded)
+	}
+}
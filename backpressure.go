@@ -0,0 +1,37 @@
+package color
+
+import (
+	"time"
+)
+
+// 背压感知的后台任务调度
+//
+// 当进行中的请求数超过高水位 BackpressureHighWater 时，后台任务让出后端连接给请求路径：
+//  1. 清理过期路由（非关键）：直接跳过本轮
+//  2. 自心跳：仅在 TTL 安全余量允许时跳过。
+//     设上次成功续期时间为 last，则路由在 last+TTL 过期。若下一次心跳（now+HeartbeatRate，启用抖动时按最长间隔计算）
+//     仍早于 last+TTL-BackpressureSafetyMargin，说明本轮跳过后下一轮仍来得及续期，可以跳过；
+//     否则无论负载多高都照常心跳，保证路由不会因背压而过期。
+//
+// 负载回落到高水位以下后立即恢复正常节奏。
+
+// overloaded 判断当前是否处于高负载（未启用背压时总是 false）
+func (p *Proxy) overloaded() bool {
+	hw := p.config.BackpressureHighWater
+	return hw > 0 && p.inflight.Load() > int64(hw)
+}
+
+// canSkipHeartbeat 判断本轮自心跳能否在 TTL 安全余量内跳过
+func (p *Proxy) canSkipHeartbeat() bool {
+	last := p.lastBeat.Load()
+	if last == 0 {
+		return false
+	}
+	ttl := p.config.TTL
+	if p.config.LocalTTL > 0 {
+		ttl = p.config.LocalTTL
+	}
+	deadline := time.Unix(0, last).Add(ttl - p.config.BackpressureSafetyMargin)
+	next := time.Duration(float64(p.config.HeartbeatRate) * (1 + p.config.HeartbeatJitter))
+	return time.Now().Add(next).Before(deadline)
+}
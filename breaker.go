@@ -0,0 +1,75 @@
+package color
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/asam264/color/internal/transport"
+)
+
+// breakerChanged 熔断器状态变化：记录日志并发出 BreakerStateChanged 事件
+func (p *Proxy) breakerChanged(req *http.Request, t transport.BreakerTransition) {
+	color := p.requestColor(req, nil)
+	if t.To == transport.BreakerClosed {
+		p.config.Logger.Info("circuit breaker %s -> %s: target=%s, color=%s", t.From, t.To, t.Target, color)
+	} else {
+		p.config.Logger.Error("circuit breaker %s -> %s: target=%s, color=%s, failures=%d", t.From, t.To, t.Target, color, t.Failures)
+	}
+	p.emitEvent(RouteEvent{
+		Type:        BreakerStateChanged,
+		Color:       color,
+		Address:     t.Target,
+		BreakerFrom: t.From.String(),
+		BreakerTo:   t.To.String(),
+		Failures:    t.Failures,
+	})
+}
+
+// breakerInfo GET /breakers 中单个 target 的熔断状态
+type breakerInfo struct {
+	Target   string     `json:"target"`
+	Colors   []string   `json:"colors"` // 路由表中使用该地址的路由 key
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+// handleBreakers 返回各 target 当前的熔断状态，按 target 排序；未启用熔断时为空列表
+func (p *Proxy) handleBreakers(w http.ResponseWriter, r *http.Request) {
+	breakers := []breakerInfo{}
+	if bs, ok := p.http.(breakerStater); ok {
+		states := bs.BreakerStates()
+
+		colors := make(map[string][]string)
+		p.events.mu.Lock()
+		for key, addresses := range p.events.known {
+			for _, address := range addresses {
+				if _, ok := states[address]; ok {
+					colors[address] = append(colors[address], key)
+				}
+			}
+		}
+		p.events.mu.Unlock()
+
+		for target, status := range states {
+			info := breakerInfo{
+				Target:   target,
+				Colors:   colors[target],
+				State:    status.State.String(),
+				Failures: status.Failures,
+			}
+			if info.Colors == nil {
+				info.Colors = []string{}
+			}
+			sort.Strings(info.Colors)
+			if !status.OpenedAt.IsZero() {
+				openedAt := status.OpenedAt
+				info.OpenedAt = &openedAt
+			}
+			breakers = append(breakers, info)
+		}
+	}
+	sort.Slice(breakers, func(i, j int) bool { return breakers[i].Target < breakers[j].Target })
+	writeJSON(w, http.StatusOK, jsonMap{"breakers": breakers})
+}
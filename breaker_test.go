@@ -0,0 +1,111 @@
+package color
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// nextBreakerEvent 跳过路由事件，返回下一个熔断事件
+func nextBreakerEvent(t *testing.T, events chan RouteEvent) RouteEvent {
+	t.Helper()
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == BreakerStateChanged {
+				return ev
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no breaker event")
+		}
+	}
+}
+
+func TestBreakerStateChangeEvents(t *testing.T) {
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	events := make(chan RouteEvent, 16)
+	p := newTestProxy(t,
+		WithCircuitBreaker(2, 50*time.Millisecond),
+		WithRouteListener(func(ev RouteEvent) { events <- ev }),
+	)
+	register(t, p, &backend.Route{Color: "beta", Address: srv.URL, Token: "t"})
+
+	serveColor(p, "beta")
+	serveColor(p, "beta")
+	ev := nextBreakerEvent(t, events)
+	if ev.BreakerFrom != "closed" || ev.BreakerTo != "open" || ev.Color != "beta" || ev.Address != srv.URL || ev.Failures != 2 {
+		t.Errorf("open event = %+v, want closed -> open for beta at %s after 2 failures", ev, srv.URL)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	if code := serveColor(p, "beta"); code != http.StatusOK {
+		t.Fatalf("probe status = %d, want 200", code)
+	}
+	if ev := nextBreakerEvent(t, events); ev.BreakerFrom != "open" || ev.BreakerTo != "half-open" {
+		t.Errorf("probe event = %+v, want open -> half-open", ev)
+	}
+	if ev := nextBreakerEvent(t, events); ev.BreakerFrom != "half-open" || ev.BreakerTo != "closed" {
+		t.Errorf("recovery event = %+v, want half-open -> closed", ev)
+	}
+}
+
+func TestBreakersEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	p := newTestProxy(t, WithCircuitBreaker(1, time.Minute))
+	waitRoutesLoaded(t, p)
+	register(t, p, &backend.Route{Color: "beta", Address: srv.URL, Token: "t"})
+	routes, err := p.backend.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.refreshRoutes(routes)
+
+	serveColor(p, "beta")
+	if code := serveColor(p, "beta"); code != http.StatusServiceUnavailable {
+		t.Fatalf("status with open breaker = %d, want 503", code)
+	}
+
+	rec := serve(p, httptest.NewRequest(http.MethodGet, "/colorproxy/breakers", nil))
+	var body struct {
+		Breakers []breakerInfo `json:"breakers"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Breakers) != 1 {
+		t.Fatalf("breakers = %+v, want one target", body.Breakers)
+	}
+	b := body.Breakers[0]
+	if b.Target != srv.URL || b.State != "open" || b.Failures != 1 || b.OpenedAt == nil {
+		t.Errorf("breaker = %+v, want %s open after 1 failure", b, srv.URL)
+	}
+	if len(b.Colors) != 1 || b.Colors[0] != "beta" {
+		t.Errorf("colors = %v, want [beta]", b.Colors)
+	}
+}
+
+func TestBreakersEndpointWithoutBreaker(t *testing.T) {
+	p := newTestProxy(t)
+	rec := serve(p, httptest.NewRequest(http.MethodGet, "/colorproxy/breakers", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"breakers":[]}` {
+		t.Errorf("response = %d %q, want an empty list", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,346 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotFound 路由不存在
+var ErrNotFound = errors.New("route not found")
+
+// Client 管理端点客户端：通过 HTTP 调用运行中代理的管理接口（默认前缀 /colorproxy）
+type Client struct {
+	baseURL     string
+	prefix      string
+	adminToken  string
+	tokenHeader string
+	httpClient  *http.Client
+}
+
+// Option 客户端配置选项
+type Option func(*Client)
+
+// WithAdminToken 设置管理端点的 admin token（以 Authorization: Bearer 发送）
+func WithAdminToken(token string) Option {
+	return func(c *Client) {
+		c.adminToken = token
+	}
+}
+
+// WithAdminTokenHeader 以指定 header 发送 admin token（值为 token 本身），需与代理的 WithAdminTokenHeader 一致
+// 为空或 "Authorization" 时仍以 Authorization: Bearer 发送
+func WithAdminTokenHeader(name string) Option {
+	return func(c *Client) {
+		c.tokenHeader = name
+	}
+}
+
+// WithPrefix 设置管理端点前缀，需与代理的 WithAdminPrefix 一致（默认 /colorproxy）
+func WithPrefix(prefix string) Option {
+	return func(c *Client) {
+		c.prefix = "/" + strings.Trim(prefix, "/")
+	}
+}
+
+// WithHTTPClient 自定义底层 http.Client
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// New 创建客户端，baseURL 为代理地址，如 "http://localhost:8080"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		prefix:     "/colorproxy",
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Route 路由信息（与服务端 /routes 返回的结构一致）
+type Route struct {
+	Color     string        `json:"Color"`
+	Address   string        `json:"Address"`
+	Endpoints []Endpoint    `json:"Endpoints"`
+	Owner     string        `json:"Owner"`
+	Token     string        `json:"Token"`
+	ExpiresAt time.Time     `json:"ExpiresAt"`
+	TTL       time.Duration `json:"TTL"`     // 路由自身的 TTL，0 表示使用代理的默认 TTL
+	Version   string        `json:"Version"` // 路由版本，空表示该 color 的默认路由
+
+	Labels map[string]string `json:"Labels,omitempty"` // 路由标签
+}
+
+// Endpoint 带权重的后端地址，Weight 为 0 表示不再分配新流量
+type Endpoint struct {
+	Address string `json:"Address"`
+	Weight  int    `json:"Weight"`
+}
+
+// RegisterEndpoint 注册请求中的后端地址
+type RegisterEndpoint struct {
+	Address string `json:"address"`
+	Weight  int    `json:"weight"`
+}
+
+// RegisterRequest 注册请求，Address 与 Endpoints 至少设置一个
+type RegisterRequest struct {
+	Color     string             `json:"color"`
+	Address   string             `json:"address,omitempty"`
+	Endpoints []RegisterEndpoint `json:"endpoints,omitempty"`
+	Owner     string             `json:"owner,omitempty"`
+	Token     string             `json:"token"`
+
+	// TTLSeconds 路由自身的 TTL（秒），0 表示使用代理的默认 TTL，最大 24 小时
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
+
+	// Version 路由版本，请求携带相同的版本 header 时优先转发到该路由
+	Version string `json:"version,omitempty"`
+
+	// Exclusive 独占注册：color 已被其他 token 注册时返回 409 的 *StatusError，而不是覆盖
+	Exclusive bool `json:"exclusive,omitempty"`
+
+	// Labels 路由标签（如 region），最多 16 个
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// RegisterResponse 注册响应
+type RegisterResponse struct {
+	Message string `json:"message"`
+	Color   string `json:"color"`
+	Version string `json:"version,omitempty"`
+}
+
+// HeartbeatRequest 心跳请求，Version 与注册时一致
+type HeartbeatRequest struct {
+	Color   string `json:"color"`
+	Version string `json:"version,omitempty"`
+	Address string `json:"address"`
+	Token   string `json:"token"`
+
+	// 以下字段与注册时一致：代理开启 WithHeartbeatReregister 且路由已过期时据此重新注册，
+	// 否则重新注册的路由会丢失 owner、标签、TTL 与额外的 endpoints
+	Endpoints  []RegisterEndpoint `json:"endpoints,omitempty"`
+	Owner      string             `json:"owner,omitempty"`
+	TTLSeconds int64              `json:"ttl_seconds,omitempty"`
+	Labels     map[string]string  `json:"labels,omitempty"`
+}
+
+// HeartbeatResponse 心跳响应
+type HeartbeatResponse struct {
+	Message string `json:"message"`
+}
+
+// ListResponse 路由列表响应
+type ListResponse struct {
+	Routes []*Route `json:"routes"`
+	Count  int      `json:"count"`
+}
+
+// DeleteResponse 删除响应
+type DeleteResponse struct {
+	Message string `json:"message"`
+	Color   string `json:"color"`
+}
+
+// ResolveResponse 解析结果
+type ResolveResponse struct {
+	Color  string `json:"color"`
+	Target string `json:"target"` // 转发目标地址，Local 为 true 时为空
+	Route  string `json:"route"`  // 实际命中的路由：带版本时为 "<color>:<version>"，沿回退链命中时为回退 color
+	Local  bool   `json:"local"`  // 请求应由代理本地处理
+}
+
+// StatusError 服务端返回非 2xx 时的错误
+// 服务端使用 problem+json（WithProblemJSON）时，Message 为 title，Detail 为 detail
+type StatusError struct {
+	StatusCode int
+	Message    string
+	Detail     string
+}
+
+func (e *StatusError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("colorproxy: status %d: %s: %s", e.StatusCode, e.Message, e.Detail)
+	}
+	return fmt.Sprintf("colorproxy: status %d: %s", e.StatusCode, e.Message)
+}
+
+// Register 注册路由
+func (c *Client) Register(ctx context.Context, req *RegisterRequest) (*RegisterResponse, error) {
+	var resp RegisterResponse
+	if err := c.do(ctx, http.MethodPost, "/register", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Heartbeat 心跳续期
+func (c *Client) Heartbeat(ctx context.Context, req *HeartbeatRequest) (*HeartbeatResponse, error) {
+	var resp HeartbeatResponse
+	if err := c.do(ctx, http.MethodPost, "/heartbeat", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// List 列出所有路由
+func (c *Client) List(ctx context.Context) ([]*Route, error) {
+	var resp ListResponse
+	if err := c.do(ctx, http.MethodGet, "/routes", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Routes, nil
+}
+
+// ListFilter 路由列表的过滤条件，空字段表示不过滤
+type ListFilter struct {
+	Owner string
+	Color string // 以 * 结尾表示前缀匹配
+}
+
+// ListFiltered 按 owner 与 color 过滤后列出路由
+func (c *Client) ListFiltered(ctx context.Context, f ListFilter) ([]*Route, error) {
+	query := url.Values{}
+	if f.Owner != "" {
+		query.Set("owner", f.Owner)
+	}
+	if f.Color != "" {
+		query.Set("color", f.Color)
+	}
+	path := "/routes"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp ListResponse
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Routes, nil
+}
+
+// Delete 强制删除路由（?force=true），需配置 admin token
+func (c *Client) Delete(ctx context.Context, color string) (*DeleteResponse, error) {
+	var resp DeleteResponse
+	if err := c.do(ctx, http.MethodDelete, "/routes/"+url.PathEscape(color)+"?force=true", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteWithToken 以路由注册时的 token 证明归属后删除路由
+// token 不一致返回 403 的 *StatusError，路由不存在返回 404 的 *StatusError
+func (c *Client) DeleteWithToken(ctx context.Context, color, token string) (*DeleteResponse, error) {
+	var resp DeleteResponse
+	body := map[string]string{"token": token}
+	if err := c.do(ctx, http.MethodDelete, "/routes/"+url.PathEscape(color), body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Resolve 按代理转发时的选择流程（回退链等）解析 color 的目标，不转发请求
+// color 没有可用路由时返回 ErrNotFound
+func (c *Client) Resolve(ctx context.Context, color string) (*ResolveResponse, error) {
+	return c.ResolveVersion(ctx, color, "")
+}
+
+// ResolveVersion 解析 color 指定版本的目标，版本没有路由时与转发一致地回退到默认路由
+func (c *Client) ResolveVersion(ctx context.Context, color, version string) (*ResolveResponse, error) {
+	query := url.Values{"color": {color}}
+	if version != "" {
+		query.Set("version", version)
+	}
+
+	var resp ResolveResponse
+	err := c.do(ctx, http.MethodGet, "/resolve?"+query.Encode(), nil, &resp)
+	var se *StatusError
+	if errors.As(err, &se) && se.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do 发送请求并解析 JSON 响应
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+c.prefix+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.adminToken != "" {
+		if c.tokenHeader != "" && !strings.EqualFold(c.tokenHeader, "Authorization") {
+			req.Header.Set(c.tokenHeader, c.adminToken)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+c.adminToken)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp.StatusCode, data)
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// statusError 解析错误响应：{"error": ...} 或 problem+json 的 title/detail，都不是时使用原始 body
+func statusError(status int, data []byte) *StatusError {
+	var e struct {
+		Error  string `json:"error"`
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	se := &StatusError{StatusCode: status, Message: strings.TrimSpace(string(data))}
+	if json.Unmarshal(data, &e) != nil {
+		return se
+	}
+	switch {
+	case e.Title != "":
+		se.Message, se.Detail = e.Title, e.Detail
+	case e.Error != "":
+		se.Message, se.Detail = e.Error, e.Detail
+	}
+	return se
+}
@@ -0,0 +1,79 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/asam264/color"
+	"github.com/asam264/color/client"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+func newServer(t *testing.T, opts ...color.Option) string {
+	t.Helper()
+	p, err := color.New(append([]color.Option{color.WithMemoryBackend(), color.WithLogger(nopLogger{})}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(p.Handler())
+	t.Cleanup(func() {
+		srv.Close()
+		p.Shutdown(context.Background())
+	})
+	return srv.URL
+}
+
+func TestResolveUsesServerSelection(t *testing.T) {
+	url := newServer(t, color.WithFallback(map[string][]string{"canary": {"stable"}}))
+	c := client.New(url)
+	ctx := context.Background()
+
+	if _, err := c.Register(ctx, &client.RegisterRequest{Color: "stable", Address: "http://10.0.0.1:8080", Token: "t"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	res, err := c.Resolve(ctx, "canary")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if res.Target != "http://10.0.0.1:8080" || res.Route != "stable" {
+		t.Errorf("Resolve = %+v, want the stable fallback", res)
+	}
+
+	if _, err := c.Resolve(ctx, "missing"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("Resolve missing: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestProblemJSONErrors(t *testing.T) {
+	url := newServer(t, color.WithAdminToken("secret"), color.WithProblemJSON(true))
+	_, err := client.New(url).List(context.Background())
+
+	var se *client.StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("err = %v, want *StatusError", err)
+	}
+	if se.StatusCode != 401 || se.Message != "unauthorized" || se.Detail != "missing or invalid admin token" {
+		t.Errorf("StatusError = %+v, want problem+json title and detail", se)
+	}
+}
+
+func TestAdminTokenHeader(t *testing.T) {
+	url := newServer(t, color.WithAdminToken("secret"), color.WithAdminTokenHeader("X-Admin-Token"))
+
+	c := client.New(url, client.WithAdminToken("secret"), client.WithAdminTokenHeader("X-Admin-Token"))
+	if _, err := c.List(context.Background()); err != nil {
+		t.Fatalf("List with custom header: %v", err)
+	}
+
+	c = client.New(url, client.WithAdminToken("secret"))
+	if _, err := c.List(context.Background()); err == nil {
+		t.Error("List with Authorization succeeded although a custom header is configured")
+	}
+}
@@ -2,27 +2,56 @@ package color
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
-	"net"
+	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/asam264/color/internal/backend"
 	"github.com/asam264/color/internal/strategy"
 	"github.com/asam264/color/internal/transport"
-	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 )
 
 // Proxy 核心代理对象
 type Proxy struct {
-	backend  backend.Backend
-	http     transport.HTTPTransporter
-	grpc     transport.GRPCTransporter
-	strategy strategy.Strategy
+	backend     backend.Backend
+	http        transport.HTTPTransporter
+	grpc        transport.GRPCTransporter
+	strategy    strategy.Strategy
+	snapshot    *backend.SnapshotBackend
+	cache       *backend.CacheBackend
+	health      *healthState
+	events      *eventBus
+	limiter     *rateLimiter
+	concurrency *concurrencyLimiter
+	mirror      *mirrorer
+	mirrorKey   []byte
+	async       *asyncPool
+	counters    proxyCounters
+	labels      *routeLabels
+	rollback    *rollbackMonitor
+
+	maintenance maintenanceState
+
+	// 进行中的转发请求，Shutdown 时等待其完成
+	drain drainState
+
+	// 最近一次 ReportAlive 的时间（UnixNano），用于存活看门狗
+	lastAlive atomic.Int64
+
+	// 进行中的请求数、最近一次成功自注册/心跳的时间（UnixNano），用于背压调度
+	inflight atomic.Int64
+	lastBeat atomic.Int64
 
 	config *Config
 	ctx    context.Context
@@ -39,20 +68,193 @@ type Config struct {
 	HTTPTransport transport.HTTPTransporter
 	GRPCTransport transport.GRPCTransporter
 
+	// 内置 HTTP 传输层参数（仅在未自定义 HTTPTransport 时生效）
+	HTTPTimeout time.Duration
+	HTTPOptions []transport.HTTPOption
+
+	// WithRequestTimeout 与 WithRetry 的全局设置，转发时由 HTTPOptions 生效，此处用于 WithColorPolicy 的合并与展示
+	RequestTimeout time.Duration
+	RetryAttempts  int
+
+	// 内置 gRPC 传输层参数（仅在未自定义 GRPCTransport 时生效）
+	GRPCTimeout time.Duration
+	GRPCOptions []transport.GRPCOption
+
 	// 路由策略
 	Strategy strategy.Strategy
 
+	// 内置策略的构造函数（需要在 backend 确定后创建），Strategy 非空时忽略
+	StrategyFactory func(b backend.Backend) strategy.Strategy
+
+	// color 内变体分流（可选）：color -> (变体名 -> 权重)
+	ColorVariants map[string]map[string]int
+
+	// 按路由剩余 TTL 衰减变体与金丝雀的分流权重（可选），0 表示不衰减
+	ExpiryWeightDecay time.Duration
+
+	// 按前缀或正则把请求 color 解析为已注册的 color（可选），见 WithPatternStrategy
+	ColorPatterns []strategy.PatternRule
+
+	// 金丝雀分流（可选）：忽略请求 color，按 CanaryPercent 在 CanaryStable / CanaryColor 间分流
+	CanaryStable  string
+	CanaryColor   string
+	CanaryPercent int
+
+	// 金丝雀自动回滚（可选），见 WithAutoRollback
+	AutoRollback *AutoRollbackConfig
+
+	// 路由查询超时（可选）：超时后返回 LookupTimeoutStatus，0 表示按未找到处理（回退本地）
+	LookupTimeout       time.Duration
+	LookupTimeoutStatus int
+
+	// 受信任的上游代理（IP 或 CIDR），由 WithTrustedProxies 设置
+	TrustedProxies []string
+
+	// 严格路由（纯网关）：缺少 color 返回 400，color 没有可用路由返回 503，不再交给本地处理
+	StrictRouting bool
+
 	// TTL 配置
 	TTL           time.Duration
 	HeartbeatRate time.Duration
 	CleanupRate   time.Duration
 
+	// 自心跳与清理任务的间隔抖动比例（0 表示不抖动），见 WithHeartbeatJitter
+	HeartbeatJitter float64
+
 	// 自注册配置（可选）
 	AutoRegister bool
 	LocalColor   string
 	LocalAddress string
 	LocalToken   string
 	LocalOwner   string
+	LocalTTL     time.Duration // 自注册路由的 TTL，0 表示使用 TTL
+	LocalLabels  map[string]string
+
+	// 自注册地址只有端口时使用的 IP：AdvertiseAddress 优先，否则从 AdvertiseInterface 网卡
+	// （为空时自动选择非虚拟网卡）选取不在 AdvertiseExclude 网段内的地址，IPv4 优先
+	AdvertiseAddress   string
+	AdvertiseInterface string
+	AdvertiseExclude   []string
+
+	// 独占注册：color 已被其他 token 注册时拒绝（409），而不是覆盖
+	ExclusiveRegister bool
+
+	// 心跳时路由已不存在（两次心跳之间 TTL 到期）则重新注册，而不是返回错误
+	HeartbeatReregister bool
+
+	// 背压：进行中的请求数超过高水位时推迟非关键后台任务，0 表示关闭
+	BackpressureHighWater    int
+	BackpressureSafetyMargin time.Duration
+
+	// 存活看门狗：超过该时间未调用 ReportAlive 则停止自心跳，0 表示关闭
+	LivenessDeadline time.Duration
+
+	// 本地快照（可选）
+	SnapshotFile     string
+	SnapshotInterval time.Duration
+
+	// 本地路由缓存：后端支持 Watch 时按路由变化维护缓存，否则按 RouteCacheTTL 缓存查询结果
+	RouteCache    bool
+	RouteCacheTTL time.Duration
+
+	// 管理页面（可选）
+	AdminUI bool
+
+	// 管理端点路径前缀，默认 /colorproxy
+	AdminPrefix string
+
+	// 路径匹配的规范化方式（大小写、末尾 /），只影响匹配，见 WithPathNormalization
+	PathNormalization PathNormalization
+
+	// 管理认证：配置后 AdminPrefix 下的管理端点需要认证
+	AdminToken       string
+	AdminTokenHeader string
+
+	// 受信任请求的目标覆盖 header（可选，需 admin 认证）
+	TargetOverrideHeader string
+
+	// color 提取方式，默认读取 color header
+	ColorExtractor ColorExtractor
+
+	// 请求未携带 color 时使用的默认 color（可选）
+	DefaultColor string
+
+	// 按 Content-Type 路由（可选）
+	ContentTypeRoutes   map[string]string
+	ContentTypeOverride bool
+
+	// 回退链（可选）：color -> 没有路由时依次尝试的 color
+	Fallbacks map[string][]string
+
+	// 按 color 限流（可选）：每个 color 独立的令牌桶，RateLimitExempt 中的 color 不限流
+	RateLimit       rate.Limit
+	RateLimitBurst  int
+	RateLimitExempt []string
+
+	// 按 color 限制同时进行的转发数（可选），0 表示不限制；
+	// 名额已满时最多排队等待 ConcurrencyQueueTimeout，为 0 时立即返回 503
+	MaxConcurrency          int
+	ConcurrencyQueueTimeout time.Duration
+
+	// 按 color 覆盖超时、重试、限流、并发与方法/header 规则（可选），见 WithColorPolicy
+	ColorPolicies map[string]ColorPolicy
+
+	// 流量镜像（可选）：来源 color -> 镜像配置
+	Mirrors       map[string]MirrorConfig
+	MirrorTimeout time.Duration
+
+	// 异步任务池：worker 数与排队上限，见 WithAsyncWorkers
+	AsyncWorkers int
+	AsyncQueue   int
+
+	// 请求 body 缓冲上限，由 WithMaxBufferedBody 设置，镜像请求同样使用该上限
+	MaxBufferedBody int64
+
+	// 转发前授权钩子（可选）
+	ProxyAuthorizer      ProxyAuthorizer
+	AuthorizerDenyStatus int
+
+	// 错误响应使用 RFC 7807 problem+json
+	ProblemJSON bool
+
+	// 跨域（可选）：配置后由代理本地应答预检请求，CORSPassthrough 为 true 时仍转发给后端
+	CORS            *CORSConfig
+	CORSPassthrough bool
+
+	// 维护模式响应
+	MaintenanceStatus     int
+	MaintenanceBody       string
+	MaintenanceRetryAfter time.Duration
+
+	// 主动健康检查（可选）
+	HealthCheckPath     string
+	HealthCheckInterval time.Duration
+	HealthCheckTimeout  time.Duration
+
+	// 就绪探针（/healthz、/readyz）检查后端连接的超时，默认 2 秒
+	ReadinessTimeout time.Duration
+
+	// 请求 ID header（可选），由 WithRequestID 设置
+	RequestIDHeader string
+
+	// 路由版本 header：请求携带时优先转发到 color+version 的路由，默认 x-version
+	VersionHeader string
+
+	// 指标采集（可选）
+	Metrics MetricsCollector
+	// 请求指标额外携带的路由标签（可选），见 WithMetricLabels
+	MetricLabels []string
+	// 连接池采样间隔（可选），0 表示不采样，见 WithConnPoolMetrics
+	ConnPoolInterval time.Duration
+
+	// 转发链路追踪（可选）
+	Tracer ForwardTracer
+
+	// 访问日志（可选），配置后内置 HTTP 传输层日志也转交给它
+	AccessLogger AccessLogger
+
+	// 路由事件监听（可选）
+	RouteListeners []RouteListener
 
 	// 日志
 	Logger Logger
@@ -92,6 +294,70 @@ func WithRedis(addr, password string, db int) Option {
 	}
 }
 
+// WithEtcd 使用 etcd 后端
+func WithEtcd(endpoints []string, username, password string) Option {
+	return func(c *Config) {
+		backend, err := backend.NewEtcdBackend(&backend.EtcdConfig{
+			Endpoints: endpoints,
+			Username:  username,
+			Password:  password,
+		})
+		if err != nil {
+			panic(err) // 初始化失败直接panic，外部可以recover
+		}
+		c.Backend = backend
+	}
+}
+
+// WithConsul 使用 Consul 后端：路由存储在 KV 中，由带 TTL 的 session 持有，心跳续期 session
+func WithConsul(address, token, datacenter string) Option {
+	return func(c *Config) {
+		backend, err := backend.NewConsulBackend(&backend.ConsulConfig{
+			Address:    address,
+			Token:      token,
+			Datacenter: datacenter,
+		})
+		if err != nil {
+			panic(err) // 初始化失败直接panic，外部可以recover
+		}
+		c.Backend = backend
+	}
+}
+
+// SQL 方言，用于 WithSQL 与 MigrateSQL
+const (
+	SQLDialectPostgres = backend.DialectPostgres
+	SQLDialectMySQL    = backend.DialectMySQL
+)
+
+// WithSQL 使用关系型数据库后端（Postgres / MySQL），路由持久化在 colorproxy_routes 表中
+// db 由调用方创建（需引入对应的驱动，MySQL 的 DSN 需要 parseTime=true），表结构可通过 MigrateSQL 创建
+func WithSQL(db *sql.DB, dialect string) Option {
+	return func(c *Config) {
+		backend, err := backend.NewSQLBackend(db, &backend.SQLConfig{Dialect: dialect})
+		if err != nil {
+			panic(err) // 初始化失败直接panic，外部可以recover
+		}
+		c.Backend = backend
+	}
+}
+
+// MigrateSQL 为 WithSQL 创建 colorproxy_routes 表（已存在时不做处理）
+func MigrateSQL(ctx context.Context, db *sql.DB, dialect string) error {
+	b, err := backend.NewSQLBackend(db, &backend.SQLConfig{Dialect: dialect})
+	if err != nil {
+		return err
+	}
+	return b.Migrate(ctx)
+}
+
+// WithMemoryBackend 使用内存后端（测试和单节点部署，无需外部依赖）
+func WithMemoryBackend() Option {
+	return func(c *Config) {
+		c.Backend = backend.NewMemoryBackend()
+	}
+}
+
 // WithBackend 自定义后端
 func WithBackend(b backend.Backend) Option {
 	return func(c *Config) {
@@ -100,16 +366,70 @@ func WithBackend(b backend.Backend) Option {
 }
 
 // WithHTTPTransport 使用 HTTP 传输
+// 传输层在 New 中创建，以便与其他 HTTP 传输选项组合
 func WithHTTPTransport(timeout time.Duration) Option {
 	return func(c *Config) {
-		c.HTTPTransport = transport.NewHTTPTransport(timeout)
+		c.HTTPTransport = nil
+		c.HTTPTimeout = timeout
+	}
+}
+
+// WithIsolatedPools 为每个后端 target 使用独立的连接池
+// 以内存换隔离：空闲连接按 target 分别保留，空闲 target 会被定期淘汰
+func WithIsolatedPools(enabled bool) Option {
+	return func(c *Config) {
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithIsolatedPools(enabled))
 	}
 }
 
 // WithGRPCTransport 使用 gRPC 传输
 func WithGRPCTransport(timeout time.Duration) Option {
 	return func(c *Config) {
-		c.GRPCTransport = transport.NewGRPCTransport(timeout)
+		c.GRPCTimeout = timeout
+	}
+}
+
+// WithGRPCTLSConfig 以 cfg 通过 TLS 连接 gRPC 后端（默认使用系统根证书的 TLS）
+func WithGRPCTLSConfig(cfg *tls.Config) Option {
+	return func(c *Config) {
+		c.GRPCOptions = append(c.GRPCOptions, transport.WithGRPCTLSConfig(cfg))
+	}
+}
+
+// WithGRPCCredentials 使用自定义的连接凭证连接 gRPC 后端
+func WithGRPCCredentials(creds credentials.TransportCredentials) Option {
+	return func(c *Config) {
+		c.GRPCOptions = append(c.GRPCOptions, transport.WithGRPCTransportCredentials(creds))
+	}
+}
+
+// WithGRPCCredentialsFunc 按后端地址（host:port）选择连接凭证，返回 nil 时使用默认凭证
+func WithGRPCCredentialsFunc(fn func(target string) credentials.TransportCredentials) Option {
+	return func(c *Config) {
+		c.GRPCOptions = append(c.GRPCOptions, transport.WithGRPCCredentialsFunc(fn))
+	}
+}
+
+// WithGRPCWaitForReady 连接未就绪时 RPC 排队等待（直到 RPC 超时），而不是在连接失败时立即返回 Unavailable
+// 适合后端短暂重启的场景；默认 fail-fast，后端不可达时调用方能立即感知
+func WithGRPCWaitForReady(enabled bool) Option {
+	return func(c *Config) {
+		c.GRPCOptions = append(c.GRPCOptions, transport.WithGRPCWaitForReady(enabled))
+	}
+}
+
+// WithGRPCBlockingDial 新建到后端的连接时阻塞等待连接就绪，最长 timeout，超时返回 Unavailable
+// 默认连接在后台建立，首次调用才会暴露后端不可达
+func WithGRPCBlockingDial(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.GRPCOptions = append(c.GRPCOptions, transport.WithGRPCBlockingDial(timeout))
+	}
+}
+
+// WithGRPCInsecure 以明文连接 gRPC 后端，仅用于本地开发
+func WithGRPCInsecure() Option {
+	return func(c *Config) {
+		c.GRPCOptions = append(c.GRPCOptions, transport.WithGRPCInsecure())
 	}
 }
 
@@ -131,16 +451,117 @@ func WithGRPCTransporter(t transport.GRPCTransporter) Option {
 func WithSimpleStrategy() Option {
 	return func(c *Config) {
 		// strategy 需要在 backend 设置后初始化
+		c.StrategyFactory = func(b backend.Backend) strategy.Strategy {
+			return strategy.NewSimpleStrategy(b)
+		}
+	}
+}
+
+// WithWeightedStrategy 使用加权随机策略，按地址权重成比例分配流量
+func WithWeightedStrategy() Option {
+	return func(c *Config) {
+		c.StrategyFactory = func(b backend.Backend) strategy.Strategy {
+			return strategy.NewWeightedRandomStrategy(b)
+		}
+	}
+}
+
+// WithRoundRobinStrategy 使用轮询策略，在 color 的多个地址间依次轮转
+func WithRoundRobinStrategy() Option {
+	return func(c *Config) {
+		c.StrategyFactory = func(b backend.Backend) strategy.Strategy {
+			return strategy.NewRoundRobinStrategy(b)
+		}
 	}
 }
 
+// WithConsistentHashStrategy 使用一致性哈希策略：按 keyFunc 从请求中提取的 key 把同一客户端固定到 color 的同一地址
+// keyFunc 为 nil 或返回空字符串时使用客户端 IP；地址增减时只有少部分 key 会重新映射。
+// 权重为 0 的地址不再接收新的 key，只有 keyFunc 返回的 key 就是该地址时（如记录上游地址的 sticky cookie）才会落在该地址
+func WithConsistentHashStrategy(keyFunc func(*http.Request) string) Option {
+	return func(c *Config) {
+		c.StrategyFactory = func(b backend.Backend) strategy.Strategy {
+			return strategy.NewConsistentHashStrategy(b, keyFunc)
+		}
+	}
+}
+
+// WithCanaryStrategy 金丝雀分流：不论请求携带的 color，按 canaryPercent% / (100-canaryPercent)%
+// 在 canary 与 stable 两个路由间分流；canary 未注册时全部走 stable，
+// stable 没有可用地址时分到 stable 的请求按未找到处理，不会提升 canary 承接全部流量
+// 只有需要转发的请求（携带 color，或配置了 WithDefaultColor）才会参与分流
+func WithCanaryStrategy(stable, canary string, canaryPercent int) Option {
+	return func(c *Config) {
+		c.CanaryStable = stable
+		c.CanaryColor = canary
+		c.CanaryPercent = canaryPercent
+	}
+}
+
+// Strategy 路由策略接口，自定义策略实现 Select(ctx, RoutingRequest) 后通过 WithStrategy 使用
+type Strategy = strategy.Strategy
+
+// RoutingRequest 传给 Strategy.Select 的路由请求：color 以及 header、客户端地址、原始 HTTP 请求（gRPC 时为 nil）
+type RoutingRequest = strategy.RoutingRequest
+
 // WithStrategy 自定义路由策略
-func WithStrategy(s strategy.Strategy) Option {
+func WithStrategy(s Strategy) Option {
 	return func(c *Config) {
 		c.Strategy = s
 	}
 }
 
+// PatternRule color 匹配规则：Prefix（前缀）或 Regexp（正则）映射到已注册的 Target color
+type PatternRule = strategy.PatternRule
+
+// WithPatternStrategy 按前缀或正则把请求 color 解析为已注册的 color 后再选择地址，可多次调用追加规则
+// 多个前缀匹配时最长的前缀胜出，前缀规则优先于正则规则，正则规则按配置顺序匹配，Target 可引用分组（如 "team-$1"）；
+// 没有规则匹配或解析出的 color 未注册时按请求 color 精确匹配。地址选择仍由 WithStrategy 等配置的策略完成
+func WithPatternStrategy(rules ...PatternRule) Option {
+	return func(c *Config) {
+		c.ColorPatterns = append(c.ColorPatterns, rules...)
+	}
+}
+
+// WithExpiryWeightDecay 路由剩余 TTL 低于 threshold 时按剩余比例衰减其分流权重（有效权重 = 权重 * 剩余 TTL / threshold），
+// 停止心跳的路由在过期前逐步失去流量。作用于按权重在多个路由间分流的策略：
+// WithColorVariantSplit 的各变体，以及 WithCanaryStrategy 的 canary（stable 不衰减）。
+// 同一路由的各地址共享过期时间，路由内的加权选择不受影响。threshold 应小于 TTL 减去心跳间隔，
+// 否则正常续期的路由也会被衰减；开启后每次分流需要读取各候选路由
+func WithExpiryWeightDecay(threshold time.Duration) Option {
+	return func(c *Config) {
+		c.ExpiryWeightDecay = threshold
+	}
+}
+
+// WithColorVariantSplit 在 color 内按比例分流到变体路由
+// 变体以 "<color>#<variant>" 的名称注册（如 "blue#experimental"、"blue#stable"），
+// variants 为变体名 -> 权重（按总和归一化）。选中的变体未注册时回退到 "<color>" 路由；
+// 未配置变体的 color 行为不变。
+func WithColorVariantSplit(color string, variants map[string]int) Option {
+	return func(c *Config) {
+		if c.ColorVariants == nil {
+			c.ColorVariants = make(map[string]map[string]int)
+		}
+		c.ColorVariants[color] = variants
+	}
+}
+
+// WithLookupTimeout 为请求路径上的路由查询设置超时上限
+// 即使请求本身没有 deadline，慢后端（如 Redis）也最多阻塞 d
+func WithLookupTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.LookupTimeout = d
+	}
+}
+
+// WithLookupTimeoutStatus 路由查询超时时返回的状态码（如 503/504），0 表示按未找到处理
+func WithLookupTimeoutStatus(status int) Option {
+	return func(c *Config) {
+		c.LookupTimeoutStatus = status
+	}
+}
+
 // WithTTL 设置路由过期时间
 func WithTTL(ttl time.Duration) Option {
 	return func(c *Config) {
@@ -148,22 +569,44 @@ func WithTTL(ttl time.Duration) Option {
 	}
 }
 
+// WithSelfTTL 自注册路由使用独立的 TTL（默认使用 WithTTL 的值），心跳间隔需小于该值
+func WithSelfTTL(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.LocalTTL = ttl
+	}
+}
+
+// WithHeartbeatJitter 为自心跳与清理任务的间隔加入 ±fraction 的随机抖动（如 0.2 为 ±20%），首次执行随机提前，
+// 避免同时启动的实例同步访问后端；取值范围 [0, 0.9]，默认 0 不抖动。最长心跳间隔为 HeartbeatRate*(1+fraction)，需小于 TTL
+func WithHeartbeatJitter(fraction float64) Option {
+	return func(c *Config) {
+		c.HeartbeatJitter = fraction
+	}
+}
+
+// WithHeartbeatReregister 心跳时路由已不存在（如后端短暂不可用导致 TTL 在两次心跳之间到期）则按心跳携带的
+// color、地址与 token 以独占方式重新注册，而不是返回错误；自心跳会按自注册的完整配置恢复路由。
+// 开启后被管理员删除的路由也会在下一次心跳时恢复，color 已被其他 token 注册时不会覆盖
+func WithHeartbeatReregister(enabled bool) Option {
+	return func(c *Config) {
+		c.HeartbeatReregister = enabled
+	}
+}
+
+// WithExclusiveRegister 启用独占注册：color 已被其他 token 注册时，注册请求返回 409、自注册失败并记录日志，
+// 而不是静默覆盖；同一 token 的重复注册与心跳不受影响。未启用时也可以在单个注册请求中设置 exclusive
+func WithExclusiveRegister() Option {
+	return func(c *Config) {
+		c.ExclusiveRegister = true
+	}
+}
+
 // WithAutoRegister 启用自动注册
 func WithAutoRegister(color, address, token, owner string) Option {
 	return func(c *Config) {
 		// 只有在 color 和 address 都不为空时才启用
+		// address 只是端口号时，在 New 中按 WithAdvertiseAddress / WithAdvertiseInterface 补全本机 IP
 		if color != "" && address != "" {
-			// 如果 address 只是端口号，自动获取本机 IP
-			if !isFullURL(address) {
-				localIP := getLocalIPv4()
-				if localIP != "" {
-					address = fmt.Sprintf("http://%s:%s", localIP, address)
-				} else {
-					c.Logger.Error("failed to get local IP, auto register disabled")
-					return
-				}
-			}
-
 			c.AutoRegister = true
 			c.LocalColor = color
 			c.LocalAddress = address
@@ -173,58 +616,407 @@ func WithAutoRegister(color, address, token, owner string) Option {
 	}
 }
 
-// isFullURL 判断是否是完整 URL
-func isFullURL(addr string) bool {
-	return len(addr) > 7 && (addr[:7] == "http://" || addr[:8] == "https://")
+// WithLocalLabels 自注册路由的标签（如 region）
+func WithLocalLabels(labels map[string]string) Option {
+	return func(c *Config) {
+		c.LocalLabels = labels
+	}
 }
 
-// getLocalIPv4 返回最可能的真实 IPv4（排除虚拟网卡）
-func getLocalIPv4() string {
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return ""
+// WithAdvertiseAddress 自注册地址只有端口时使用 ip（IPv4、IPv6 或主机名）补全，不再自动探测本机 IP
+func WithAdvertiseAddress(ip string) Option {
+	return func(c *Config) {
+		c.AdvertiseAddress = ip
 	}
+}
 
-	// 常见虚拟网卡关键词
-	virtualKeywords := []string{
-		"virtual", "vmware", "hyper-v", "docker", "veth", "wsl",
-		"vbox", "loopback", "tunnel", "tap", "tun", "ethernet default switch",
+// WithAdvertiseInterface 自注册地址只有端口时从网卡 name（如 "eth0"）选取 IP，避免多网卡主机选中 Docker 网桥等网卡；
+// 该网卡没有可用地址时 New 返回错误
+func WithAdvertiseInterface(name string) Option {
+	return func(c *Config) {
+		c.AdvertiseInterface = name
 	}
+}
 
-	isVirtual := func(name string) bool {
-		lower := strings.ToLower(name)
-		for _, kw := range virtualKeywords {
-			if strings.Contains(lower, kw) {
-				return true
-			}
+// WithAdvertiseExclude 自动探测本机 IP 时跳过 cidrs 网段内的地址（如 "172.17.0.0/16"），可多次调用追加
+func WithAdvertiseExclude(cidrs ...string) Option {
+	return func(c *Config) {
+		c.AdvertiseExclude = append(c.AdvertiseExclude, cidrs...)
+	}
+}
+
+// WithBackpressure 启用背压感知的后台任务调度
+// 进行中的请求数超过 highWater 时跳过过期清理，并在距 TTL 过期仍有 safetyMargin 以上余量时跳过自心跳
+func WithBackpressure(highWater int, safetyMargin time.Duration) Option {
+	return func(c *Config) {
+		c.BackpressureHighWater = highWater
+		c.BackpressureSafetyMargin = safetyMargin
+	}
+}
+
+// WithLivenessDeadline 启用存活看门狗
+// 应用需在主循环中定期调用 Proxy.ReportAlive；超过 d 未调用时停止自心跳，
+// 自注册的路由随 TTL 自然过期，流量不再转发到已卡死的进程
+func WithLivenessDeadline(d time.Duration) Option {
+	return func(c *Config) {
+		c.LivenessDeadline = d
+	}
+}
+
+// WithSnapshotFile 启用本地路由快照
+// 每隔 interval 将后端路由表写入 path；启动时从 path 加载上次的快照，
+// 在后端可达之前作为临时路由兜底。后端首次成功响应后快照覆盖层即被丢弃。
+func WithSnapshotFile(path string, interval time.Duration) Option {
+	return func(c *Config) {
+		c.SnapshotFile = path
+		c.SnapshotInterval = interval
+	}
+}
+
+// WithRouteCache 启用本地路由缓存
+// 后端支持监听路由变化时（memory、Redis、etcd），启动后全量加载路由并按变化增量更新，
+// 请求的路由查询直接读取缓存；Redis 需所有写入方都使用本库的 Redis 后端。
+// 后端不支持监听（Consul、SQL）或监听中断期间，按 ttl 缓存每个 color 的查询结果（含路由不存在），
+// 其他实例的变化最多延迟 ttl 生效，本实例的注册、续期与删除立即生效；ttl <= 0 表示此时不缓存
+func WithRouteCache(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.RouteCache = true
+		c.RouteCacheTTL = ttl
+	}
+}
+
+// WithAdminPrefix 修改管理端点的路径前缀（默认 /colorproxy），如 "/_internal/cproxy"
+// 前缀需以 / 开头，末尾的 / 会被去掉；Gin、net/http 与 Echo 集成都使用该前缀，前缀下的请求不会被转发
+func WithAdminPrefix(prefix string) Option {
+	return func(c *Config) {
+		c.AdminPrefix = prefix
+	}
+}
+
+// WithAdminUI 启用 GET <prefix>/ui 路由管理页面，与其他管理端点一样需要 admin 认证
+func WithAdminUI(enabled bool) Option {
+	return func(c *Config) {
+		c.AdminUI = enabled
+	}
+}
+
+// WithAdminToken 设置 admin token，请求以 Authorization: Bearer <token> 认证
+// 设置后管理端点缺少或携带错误 token 时返回 401，业务路由不受影响
+func WithAdminToken(token string) Option {
+	return func(c *Config) {
+		c.AdminToken = token
+	}
+}
+
+// WithAdminTokenHeader 从自定义 header 读取 admin token（header 值即 token，不带 Bearer 前缀）
+func WithAdminTokenHeader(name string) Option {
+	return func(c *Config) {
+		c.AdminTokenHeader = name
+	}
+}
+
+// WithTargetOverrideHeader 允许 admin 认证的请求通过 header（如 X-Proxy-Target）直接指定转发目标，
+// 绕过注册表与路由策略。未认证请求的该 header 会被完全忽略。
+func WithTargetOverrideHeader(name string) Option {
+	return func(c *Config) {
+		c.TargetOverrideHeader = name
+	}
+}
+
+// WithColorExtractor 自定义 color 的提取方式，如 FromQuery("color")、FromCookie("color")
+func WithColorExtractor(extractor ColorExtractor) Option {
+	return func(c *Config) {
+		c.ColorExtractor = extractor
+	}
+}
+
+// WithTrustedProxies 只保留来自这些上游代理（IP 或 CIDR，如 "10.0.0.0/8"）的 X-Forwarded-For / Forwarded 链与
+// X-Forwarded-Proto / Host，其他来源的会被重置为本次连接的信息。
+// 未配置时不信任任何上游，客户端传来的这些 header 都会被重置；部署在负载均衡之后时需配置其地址。
+// 仅作用于内置 HTTP 传输层，无效的地址会使 New 返回错误
+func WithTrustedProxies(proxies []string) Option {
+	return func(c *Config) {
+		c.TrustedProxies = proxies
+	}
+}
+
+// WithStrictRouting 纯网关模式：请求缺少 color 时返回 400，color 没有可用路由时返回 503（JSON 说明原因），
+// 而不是交给本地处理。配置了 WithDefaultColor 时缺少 color 的请求使用默认 color，不会返回 400；
+// 配置了 WithFallback 时整条回退链都没有路由才返回 503，回退到本地 color 时仍在本地处理
+func WithStrictRouting() Option {
+	return func(c *Config) {
+		c.StrictRouting = true
+	}
+}
+
+// WithDefaultColor 请求未携带 color 时路由到 color 的服务；该 color 没有路由时仍在本地处理
+func WithDefaultColor(color string) Option {
+	return func(c *Config) {
+		c.DefaultColor = color
+	}
+}
+
+// WithContentTypeRouting 按请求 Content-Type 映射 color
+// key 为 media type（如 "multipart/form-data"）或主类型通配（如 "image/*"）。
+// 默认仅在请求没有 color header 时生效（header 优先），可通过 WithContentTypeOverride 改为覆盖 header。
+func WithContentTypeRouting(routes map[string]string) Option {
+	return func(c *Config) {
+		c.ContentTypeRoutes = make(map[string]string, len(routes))
+		for mediaType, color := range routes {
+			c.ContentTypeRoutes[strings.ToLower(mediaType)] = color
 		}
-		return false
 	}
+}
+
+// WithContentTypeOverride Content-Type 映射命中时覆盖 color header
+func WithContentTypeOverride(enabled bool) Option {
+	return func(c *Config) {
+		c.ContentTypeOverride = enabled
+	}
+}
+
+// ProxyAuthorizer 转发前的授权钩子，返回非 nil 错误时拒绝转发
+// 返回 *HTTPError 可指定响应状态码，否则使用 AuthorizerDenyStatus（默认 403）
+type ProxyAuthorizer func(ctx context.Context, color string, req *http.Request) error
+
+// WithProxyAuthorizer 设置转发前的授权钩子（与管理端点认证相互独立）
+// 在确定 color 与目标之后、转发之前调用，可用于按 color 做访问控制
+func WithProxyAuthorizer(fn ProxyAuthorizer) Option {
+	return func(c *Config) {
+		c.ProxyAuthorizer = fn
+	}
+}
+
+// WithAuthorizerDenyStatus 设置授权钩子拒绝时的默认状态码
+func WithAuthorizerDenyStatus(status int) Option {
+	return func(c *Config) {
+		c.AuthorizerDenyStatus = status
+	}
+}
+
+// WithProblemJSON 代理错误（转发失败、维护中等）以 RFC 7807 application/problem+json 返回
+func WithProblemJSON(enabled bool) Option {
+	return func(c *Config) {
+		c.ProblemJSON = enabled
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithProblemJSON(enabled))
+	}
+}
+
+// WithMaintenanceResponse 自定义维护模式下的响应
+// body 为空时返回默认 JSON；retryAfter > 0 时附带 Retry-After header
+func WithMaintenanceResponse(status int, body string, retryAfter time.Duration) Option {
+	return func(c *Config) {
+		c.MaintenanceStatus = status
+		c.MaintenanceBody = body
+		c.MaintenanceRetryAfter = retryAfter
+	}
+}
+
+// WithDNSRefresh 每隔 interval 重新解析后端 hostname，IP 变化时刷新连接
+func WithDNSRefresh(interval time.Duration) Option {
+	return func(c *Config) {
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithDNSRefresh(interval))
+	}
+}
+
+// WithRequestTimeout 单个转发请求的总时长上限（含读取响应 body），与 WithHTTPTransport 的超时（等待响应头）相互独立，
+// 超时返回 504；请求 context 带有更早的截止时间时以较早者为准。仅作用于内置 HTTP 传输层
+func WithRequestTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.RequestTimeout = d
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithRequestTimeout(d))
+	}
+}
+
+// WithRequestModifier 改写转发到后端的 HTTP 请求，在默认设置（URL、Host 为 target）之后执行，
+// 可用于覆盖 Host、注入内部认证 header 等；多次调用按顺序执行，仅作用于内置 HTTP 传输层
+func WithRequestModifier(fn func(*http.Request)) Option {
+	return func(c *Config) {
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithRequestModifier(fn))
+	}
+}
+
+// WithResponseModifier 改写后端返回的 HTTP 响应，返回错误时按转发失败处理（502）；仅作用于内置 HTTP 传输层
+func WithResponseModifier(fn func(*http.Response) error) Option {
+	return func(c *Config) {
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithResponseModifier(fn))
+	}
+}
+
+// WithCompression 客户端接受 gzip / deflate、后端响应未编码且不小于 minSize 字节时，由代理压缩响应，
+// 并设置 Content-Encoding 与 Vary: Accept-Encoding；已编码的响应不会被二次压缩，未声明长度的响应按流式压缩
+func WithCompression(minSize int) Option {
+	return func(c *Config) {
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithCompression(minSize))
+	}
+}
+
+// WithCORS 由代理本地应答跨域预检（OPTIONS）请求，不再转发给可能不处理预检的后端；
+// 转发的响应缺少 Access-Control-Allow-Origin 时按 cfg 补充（仅作用于内置 HTTP 传输层）
+func WithCORS(cfg CORSConfig) Option {
+	return func(c *Config) {
+		c.CORS = &cfg
+	}
+}
 
-	for _, iface := range ifaces {
-		// 跳过虚拟网卡 + 关闭的网卡
-		if iface.Flags&net.FlagUp == 0 ||
-			iface.Flags&net.FlagLoopback != 0 ||
-			isVirtual(iface.Name) {
-			continue
+// WithCORSPassthrough 为 true 时即使配置了 WithCORS 也把预检请求转发给后端，由后端处理跨域（默认 false）
+func WithCORSPassthrough(enabled bool) Option {
+	return func(c *Config) {
+		c.CORSPassthrough = enabled
+	}
+}
+
+// WithMaxResponseBody 限制转发给客户端的响应 body 不超过 n 字节：声明了更大 Content-Length 的响应返回 502；
+// chunked 等未声明长度的响应边转发边计数，超过上限时中断与客户端的连接（HTTP/1.x 关闭连接，HTTP/2 重置流），
+// 客户端读取 body 时得到错误而不是截断后看似完整的响应。仅作用于内置 HTTP 传输层
+func WithMaxResponseBody(n int64) Option {
+	return func(c *Config) {
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithMaxResponseBody(n))
+	}
+}
+
+// WithMaxBufferedBody 缓冲不超过 n 字节的请求 body，使重试等功能可以重放 POST/PUT 的 body
+// 1MiB 以内保存在内存中，更大的写入临时文件；超过 n 的 body 按原样转发，不参与重试
+func WithMaxBufferedBody(n int64) Option {
+	return func(c *Config) {
+		c.MaxBufferedBody = n
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithMaxBufferedBody(n))
+	}
+}
+
+// WithRetry 转发幂等请求（默认 GET/HEAD/PUT/DELETE，可通过 methods 覆盖）时，
+// 遇到连接错误或 502/503/504 最多尝试 maxAttempts 次，间隔从 backoff 开始指数退避
+func WithRetry(maxAttempts int, backoff time.Duration, methods ...string) Option {
+	return func(c *Config) {
+		c.RetryAttempts = maxAttempts
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithRetry(maxAttempts, backoff))
+		if len(methods) > 0 {
+			c.HTTPOptions = append(c.HTTPOptions, transport.WithRetryMethods(methods...))
 		}
+	}
+}
 
-		addrs, err := iface.Addrs()
-		if err != nil {
-			continue
+// WithHTTPTLSConfig 内置 HTTP 传输层连接 https 后端时使用的 TLS 配置（如私有 CA）
+// ServerName 留空时按目标地址的 host 做 SNI 与证书校验
+func WithHTTPTLSConfig(cfg *tls.Config) Option {
+	return func(c *Config) {
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithTLSConfig(cfg))
+	}
+}
+
+// WithHTTPInsecureSkipVerify 跳过 https 后端的证书校验，仅用于开发环境
+func WithHTTPInsecureSkipVerify() Option {
+	return func(c *Config) {
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithInsecureSkipVerify())
+	}
+}
+
+// WithHTTP2 内置 HTTP 传输层对 https 后端协商 HTTP/2（默认仅 HTTP/1.1），WebSocket 等升级请求仍使用 HTTP/1.1
+func WithHTTP2(enabled bool) Option {
+	return func(c *Config) {
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithHTTP2(enabled))
+	}
+}
+
+// WithHTTPLogging 开关内置 HTTP 传输层的日志（默认开启），日志输出到 WithAccessLog 或 WithLogger（Info）
+// 关闭后传输层不再输出转发错误、连接淘汰等日志，访问日志（WithAccessLog）不受影响
+func WithHTTPLogging(enabled bool) Option {
+	return func(c *Config) {
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithHTTPLogging(enabled))
+	}
+}
+
+// WithRateLimit 按 color 限流：每个 color 独立的令牌桶，每秒 perColor 个请求、突发 burst 个
+// 超出时返回 429 并设置 Retry-After；exempt 中的 color 不限流
+func WithRateLimit(perColor rate.Limit, burst int, exempt ...string) Option {
+	return func(c *Config) {
+		c.RateLimit = perColor
+		c.RateLimitBurst = burst
+		c.RateLimitExempt = exempt
+	}
+}
+
+// WithMaxConcurrency 按 color 限制同时进行的转发数，保护承载能力有限的后端
+// 名额已满时默认立即返回 503（带 Retry-After），可通过 WithConcurrencyQueue 改为排队等待
+func WithMaxConcurrency(perColor int) Option {
+	return func(c *Config) {
+		c.MaxConcurrency = perColor
+	}
+}
+
+// WithConcurrencyQueue 并发名额已满时排队等待，最长 timeout，仍未获得名额（或客户端断开）时返回 503
+func WithConcurrencyQueue(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.ConcurrencyQueueTimeout = timeout
+	}
+}
+
+// WithRequestID 为转发的请求携带请求 ID：沿用请求中的 headerName（或 X-Request-Id / X-Trace-Id），缺失时生成 UUID
+// ID 同时写入转发请求与响应 header，并记录在转发日志中；headerName 为空时使用 X-Request-Id
+// 仅作用于内置 HTTP 传输层
+func WithRequestID(headerName string) Option {
+	return func(c *Config) {
+		if headerName == "" {
+			headerName = transport.DefaultRequestIDHeader
 		}
+		c.RequestIDHeader = headerName
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithRequestID(headerName))
+	}
+}
 
-		for _, addr := range addrs {
-			if ipNet, ok := addr.(*net.IPNet); ok {
-				ip := ipNet.IP
-				if ipv4 := ip.To4(); ipv4 != nil {
-					return ipv4.String()
-				}
-			}
+// WithVersionHeader 指定读取路由版本的 header（gRPC 为同名 metadata），默认 x-version
+// 请求携带版本时优先转发到 color+version 的路由，没有该版本的路由时回退到不带版本的路由
+func WithVersionHeader(name string) Option {
+	return func(c *Config) {
+		c.VersionHeader = name
+	}
+}
+
+// WithCircuitBreaker 按后端地址熔断：连续 failureThreshold 次失败后 cooldown 内直接返回 503
+// 每次状态变化（closed→open、open→half-open、half-open→closed/open）向 WithRouteListener 发出 BreakerStateChanged 事件，
+// 当前状态可通过 GET /breakers 查看；仅作用于内置 HTTP 传输层
+func WithCircuitBreaker(failureThreshold int, cooldown time.Duration) Option {
+	return func(c *Config) {
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithCircuitBreaker(failureThreshold, cooldown))
+	}
+}
+
+// WithHealthCheck 每隔 interval 探测所有路由地址的 path（默认 /healthz），
+// 探测失败的地址在策略选择时被跳过，探测恢复后自动重新参与路由
+func WithHealthCheck(path string, interval, timeout time.Duration) Option {
+	return func(c *Config) {
+		if path == "" {
+			path = "/healthz"
 		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		c.HealthCheckPath = path
+		c.HealthCheckInterval = interval
+		c.HealthCheckTimeout = timeout
+	}
+}
+
+// WithReadinessTimeout 设置就绪探针检查后端连接的超时（默认 2 秒），超时视为后端不可达
+func WithReadinessTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.ReadinessTimeout = d
 	}
+}
+
+// WithMetrics 启用指标采集，Prometheus 实现见 prommetrics 子包
+func WithMetrics(m MetricsCollector) Option {
+	return func(c *Config) {
+		c.Metrics = m
+	}
+}
 
-	return ""
+// WithRouteListener 监听路由注册、续期、删除与过期事件，可多次调用注册多个监听者
+// 事件在独立的 goroutine 中投递，缓冲区满时丢弃新事件并记录日志
+func WithRouteListener(listener RouteListener) Option {
+	return func(c *Config) {
+		c.RouteListeners = append(c.RouteListeners, listener)
+	}
 }
 
 // WithLogger 自定义日志
@@ -242,6 +1034,19 @@ func New(opts ...Option) (*Proxy, error) {
 		HeartbeatRate: 30 * time.Second,
 		CleanupRate:   1 * time.Minute,
 		Logger:        &defaultLogger{},
+
+		ColorExtractor: FromHeader("color"),
+		VersionHeader:  "x-version",
+		AdminPrefix:    defaultAdminPrefix,
+
+		AuthorizerDenyStatus:  403,
+		MaintenanceStatus:     503,
+		MaintenanceRetryAfter: 60 * time.Second,
+
+		ReadinessTimeout: 2 * time.Second,
+
+		AsyncWorkers: defaultAsyncWorkers,
+		AsyncQueue:   defaultAsyncQueue,
 	}
 
 	// 应用选项
@@ -253,28 +1058,196 @@ func New(opts ...Option) (*Proxy, error) {
 	if cfg.Backend == nil {
 		return nil, ErrBackendRequired
 	}
+	if err := validateMetricLabels(cfg.MetricLabels); err != nil {
+		return nil, err
+	}
+	if cfg.AutoRollback != nil {
+		if err := cfg.AutoRollback.validate(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.AdminPrefix != defaultAdminPrefix {
+		prefix := strings.TrimRight(cfg.AdminPrefix, "/")
+		if !strings.HasPrefix(cfg.AdminPrefix, "/") || prefix == "" {
+			return nil, fmt.Errorf("invalid admin prefix %q: must start with / and not be /", cfg.AdminPrefix)
+		}
+		cfg.AdminPrefix = prefix
+	}
+	if len(cfg.TrustedProxies) > 0 {
+		nets, err := parseTrustedProxies(cfg.TrustedProxies)
+		if err != nil {
+			return nil, err
+		}
+		cfg.HTTPOptions = append(cfg.HTTPOptions, transport.WithTrustedProxies(nets))
+	}
+	// 自注册地址只有端口时补全本机 IP，其余地址交给 normalizeAddress 校验；自动探测失败时不启用自注册（与之前的行为一致）
+	if cfg.AutoRegister && validPort(cfg.LocalAddress) {
+		addr, err := resolveAdvertiseAddress(cfg, cfg.LocalAddress)
+		switch {
+		case err == nil:
+			cfg.LocalAddress = addr
+		case cfg.AdvertiseAddress != "" || cfg.AdvertiseInterface != "" || len(cfg.AdvertiseExclude) > 0:
+			return nil, fmt.Errorf("auto register: %w", err)
+		default:
+			cfg.Logger.Error("failed to get local IP, auto register disabled: %v", err)
+			cfg.AutoRegister = false
+		}
+	}
+	// 自注册地址在启动前规范化，注册与心跳使用同一个地址
+	if cfg.AutoRegister {
+		addr, err := normalizeAddress(cfg.LocalAddress)
+		if err != nil {
+			return nil, fmt.Errorf("auto register: %w", err)
+		}
+		cfg.LocalAddress = addr
+	}
+	var snapshot *backend.SnapshotBackend
+	if cfg.SnapshotFile != "" {
+		if cfg.SnapshotInterval <= 0 {
+			cfg.SnapshotInterval = 30 * time.Second
+		}
+		sb, err := backend.NewSnapshotBackend(cfg.Backend, cfg.SnapshotFile)
+		if err != nil {
+			return nil, fmt.Errorf("load snapshot failed: %w", err)
+		}
+		snapshot = sb
+		cfg.Backend = sb
+	}
+	var cache *backend.CacheBackend
+	if cfg.RouteCache {
+		cache = backend.NewCacheBackend(cfg.Backend, cfg.RouteCacheTTL)
+		cfg.Backend = cache
+	}
+	var p *Proxy
 	if cfg.HTTPTransport == nil {
-		cfg.HTTPTransport = transport.NewHTTPTransport(30 * time.Second)
+		httpOpts := cfg.HTTPOptions
+		if cfg.CORS != nil && !cfg.CORSPassthrough {
+			httpOpts = append(httpOpts, transport.WithResponseModifier(cfg.CORS.corsResponseModifier))
+		}
+		// 传输层失败（连接错误、超时）单独计数，与后端自身返回的 5xx 区分
+		httpOpts = append(httpOpts, transport.WithErrorObserver(func(req *http.Request, status int, err error) {
+			p.observeError(p.requestColor(req, nil), upstreamErrorReason(status, err))
+		}))
+		// 熔断器状态变化作为事件发出，未启用熔断时不会调用
+		httpOpts = append(httpOpts, transport.WithBreakerObserver(func(req *http.Request, t transport.BreakerTransition) {
+			p.breakerChanged(req, t)
+		}))
+		// 传输层日志优先输出到访问日志，其次是自定义的 Logger，都未配置时保持 log.Printf
+		if cfg.AccessLogger != nil {
+			httpOpts = append(httpOpts, transport.WithLogFunc(func(format string, args ...interface{}) {
+				cfg.AccessLogger.LogTransport(fmt.Sprintf(format, args...))
+			}))
+		} else if _, ok := cfg.Logger.(*defaultLogger); !ok {
+			httpOpts = append(httpOpts, transport.WithLogFunc(cfg.Logger.Info))
+		}
+		cfg.HTTPTransport = transport.NewHTTPTransport(cfg.HTTPTimeout, httpOpts...)
 	}
 	if cfg.GRPCTransport == nil {
-		cfg.GRPCTransport = transport.NewGRPCTransport(30 * time.Second)
+		cfg.GRPCTransport = transport.NewGRPCTransport(cfg.GRPCTimeout, cfg.GRPCOptions...)
 	}
 	if cfg.Strategy == nil {
-		cfg.Strategy = strategy.NewSimpleStrategy(cfg.Backend)
+		if cfg.StrategyFactory != nil {
+			cfg.Strategy = cfg.StrategyFactory(cfg.Backend)
+		} else {
+			cfg.Strategy = strategy.NewSimpleStrategy(cfg.Backend)
+		}
+	}
+	if cfg.ColorExtractor == nil {
+		cfg.ColorExtractor = FromHeader("color")
+	}
+	if len(cfg.ColorVariants) > 0 {
+		variants := strategy.NewVariantSplitStrategy(cfg.Strategy, cfg.ColorVariants)
+		variants.SetExpiryDecay(cfg.Backend, cfg.ExpiryWeightDecay)
+		cfg.Strategy = variants
+	}
+	var rollback *rollbackMonitor
+	if cfg.CanaryStable != "" {
+		if cfg.CanaryPercent < 0 || cfg.CanaryPercent > 100 {
+			clamped := min(max(cfg.CanaryPercent, 0), 100)
+			cfg.Logger.Error("canary percent %d out of range [0, 100], clamped to %d", cfg.CanaryPercent, clamped)
+			cfg.CanaryPercent = clamped
+		}
+		canary := strategy.NewCanaryStrategy(cfg.Strategy, cfg.CanaryStable, cfg.CanaryColor, cfg.CanaryPercent)
+		canary.SetExpiryDecay(cfg.Backend, cfg.ExpiryWeightDecay)
+		cfg.Strategy = canary
+		if cfg.AutoRollback != nil {
+			rollback = newRollbackMonitor(*cfg.AutoRollback, canary)
+		}
+	}
+	if cfg.HeartbeatJitter < 0 || cfg.HeartbeatJitter > maxJitter {
+		clamped := min(max(cfg.HeartbeatJitter, 0), maxJitter)
+		cfg.Logger.Error("heartbeat jitter %v out of range [0, %v], clamped to %v", cfg.HeartbeatJitter, maxJitter, clamped)
+		cfg.HeartbeatJitter = clamped
+	}
+	// 模式匹配在最外层：解析后的 color 再参与变体分流，金丝雀的 stable / canary 不会被改写
+	if len(cfg.ColorPatterns) > 0 {
+		cfg.Strategy = strategy.NewPatternStrategy(cfg.Strategy, cfg.ColorPatterns)
+	}
+	var health *healthState
+	if cfg.HealthCheckInterval > 0 {
+		if cfg.HealthCheckTimeout <= 0 {
+			cfg.HealthCheckTimeout = 2 * time.Second
+		}
+		health = newHealthState(cfg.HealthCheckTimeout)
+		if ha, ok := cfg.Strategy.(strategy.HealthAware); ok {
+			ha.SetHealthChecker(health)
+		} else {
+			cfg.Logger.Error("strategy does not support health checks, unhealthy endpoints will not be skipped")
+		}
+	}
+
+	var limiter *rateLimiter
+	rateOverrides := policyRateLimits(cfg.ColorPolicies)
+	if cfg.RateLimit > 0 || len(rateOverrides) > 0 {
+		limiter = newRateLimiter(cfg.RateLimit, cfg.RateLimitBurst, cfg.RateLimitExempt, rateOverrides)
+	}
+
+	var concurrency *concurrencyLimiter
+	concurrencyOverrides := policyConcurrency(cfg.ColorPolicies)
+	if cfg.MaxConcurrency > 0 || len(concurrencyOverrides) > 0 {
+		concurrency = newConcurrencyLimiter(cfg.MaxConcurrency, concurrencyOverrides, cfg.ConcurrencyQueueTimeout)
+	}
+
+	var mirror *mirrorer
+	if len(cfg.Mirrors) > 0 {
+		if cfg.MirrorTimeout <= 0 {
+			cfg.MirrorTimeout = defaultMirrorTimeout
+		}
+		mirror = newMirrorer(cfg.MirrorTimeout, cfg.HTTPTransport)
+	}
+
+	if cfg.AsyncWorkers <= 0 {
+		cfg.AsyncWorkers = defaultAsyncWorkers
+	}
+	if cfg.AsyncQueue < 0 {
+		cfg.AsyncQueue = 0
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	p := &Proxy{
-		backend:  cfg.Backend,
-		http:     cfg.HTTPTransport,
-		grpc:     cfg.GRPCTransport,
-		strategy: cfg.Strategy,
-		config:   cfg,
-		ctx:      ctx,
-		cancel:   cancel,
+	p = &Proxy{
+		backend:     cfg.Backend,
+		http:        cfg.HTTPTransport,
+		grpc:        cfg.GRPCTransport,
+		strategy:    cfg.Strategy,
+		snapshot:    snapshot,
+		cache:       cache,
+		health:      health,
+		events:      newEventBus(),
+		limiter:     limiter,
+		concurrency: concurrency,
+		mirror:      mirror,
+		mirrorKey:   newMirrorKey(cfg.AdminToken),
+		async:       newAsyncPool(cfg.AsyncQueue),
+		labels:      newRouteLabels(cfg.MetricLabels),
+		rollback:    rollback,
+		config:      cfg,
+		ctx:         ctx,
+		cancel:      cancel,
 	}
 
+	p.ReportAlive()
+
 	// 启动后台任务
 	p.startBackgroundTasks()
 
@@ -289,23 +1262,6 @@ func New(opts ...Option) (*Proxy, error) {
 	return p, nil
 }
 
-// AttachGin 集成到 Gin 引擎
-func (p *Proxy) AttachGin(engine *gin.Engine) {
-	// 注册管理端点
-	api := engine.Group("/colorproxy")
-	{
-		api.POST("/register", p.ginHandleRegister)
-		api.POST("/heartbeat", p.ginHandleHeartbeat)
-		api.GET("/routes", p.ginHandleListRoutes)
-		api.DELETE("/routes/:color", p.ginHandleDeleteRoute)
-	}
-
-	// 全局代理中间件
-	engine.Use(p.ginProxyMiddleware())
-
-	p.config.Logger.Info("attached to Gin engine")
-}
-
 // GetGRPCUnaryClientInterceptor 获取 gRPC 客户端拦截器
 // 用于在创建 gRPC client 时注入，实现自动的 color 路由转发
 func (p *Proxy) GetGRPCUnaryClientInterceptor() grpc.UnaryClientInterceptor {
@@ -345,7 +1301,7 @@ func (p *Proxy) GetGRPCUnaryClientInterceptor() grpc.UnaryClientInterceptor {
 			return invoker(ctx, method, req, reply, cc, opts...)
 		}
 
-		target, err := p.strategy.Select(ctx, color)
+		target, err := p.selectTarget(ctx, strategy.ForColor(color))
 		if err != nil {
 			// 找不到目标，按正常流程处理
 			p.config.Logger.Info("gRPC color %s not found, fallback to normal call", color)
@@ -375,40 +1331,139 @@ func (p *Proxy) GetGRPCUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 
 				p.config.Logger.Info("gRPC server received color=%s for method=%s", colorValues[0], info.FullMethod)
 			}
-		}
-
-		// 继续处理请求
-		return handler(ctx, req)
+		}
+
+		// 继续处理请求
+		return handler(ctx, req)
+	}
+}
+
+// startBackgroundTasks 启动后台任务
+func (p *Proxy) startBackgroundTasks() {
+	// 异步任务池只服务于流量镜像，未配置镜像时不启动 worker
+	if p.mirror != nil {
+		p.async.start(p.ctx, &p.wg, p.config.AsyncWorkers)
+	}
+
+	// 连接池采样，需要同时启用指标
+	if p.config.ConnPoolInterval > 0 && p.config.Metrics != nil {
+		if _, ok := p.http.(connStater); ok {
+			p.wg.Add(1)
+			go func() {
+				defer p.wg.Done()
+				p.sampleConnPool()
+			}()
+		} else {
+			p.config.Logger.Error("http transport does not report connection pool stats, sampling disabled")
+		}
+	}
+
+	// 清理过期路由
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := newJitterTicker(p.config.CleanupRate, p.config.HeartbeatJitter)
+		defer ticker.Stop()
+
+		// 启动时先加载一次路由表，Stats 无需等待第一轮清理
+		if routes, err := p.backend.List(p.ctx); err == nil {
+			p.refreshRoutes(routes)
+		}
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+				if p.overloaded() {
+					p.config.Logger.Info("high load, cleanup skipped: inflight=%d", p.inflight.Load())
+					continue
+				}
+				// 复用清理后的路由表对比变化，不再单独 List
+				if routes, err := p.backend.DeleteExpired(p.ctx); err != nil {
+					p.config.Logger.Error("cleanup expired failed: %v", err)
+				} else {
+					p.refreshRoutes(routes)
+				}
+				if p.limiter != nil {
+					p.limiter.prune(p.limiter.limiterIdle())
+				}
+				if p.concurrency != nil {
+					p.concurrency.prune()
+				}
+			}
+		}
+	}()
+
+	// 路由事件分发
+	if len(p.config.RouteListeners) > 0 {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.dispatchEvents()
+		}()
+	}
+
+	// 路由缓存同步
+	if p.cache != nil {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.cache.Run(p.ctx, func(err error) {
+				if errors.Is(err, backend.ErrWatchUnsupported) {
+					p.config.Logger.Info("route cache watch disabled, using ttl cache: %v", err)
+					return
+				}
+				p.config.Logger.Error("route cache sync interrupted, falling back to backend: %v", err)
+			})
+		}()
+	}
+
+	// 定期写入本地快照
+	if p.snapshot != nil {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			ticker := time.NewTicker(p.config.SnapshotInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-p.ctx.Done():
+					return
+				case <-ticker.C:
+					if err := p.snapshot.Save(p.ctx); err != nil {
+						p.config.Logger.Error("save snapshot failed: %v", err)
+					}
+				}
+			}
+		}()
 	}
-}
 
-// startBackgroundTasks 启动后台任务
-func (p *Proxy) startBackgroundTasks() {
-	// 清理过期路由
-	p.wg.Add(1)
-	go func() {
-		defer p.wg.Done()
-		ticker := time.NewTicker(p.config.CleanupRate)
-		defer ticker.Stop()
+	// 主动健康检查
+	if p.health != nil {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			ticker := time.NewTicker(p.config.HealthCheckInterval)
+			defer ticker.Stop()
 
-		for {
-			select {
-			case <-p.ctx.Done():
-				return
-			case <-ticker.C:
-				if err := p.backend.DeleteExpired(p.ctx); err != nil {
-					p.config.Logger.Error("cleanup expired failed: %v", err)
+			for {
+				select {
+				case <-p.ctx.Done():
+					return
+				case <-ticker.C:
+					p.runHealthChecks(p.ctx)
 				}
 			}
-		}
-	}()
+		}()
+	}
 
 	// 自动心跳
 	if p.config.AutoRegister {
 		p.wg.Add(1)
 		go func() {
 			defer p.wg.Done()
-			ticker := time.NewTicker(p.config.HeartbeatRate)
+			ticker := newJitterTicker(p.config.HeartbeatRate, p.config.HeartbeatJitter)
 			defer ticker.Stop()
 
 			for {
@@ -425,45 +1480,171 @@ func (p *Proxy) startBackgroundTasks() {
 	}
 }
 
-// registerSelf 自注册
-func (p *Proxy) registerSelf() error {
-	route := &backend.Route{
+// selfRoute 自注册的路由
+func (p *Proxy) selfRoute() *backend.Route {
+	return &backend.Route{
 		Color:   p.config.LocalColor,
 		Address: p.config.LocalAddress,
 		Owner:   p.config.LocalOwner,
 		Token:   p.config.LocalToken,
+		TTL:     p.config.LocalTTL,
+		Labels:  p.config.LocalLabels,
 	}
+}
+
+// registerSelf 自注册
+func (p *Proxy) registerSelf() error {
+	route := p.selfRoute()
 
-	if err := p.backend.Register(p.ctx, route, p.config.TTL); err != nil {
+	if err := p.registerRoute(p.ctx, route, p.config.ExclusiveRegister); err != nil {
 		return err
 	}
+	p.lastBeat.Store(time.Now().UnixNano())
+	p.emit(RouteRegistered, route.Color, route.Address)
 
 	p.config.Logger.Info("self registered: color=%s, addr=%s", route.Color, route.Address)
 	return nil
 }
 
+// registerRoute 写入路由；exclusive 为 true 时，color 已被其他 token 注册返回 backend.ErrRouteConflict
+func (p *Proxy) registerRoute(ctx context.Context, route *backend.Route, exclusive bool) error {
+	err := p.storeRoute(ctx, route, exclusive)
+	if err != nil {
+		return err
+	}
+	if p.labels != nil {
+		p.labels.set(route)
+	}
+	if p.rollback != nil && route.Key() == p.rollback.cfg.Color {
+		p.rollback.setTargets(route)
+	}
+	return nil
+}
+
+// storeRoute 按是否独占写入后端
+func (p *Proxy) storeRoute(ctx context.Context, route *backend.Route, exclusive bool) error {
+	if !exclusive {
+		return p.backend.Register(ctx, route, p.config.TTL)
+	}
+	if er, ok := p.backend.(backend.ExclusiveRegisterer); ok {
+		return er.RegisterExclusive(ctx, route, p.config.TTL)
+	}
+
+	// 后端不支持原子的独占注册，退化为先读后写
+	existing, err := p.backend.Get(ctx, route.Key())
+	switch {
+	case err == nil && existing.Token != route.Token:
+		return backend.ErrRouteConflict
+	case err != nil && !errors.Is(err, backend.ErrRouteNotFound):
+		return err
+	}
+	return p.backend.Register(ctx, route, p.config.TTL)
+}
+
+// ReportAlive 报告应用存活，配合 WithLivenessDeadline 使用
+func (p *Proxy) ReportAlive() {
+	p.lastAlive.Store(time.Now().UnixNano())
+}
+
+// alive 判断应用是否在存活窗口内报告过存活（未启用看门狗时总是 true）
+func (p *Proxy) alive() bool {
+	if p.config.LivenessDeadline <= 0 {
+		return true
+	}
+	return time.Since(time.Unix(0, p.lastAlive.Load())) <= p.config.LivenessDeadline
+}
+
 // heartbeatSelf 自心跳
 func (p *Proxy) heartbeatSelf() error {
-	return p.backend.Heartbeat(
-		p.ctx,
-		p.config.LocalColor,
-		p.config.LocalAddress,
-		p.config.LocalToken,
-		p.config.TTL,
-	)
+	if !p.alive() {
+		p.config.Logger.Error("liveness deadline exceeded, heartbeat suppressed: color=%s", p.config.LocalColor)
+		return nil
+	}
+
+	if p.overloaded() && p.canSkipHeartbeat() {
+		p.config.Logger.Info("high load, heartbeat deferred: inflight=%d", p.inflight.Load())
+		return nil
+	}
+
+	reregistered, err := p.heartbeatRoute(p.ctx, p.selfRoute())
+	if err != nil {
+		return err
+	}
+	p.lastBeat.Store(time.Now().UnixNano())
+	if !reregistered {
+		p.emit(RouteRenewed, p.config.LocalColor, p.config.LocalAddress)
+	}
+	return nil
+}
+
+// heartbeatRoute 续期路由；启用 HeartbeatReregister 且路由已不存在时以独占方式重新注册 route，
+// 重新注册成功时返回 true 并发出 RouteRegistered 事件
+func (p *Proxy) heartbeatRoute(ctx context.Context, route *backend.Route) (bool, error) {
+	err := p.backend.Heartbeat(ctx, route.Key(), route.Address, route.Token, p.config.TTL)
+	if err == nil || !p.config.HeartbeatReregister || !errors.Is(err, backend.ErrRouteNotFound) {
+		return false, err
+	}
+
+	// 独占注册：期间已被其他 token 注册时不覆盖
+	if err := p.registerRoute(ctx, route, true); err != nil {
+		return false, err
+	}
+	p.emit(RouteRegistered, route.Key(), route.Address)
+	p.config.Logger.Info("route re-registered after heartbeat miss: color=%s, addr=%s", route.Key(), route.Address)
+	return true, nil
 }
 
-// Gin handlers
-func (p *Proxy) ginHandleRegister(c *gin.Context) {
+// 管理端点 handler：基于 net/http 实现，由 Gin、net/http 与 Echo 集成共用
+
+// handleRegister 注册路由：单地址 address，或多地址 endpoints
+func (p *Proxy) handleRegister(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Color   string `json:"color" binding:"required"`
-		Address string `json:"address" binding:"required"`
-		Owner   string `json:"owner"`
-		Token   string `json:"token" binding:"required"`
+		Color     string `json:"color"`
+		Address   string `json:"address"`
+		Endpoints []struct {
+			Address string `json:"address"`
+			Weight  int    `json:"weight"`
+		} `json:"endpoints"`
+		Owner      string            `json:"owner"`
+		Token      string            `json:"token"`
+		TTLSeconds int64             `json:"ttl_seconds"`
+		Version    string            `json:"version"`
+		Exclusive  bool              `json:"exclusive"`
+		Labels     map[string]string `json:"labels"`
+	}
+
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, jsonMap{"error": err.Error()})
+		return
+	}
+	if req.Color == "" || req.Token == "" {
+		writeJSON(w, 400, jsonMap{"error": "color and token are required"})
+		return
+	}
+	if err := validateRouteName(req.Color, req.Version); err != nil {
+		writeJSON(w, 400, jsonMap{"error": err.Error()})
+		return
+	}
+	if err := validateLabels(req.Labels); err != nil {
+		writeJSON(w, 400, jsonMap{"error": err.Error()})
+		return
+	}
+	if req.Address == "" && len(req.Endpoints) == 0 {
+		writeJSON(w, 400, jsonMap{"error": "address or endpoints is required"})
+		return
+	}
+	if req.Address != "" {
+		addr, err := normalizeAddress(req.Address)
+		if err != nil {
+			writeJSON(w, 400, jsonMap{"error": err.Error()})
+			return
+		}
+		req.Address = addr
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	ttl, err := routeTTL(req.TTLSeconds)
+	if err != nil {
+		writeJSON(w, 400, jsonMap{"error": err.Error()})
 		return
 	}
 
@@ -472,118 +1653,532 @@ func (p *Proxy) ginHandleRegister(c *gin.Context) {
 		Address: req.Address,
 		Owner:   req.Owner,
 		Token:   req.Token,
+		TTL:     ttl,
+		Version: req.Version,
+		Labels:  req.Labels,
+	}
+	for _, ep := range req.Endpoints {
+		if ep.Address == "" || ep.Weight < 0 {
+			writeJSON(w, 400, jsonMap{"error": "endpoint address is required and weight must be >= 0"})
+			return
+		}
+		addr, err := normalizeAddress(ep.Address)
+		if err != nil {
+			writeJSON(w, 400, jsonMap{"error": err.Error()})
+			return
+		}
+		route.Endpoints = append(route.Endpoints, backend.Endpoint{Address: addr, Weight: ep.Weight})
 	}
 
-	if err := p.backend.Register(c.Request.Context(), route, p.config.TTL); err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+	err = p.registerRoute(r.Context(), route, req.Exclusive || p.config.ExclusiveRegister)
+	if errors.Is(err, backend.ErrRouteConflict) {
+		writeJSON(w, 409, jsonMap{"error": "color is already registered by another token", "color": req.Color})
+		return
+	}
+	if err != nil {
+		writeJSON(w, 500, jsonMap{"error": err.Error()})
 		return
 	}
+	p.emit(RouteRegistered, route.Key(), route.Address)
+
+	resp := jsonMap{"message": "registered", "color": req.Color}
+	if req.Version != "" {
+		resp["version"] = req.Version
+	}
+	writeJSON(w, 200, resp)
+}
+
+// validateRouteName color 与 version 不能包含版本分隔符，否则存储 key 会与其他路由冲突
+func validateRouteName(color, version string) error {
+	if strings.Contains(color, backend.VersionSeparator) || strings.Contains(version, backend.VersionSeparator) {
+		return fmt.Errorf("color and version must not contain %q", backend.VersionSeparator)
+	}
+	return nil
+}
+
+// maxRouteLabels 单个路由的标签数上限
+const maxRouteLabels = 16
+
+// validateLabels 标签名不能为空，数量不超过 maxRouteLabels
+func validateLabels(labels map[string]string) error {
+	if len(labels) > maxRouteLabels {
+		return fmt.Errorf("at most %d labels are allowed", maxRouteLabels)
+	}
+	for k := range labels {
+		if k == "" {
+			return errors.New("label name must not be empty")
+		}
+	}
+	return nil
+}
+
+// maxRouteTTL 注册请求允许的最大路由 TTL
+const maxRouteTTL = 24 * time.Hour
 
-	c.JSON(200, gin.H{"message": "registered", "color": req.Color})
+// routeTTL 校验注册请求中的 TTL（秒），0 表示使用默认 TTL
+func routeTTL(seconds int64) (time.Duration, error) {
+	if seconds < 0 {
+		return 0, errors.New("ttl_seconds must be >= 0")
+	}
+	if seconds > int64(maxRouteTTL/time.Second) {
+		return 0, fmt.Errorf("ttl_seconds must be <= %d", int64(maxRouteTTL/time.Second))
+	}
+	return time.Duration(seconds) * time.Second, nil
 }
 
-func (p *Proxy) ginHandleHeartbeat(c *gin.Context) {
+// handleHeartbeat 心跳续期；owner、labels、ttl_seconds 与 endpoints 应与注册时一致，
+// 路由已过期、按 HeartbeatReregister 重新注册时据此还原路由
+func (p *Proxy) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Color   string `json:"color" binding:"required"`
-		Address string `json:"address" binding:"required"`
-		Token   string `json:"token" binding:"required"`
+		Color     string `json:"color"`
+		Version   string `json:"version"`
+		Address   string `json:"address"`
+		Token     string `json:"token"`
+		Endpoints []struct {
+			Address string `json:"address"`
+			Weight  int    `json:"weight"`
+		} `json:"endpoints"`
+		Owner      string            `json:"owner"`
+		TTLSeconds int64             `json:"ttl_seconds"`
+		Labels     map[string]string `json:"labels"`
+	}
+
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, jsonMap{"error": err.Error()})
+		return
+	}
+	if req.Color == "" || req.Address == "" || req.Token == "" {
+		writeJSON(w, 400, jsonMap{"error": "color, address and token are required"})
+		return
+	}
+	if err := validateRouteName(req.Color, req.Version); err != nil {
+		writeJSON(w, 400, jsonMap{"error": err.Error()})
+		return
+	}
+	if err := validateLabels(req.Labels); err != nil {
+		writeJSON(w, 400, jsonMap{"error": err.Error()})
+		return
+	}
+	// 与注册时的规范化保持一致，例如末尾带 "/" 的地址
+	addr, err := normalizeAddress(req.Address)
+	if err != nil {
+		writeJSON(w, 400, jsonMap{"error": err.Error()})
+		return
 	}
+	req.Address = addr
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	ttl, err := routeTTL(req.TTLSeconds)
+	if err != nil {
+		writeJSON(w, 400, jsonMap{"error": err.Error()})
 		return
 	}
 
-	if err := p.backend.Heartbeat(c.Request.Context(), req.Color, req.Address, req.Token, p.config.TTL); err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+	route := &backend.Route{
+		Color:   req.Color,
+		Address: req.Address,
+		Owner:   req.Owner,
+		Token:   req.Token,
+		TTL:     ttl,
+		Version: req.Version,
+		Labels:  req.Labels,
+	}
+	for _, ep := range req.Endpoints {
+		if ep.Address == "" || ep.Weight < 0 {
+			writeJSON(w, 400, jsonMap{"error": "endpoint address is required and weight must be >= 0"})
+			return
+		}
+		addr, err := normalizeAddress(ep.Address)
+		if err != nil {
+			writeJSON(w, 400, jsonMap{"error": err.Error()})
+			return
+		}
+		route.Endpoints = append(route.Endpoints, backend.Endpoint{Address: addr, Weight: ep.Weight})
+	}
+
+	reregistered, err := p.heartbeatRoute(r.Context(), route)
+	if errors.Is(err, backend.ErrRouteConflict) {
+		writeJSON(w, 409, jsonMap{"error": "color is already registered by another token", "color": req.Color})
 		return
 	}
+	if err != nil {
+		writeJSON(w, 500, jsonMap{"error": err.Error()})
+		return
+	}
+	if reregistered {
+		writeJSON(w, 200, jsonMap{"message": "route re-registered"})
+		return
+	}
+	p.emit(RouteRenewed, route.Key(), req.Address)
 
-	c.JSON(200, gin.H{"message": "heartbeat ok"})
+	writeJSON(w, 200, jsonMap{"message": "heartbeat ok"})
 }
 
-func (p *Proxy) ginHandleListRoutes(c *gin.Context) {
-	routes, err := p.backend.List(c.Request.Context())
+// handleListRoutes 列出路由，支持 ?owner= 与 ?color=（以 * 结尾表示前缀匹配）过滤
+func (p *Proxy) handleListRoutes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	routes, err := p.listRoutes(r.Context(), query.Get("owner"), query.Get("color"))
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		writeJSON(w, 500, jsonMap{"error": err.Error()})
 		return
 	}
 
-	c.JSON(200, gin.H{"routes": routes, "count": len(routes)})
+	writeJSON(w, 200, jsonMap{"routes": routes, "count": len(routes)})
 }
 
-func (p *Proxy) ginHandleDeleteRoute(c *gin.Context) {
-	color := c.Param("color")
-	if err := p.backend.Delete(c.Request.Context(), color); err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+// handleResolve 按转发时的选择流程（版本路由、回退链与查询超时）解析 ?color= 与 ?version= 的目标，不转发
+func (p *Proxy) handleResolve(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	color := query.Get("color")
+	if color == "" {
+		writeJSON(w, 400, jsonMap{"error": "color is required"})
+		return
+	}
+
+	req := strategy.ForColor(color)
+	req.Version = query.Get("version")
+	target, route, local, err := p.resolveRoute(r.Context(), req)
+	switch {
+	case isNoRoute(err):
+		writeJSON(w, 404, jsonMap{"error": "route not found", "color": color})
 		return
+	case errors.Is(err, ErrLookupTimeout):
+		writeJSON(w, 504, jsonMap{"error": err.Error(), "color": color})
+		return
+	case err != nil:
+		writeJSON(w, 500, jsonMap{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, jsonMap{"color": color, "target": target, "route": route, "local": local})
+}
+
+// listRoutes 列出路由：owner 非空时只返回该 owner 的路由（后端有索引时通过索引查询）；
+// color 非空时按 color 过滤，以 * 结尾表示前缀匹配，否则精确匹配，同一 color 的各版本都会返回
+func (p *Proxy) listRoutes(ctx context.Context, owner, color string) ([]*backend.Route, error) {
+	var (
+		routes []*backend.Route
+		err    error
+	)
+	if owner != "" {
+		routes, err = p.backend.ListByOwner(ctx, owner)
+	} else {
+		routes, err = p.backend.List(ctx)
+	}
+	if err != nil || color == "" {
+		return routes, err
 	}
 
-	c.JSON(200, gin.H{"message": "deleted", "color": color})
+	prefix, isPrefix := strings.CutSuffix(color, "*")
+	out := routes[:0]
+	for _, route := range routes {
+		if route.Color == color || (isPrefix && strings.HasPrefix(route.Color, prefix)) {
+			out = append(out, route)
+		}
+	}
+	return out, nil
 }
 
-func (p *Proxy) ginProxyMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 关键修复：检查请求是否已经被处理过（防止重复处理）
-		// 如果响应头已经写入，说明请求已经被处理，直接返回
-		if c.Writer.Written() {
+// handleDeleteRoute 删除路由：调用方需提供路由的 token（X-Route-Token header 或 body 中的 token），
+// 携带有效 admin token 且带 ?force=true 的请求跳过 token 校验强制删除；带版本的路由通过 ?version= 或路径 "<color>:<version>" 指定
+func (p *Proxy) handleDeleteRoute(w http.ResponseWriter, r *http.Request) {
+	color := backend.RouteKey(r.PathValue("color"), r.URL.Query().Get("version"))
+
+	token := r.Header.Get("X-Route-Token")
+	if token == "" && r.ContentLength != 0 {
+		var req struct {
+			Token string `json:"token"`
+		}
+		if err := decodeJSON(r, &req); err != nil {
+			writeJSON(w, 400, jsonMap{"error": err.Error()})
 			return
 		}
+		token = req.Token
+	}
 
-		// 获取 color header（不区分大小写）
-		color := c.GetHeader("color")
-		if color == "" {
-			// 尝试从其他可能的 header 名称获取（兼容性）
-			color = c.GetHeader("Color")
-			if color == "" {
-				color = c.GetHeader("COLOR")
-			}
+	// 管理端点都要求 admin 认证，强制删除需要显式开启，否则 admin 调用也校验路由 token
+	force := r.URL.Query().Get("force") == "true" && p.isAdminRequest(r)
+	err := p.deleteRoute(r.Context(), color, token, force)
+	switch {
+	case errors.Is(err, backend.ErrRouteNotFound):
+		writeJSON(w, 404, jsonMap{"error": "route not found", "color": color})
+		return
+	case errors.Is(err, backend.ErrTokenMismatch):
+		writeJSON(w, 403, jsonMap{"error": "token mismatch", "color": color})
+		return
+	case err != nil:
+		writeJSON(w, 500, jsonMap{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, 200, jsonMap{"message": "deleted", "color": color})
+}
+
+// deleteRoute 删除路由：force 为 false 时要求 token 与已注册路由一致
+// 路由不存在返回 backend.ErrRouteNotFound，token 不一致返回 backend.ErrTokenMismatch
+func (p *Proxy) deleteRoute(ctx context.Context, color, token string, force bool) error {
+	// 删除前记录地址，用于释放到该地址的连接
+	route, err := p.backend.Get(ctx, color)
+	if err != nil {
+		return err
+	}
+
+	switch td, ok := p.backend.(backend.TokenDeleter); {
+	case force:
+		err = p.backend.Delete(ctx, color)
+	case ok:
+		err = td.DeleteWithToken(ctx, color, token)
+	case route.Token != token:
+		err = backend.ErrTokenMismatch
+	default:
+		// 后端不支持原子校验删除，退化为先读后删
+		err = p.backend.Delete(ctx, color)
+	}
+	if err != nil {
+		return err
+	}
+
+	remaining := p.forgetRoute(color)
+	p.emit(RouteDeleted, color, route.Address)
+	p.releaseTargets(endpointAddresses(route), remaining)
+	return nil
+}
+
+// selectTarget 使用路由策略选择目标
+// 配置了 LookupTimeout 时为查询设置独立的超时上限，超时返回 ErrLookupTimeout
+func (p *Proxy) selectTarget(ctx context.Context, req strategy.RoutingRequest) (string, error) {
+	if p.config.LookupTimeout <= 0 {
+		return p.strategy.Select(ctx, req)
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, p.config.LookupTimeout)
+	defer cancel()
+
+	target, err := p.strategy.Select(lookupCtx, req)
+	if err != nil && errors.Is(lookupCtx.Err(), context.DeadlineExceeded) {
+		p.config.Logger.Error("route lookup timed out: color=%s, timeout=%v", req.Color, p.config.LookupTimeout)
+		return "", ErrLookupTimeout
+	}
+	return target, err
+}
+
+// removeTarget 通知传输层释放到 address 的缓存与连接（传输层支持时）
+func (p *Proxy) removeTarget(address string) {
+	if r, ok := p.http.(transport.TargetRemover); ok {
+		r.RemoveTarget(address)
+	}
+}
+
+// routingRequest 构造 HTTP 请求的路由请求，并按 VersionHeader 解析版本
+func (p *Proxy) routingRequest(color string, r *http.Request) strategy.RoutingRequest {
+	req := strategy.FromHTTP(color, r)
+	if p.config.VersionHeader != "" {
+		req.Version = r.Header.Get(p.config.VersionHeader)
+	}
+	return req
+}
+
+// requestColor 解析请求的 color：先用 ColorExtractor 提取，再按 Content-Type 映射补充或覆盖，最后回退到默认 color
+// tr 不为 nil 时记录每个来源的判定过程（用于 /trace 调试）
+func (p *Proxy) requestColor(req *http.Request, tr *routeTrace) string {
+	// 使用配置的提取器获取 color（默认读取 color header）
+	color := p.config.ColorExtractor(req)
+	tr.add("extractor", color, "configured color extractor")
+
+	// 按 Content-Type 路由：header 缺失时补充，或在配置了覆盖时替换 header
+	if ctColor := p.contentTypeColor(req); ctColor != "" {
+		if color == "" || p.config.ContentTypeOverride {
+			tr.add("content-type", ctColor, "applied")
+			color = ctColor
+		} else {
+			tr.add("content-type", ctColor, "ignored: color header wins")
 		}
+	}
 
-		// 如果没有 color header，继续正常处理
-		if color == "" {
-			c.Next()
-			return
+	// 仍未解析出 color 时使用默认 color（纯网关部署没有本地处理逻辑）
+	if color == "" && p.config.DefaultColor != "" {
+		tr.add("default", p.config.DefaultColor, "no color in request")
+		color = p.config.DefaultColor
+	}
+
+	return color
+}
+
+// serveProxy 按 color 转发请求（Gin / net/http / Echo 共用）
+// 请求不需要转发（无 color、本地 color、找不到路由）时调用 next 交给后续处理并返回 false；
+// 已由代理处理（转发或输出错误响应）时返回 true，调用方应终止后续处理。
+func (p *Proxy) serveProxy(w http.ResponseWriter, r *http.Request, next func()) bool {
+	// 统计进行中的请求数（含本地处理），用于背压调度
+	p.inflight.Add(1)
+	defer p.inflight.Add(-1)
+	start := time.Now()
+
+	// 跨域预检由代理本地应答，不依赖 color（浏览器的预检请求不携带自定义 header）
+	if p.localPreflight(r) {
+		p.servePreflight(w, r)
+		return true
+	}
+
+	// 受信任请求指定了目标：直接转发，不经过 color 与策略
+	if target := p.overrideTarget(r); target != "" {
+		if !p.drain.enter() {
+			p.writeError(w, http.StatusServiceUnavailable, "proxy is shutting down", "", nil)
+			return true
 		}
+		defer p.drain.leave()
+		p.proxyOverride(w, r, target)
+		return true
+	}
 
-		// 关键修复：如果请求的 color 和自己的颜色一样，直接处理，不再转发
-		if p.config.LocalColor != "" && color == p.config.LocalColor {
-			c.Next()
-			return
+	// 解析请求的 color
+	color := p.requestColor(r, nil)
+
+	// 如果没有 color header，继续正常处理；严格路由下返回 400
+	if color == "" {
+		if p.config.StrictRouting {
+			p.writeError(w, http.StatusBadRequest, "color is required", "", nil)
+			return true
 		}
+		next()
+		return false
+	}
 
-		// 使用策略选择目标
-		target, err := p.strategy.Select(c.Request.Context(), color)
-		if err != nil {
-			// 如果找不到匹配的 color 服务，继续正常处理请求
-			c.Next()
-			return
+	// 关键修复：如果请求的 color 和自己的颜色一样，直接处理，不再转发
+	if p.config.LocalColor != "" && color == p.config.LocalColor {
+		next()
+		return false
+	}
+
+	// 镜像请求只在本地处理，避免影子服务再把流量转发出去
+	if p.isMirrored(r) {
+		next()
+		return false
+	}
+
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+	// 正在关闭：拒绝新的转发，已开始的转发由 Shutdown 等待完成
+	if !p.drain.enter() {
+		p.writeError(sw, http.StatusServiceUnavailable, "proxy is shutting down", "", jsonMap{"color": color})
+		p.observeRequest(sw, r, color, "", start)
+		return true
+	}
+	defer p.drain.leave()
+
+	// 维护模式：直接返回静态响应，不再选择目标
+	if p.inMaintenance(color) {
+		p.writeMaintenance(sw, color)
+		p.observeRequest(sw, r, color, "", start)
+		return true
+	}
+
+	// 使用策略选择目标，携带原始请求供一致性哈希等策略使用
+	// 没有路由时沿回退链查找，整条链都没有路由（或回退到本地 color）时本地处理
+	target, route, err := p.selectWithFallback(r.Context(), p.routingRequest(color, r))
+	if err != nil {
+		// 路由查询超时且配置了状态码时直接返回错误，避免慢后端拖住请求
+		if errors.Is(err, ErrLookupTimeout) && p.config.LookupTimeoutStatus != 0 {
+			p.writeError(sw, p.config.LookupTimeoutStatus, "route lookup timed out", "", jsonMap{"color": color})
+			p.observeError(color, "lookup_timeout")
+			p.observeRequest(sw, r, color, "", start)
+			return true
+		}
+		// 严格路由下没有可用路由返回 503；回退到本地 color 时仍在本地处理
+		if p.config.StrictRouting && !errors.Is(err, errFallbackLocal) {
+			p.writeError(sw, http.StatusServiceUnavailable, "no healthy route for color", err.Error(), jsonMap{"color": color})
+			p.observeError(color, "no_route")
+			p.observeRequest(sw, r, color, "", start)
+			return true
+		}
+		// 如果找不到匹配的 color 服务，继续正常处理请求
+		next()
+		return false
+	}
+
+	// color 策略限制了方法：其他方法返回 405，不占用限流与并发名额
+	if !p.methodAllowed(color, r.Method) {
+		p.writeMethodNotAllowed(sw, color, r.Method)
+		p.observeError(color, "method_not_allowed")
+		p.observeRequest(sw, r, color, route, start)
+		return true
+	}
+
+	// 按 color 限流：超出速率时返回 429，不再转发
+	if p.limiter != nil {
+		if ok, delay := p.limiter.allow(color); !ok {
+			p.writeRateLimited(sw, color, delay)
+			p.observeError(color, "rate_limited")
+			p.observeRequest(sw, r, color, route, start)
+			return true
+		}
+	}
+
+	// 转发前授权：拒绝时不再转发
+	if p.config.ProxyAuthorizer != nil {
+		if err := p.config.ProxyAuthorizer(r.Context(), color, r); err != nil {
+			p.writeAuthorizerError(sw, err)
+			p.observeRequest(sw, r, color, route, start)
+			return true
 		}
+	}
 
-		// 关键修复：在调用 Proxy 之前，先标记请求正在处理
-		// 使用 Abort() 确保即使 Proxy 内部出错，也不会继续后续处理
-		c.Abort()
+	// 按 color 限制并发：名额已满（排队超时）时返回 503，不再转发
+	if p.concurrency != nil {
+		release, ok := p.concurrency.acquire(r.Context(), color)
+		if !ok {
+			p.writeConcurrencyLimited(sw, color)
+			p.observeError(color, "concurrency_limited")
+			p.observeRequest(sw, r, color, route, start)
+			return true
+		}
+		defer release()
+	}
 
-		// 使用传输层转发
-		// 注意：即使 Proxy 返回错误，我们也已经 Abort() 了，不会继续处理
-		if err := p.http.Proxy(c.Request.Context(), target, c.Request, c.Writer); err != nil {
-			// 只有在响应还没写入时才写入错误响应
-			if !c.Writer.Written() {
+	// 按比例异步镜像到影子 color，不影响本次转发
+	mirrorDone := p.startMirror(r, color)
+	defer mirrorDone()
+
+	// 按 color 策略改写 header、设置超时与重试（镜像请求保持原样）
+	r = p.applyPolicy(r, color)
+
+	// 使用传输层转发，启用追踪时 span 覆盖整个转发过程
+	fr, endSpan := p.startForward(r, color, target)
+	p.counters.forwarded.Add(1)
+	err = p.http.Proxy(fr.Context(), target, fr, sw)
+	tooLarge := errors.Is(err, transport.ErrResponseTooLarge)
+	if tooLarge {
+		// 响应头已经写出，截断的 body 无法正常结束，记录后中断连接
+		p.config.Logger.Error("response too large for color=%s, target=%s: %v", color, target, err)
+		p.observeError(color, "response_too_large")
+	} else if err != nil {
+		// 只有在响应还没写入时才写入错误响应
+		if !sw.wroteHeader {
+			if h := p.config.RequestIDHeader; h != "" {
+				p.config.Logger.Error("proxy failed for color=%s, target=%s, request_id=%s: %v", color, target, fr.Header.Get(h), err)
+			} else {
 				p.config.Logger.Error("proxy failed for color=%s, target=%s: %v", color, target, err)
-				c.JSON(502, gin.H{"error": "proxy failed", "detail": err.Error()})
 			}
-			return
+			p.writeError(sw, 502, "proxy failed", err.Error(), nil)
 		}
-
-		// 代理成功，已经 Abort()，直接返回
-		return
+		p.observeError(color, "proxy")
+	}
+	endSpan(sw.status, err)
+	p.observeCanary(target, sw.status)
+	p.logAccess(fr, sw, color, target, start, err)
+	p.observeRequest(sw, fr, color, route, start)
+	if tooLarge {
+		abortResponse(sw)
 	}
+	return true
 }
 
-// Shutdown 优雅退出：停止后台任务、删除自己的注册、关闭所有资源
+// Shutdown 优雅退出：拒绝新的转发、删除自己的注册、等待进行中的转发完成、停止后台任务、关闭所有资源
+// 新的转发请求返回 503；ctx 结束前仍未完成的转发会被放弃，此时返回 ctx.Err() 且不关闭传输层
 func (p *Proxy) Shutdown(ctx context.Context) error {
 	p.config.Logger.Info("shutting down proxy...")
 
+	// 不再接收新的转发
+	p.drain.close()
+
 	// 停止后台任务
 	p.cancel()
 
@@ -596,6 +2191,12 @@ func (p *Proxy) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	// 等待进行中的转发完成，避免关闭传输层时中断响应
+	if err := p.drain.wait(ctx); err != nil {
+		p.config.Logger.Error("shutdown timeout: in-flight proxied requests not finished")
+		return err
+	}
+
 	done := make(chan struct{})
 	go func() {
 		p.wg.Wait()
@@ -646,8 +2247,20 @@ func (p *Proxy) Close() error {
 // 错误定义
 var (
 	ErrBackendRequired = &ProxyError{Code: "BACKEND_REQUIRED", Message: "backend is required"}
+	ErrLookupTimeout   = &ProxyError{Code: "LOOKUP_TIMEOUT", Message: "route lookup timed out"}
+	ErrShuttingDown    = &ProxyError{Code: "SHUTTING_DOWN", Message: "proxy is shutting down"}
 )
 
+// HTTPError 携带 HTTP 状态码的错误，钩子（如 ProxyAuthorizer）可返回它指定响应状态码
+type HTTPError struct {
+	Status  int
+	Message string
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
 type ProxyError struct {
 	Code    string
 	Message string
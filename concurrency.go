@@ -0,0 +1,119 @@
+package color
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// concurrencyLimiter 按 color 限制同时进行的转发数，信号量在某个 color 第一次转发时创建
+// 没有进行中请求的信号量在清理任务中回收（不含任何状态，重新创建不影响限制效果）
+// overrides 中的 color（来自 ColorPolicy）使用各自的上限；全局上限为 0 时其他 color 不限制
+type concurrencyLimiter struct {
+	limit        int
+	overrides    map[string]int
+	queueTimeout time.Duration
+
+	mu   sync.Mutex
+	sems map[string]*colorSemaphore
+}
+
+type colorSemaphore struct {
+	ch   chan struct{}
+	refs int // 正在等待或持有的请求数，大于 0 时不回收
+}
+
+func newConcurrencyLimiter(limit int, overrides map[string]int, queueTimeout time.Duration) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		limit:        limit,
+		overrides:    overrides,
+		queueTimeout: queueTimeout,
+		sems:         make(map[string]*colorSemaphore),
+	}
+}
+
+// acquire 占用 color 的一个并发名额，成功时返回释放函数
+// 名额已满时：未配置排队超时则立即失败，否则最多等待 queueTimeout（或 ctx 结束）
+func (cl *concurrencyLimiter) acquire(ctx context.Context, color string) (func(), bool) {
+	limit := cl.limitFor(color)
+	if limit <= 0 {
+		return func() {}, true
+	}
+
+	cl.mu.Lock()
+	sem, ok := cl.sems[color]
+	if !ok {
+		sem = &colorSemaphore{ch: make(chan struct{}, limit)}
+		cl.sems[color] = sem
+	}
+	sem.refs++
+	cl.mu.Unlock()
+
+	release := func() {
+		<-sem.ch
+		cl.unref(sem)
+	}
+
+	select {
+	case sem.ch <- struct{}{}:
+		return release, true
+	default:
+	}
+	if cl.queueTimeout <= 0 {
+		cl.unref(sem)
+		return nil, false
+	}
+
+	timer := time.NewTimer(cl.queueTimeout)
+	defer timer.Stop()
+	select {
+	case sem.ch <- struct{}{}:
+		return release, true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	cl.unref(sem)
+	return nil, false
+}
+
+// limitFor 返回 color 的并发上限，0 表示不限制
+func (cl *concurrencyLimiter) limitFor(color string) int {
+	if n, ok := cl.overrides[color]; ok {
+		return n
+	}
+	return cl.limit
+}
+
+func (cl *concurrencyLimiter) unref(sem *colorSemaphore) {
+	cl.mu.Lock()
+	sem.refs--
+	cl.mu.Unlock()
+}
+
+// inflight 返回 color 当前进行中的转发数（用于 /trace）
+func (cl *concurrencyLimiter) inflight(color string) int {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if sem, ok := cl.sems[color]; ok {
+		return len(sem.ch)
+	}
+	return 0
+}
+
+// prune 回收没有进行中或排队请求的信号量
+func (cl *concurrencyLimiter) prune() {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	for color, sem := range cl.sems {
+		if sem.refs == 0 {
+			delete(cl.sems, color)
+		}
+	}
+}
+
+// writeConcurrencyLimited 返回 503，提示客户端稍后重试
+func (p *Proxy) writeConcurrencyLimited(w http.ResponseWriter, color string) {
+	w.Header().Set("Retry-After", "1")
+	p.writeError(w, http.StatusServiceUnavailable, "too many concurrent requests", "", jsonMap{"color": color, "limit": p.concurrency.limitFor(color)})
+}
@@ -0,0 +1,92 @@
+package color
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// inflightBackend 记录同时处理中的请求数峰值，每个请求保持 hold 时长
+func inflightBackend(t *testing.T, hold time.Duration) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var current, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := current.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(hold)
+		current.Add(-1)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &peak
+}
+
+// loadColor 并发发送 n 个 color 请求，返回各状态码的数量
+func loadColor(p *Proxy, color string, n int) map[int]int {
+	var mu sync.Mutex
+	codes := make(map[int]int)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/api", nil)
+			req.Header.Set("color", color)
+			code := serve(p, req).Code
+			mu.Lock()
+			codes[code]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return codes
+}
+
+func TestMaxConcurrencyRejectMode(t *testing.T) {
+	srv, peak := inflightBackend(t, 50*time.Millisecond)
+	p := newTestProxy(t, WithMaxConcurrency(3))
+	register(t, p, &backend.Route{Color: "fragile", Address: srv.URL, Token: "t"})
+
+	codes := loadColor(p, "fragile", 40)
+	if peak.Load() > 3 {
+		t.Errorf("backend saw %d concurrent requests, want at most 3", peak.Load())
+	}
+	if codes[http.StatusOK] == 0 || codes[http.StatusServiceUnavailable] == 0 || codes[http.StatusOK]+codes[http.StatusServiceUnavailable] != 40 {
+		t.Errorf("status counts = %v, want a mix of 200 and 503", codes)
+	}
+}
+
+func TestMaxConcurrencyQueueMode(t *testing.T) {
+	srv, peak := inflightBackend(t, 10*time.Millisecond)
+	p := newTestProxy(t, WithMaxConcurrency(3), WithConcurrencyQueue(5*time.Second))
+	register(t, p, &backend.Route{Color: "fragile", Address: srv.URL, Token: "t"})
+
+	codes := loadColor(p, "fragile", 40)
+	if peak.Load() > 3 {
+		t.Errorf("backend saw %d concurrent requests, want at most 3", peak.Load())
+	}
+	if codes[http.StatusOK] != 40 {
+		t.Errorf("status counts = %v, want every queued request served", codes)
+	}
+
+	// 请求全部结束后信号量不再被引用，清理时回收
+	p.concurrency.prune()
+	if n := p.concurrency.inflight("fragile"); n != 0 {
+		t.Errorf("inflight after load = %d, want 0", n)
+	}
+	p.concurrency.mu.Lock()
+	remaining := len(p.concurrency.sems)
+	p.concurrency.mu.Unlock()
+	if remaining != 0 {
+		t.Errorf("%d semaphores left after prune, want 0", remaining)
+	}
+}
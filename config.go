@@ -0,0 +1,417 @@
+package color
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+	"github.com/goccy/go-yaml"
+)
+
+// FileConfig 配置文件（JSON 或 YAML）的结构，也是 NewFromEnv 读取的环境变量的目标
+// 加载后转换为 Option 交给 New，未出现的字段保持 New 的默认值：
+//
+//	backend:
+//	  type: redis          # memory | redis | etcd | consul
+//	  address: localhost:6379
+//	transport:
+//	  timeout: 30s
+//	strategy: roundrobin   # simple | weighted | roundrobin | consistenthash
+//	ttl: 2m
+//	auto_register:
+//	  color: blue
+//	  address: "8080"
+//	  token: secret
+//	admin_token: admin-secret
+type FileConfig struct {
+	Backend   BackendFileConfig   `json:"backend"`
+	Transport TransportFileConfig `json:"transport"`
+
+	// 路由策略，hash_header 为 consistenthash 策略取 key 的 header（为空时使用客户端 IP）
+	Strategy   string `json:"strategy"`
+	HashHeader string `json:"hash_header"`
+
+	TTL           Duration `json:"ttl"`
+	SelfTTL       Duration `json:"self_ttl"`
+	HeartbeatRate Duration `json:"heartbeat_rate"`
+	CleanupRate   Duration `json:"cleanup_rate"`
+
+	// 心跳与清理间隔的抖动比例，见 WithHeartbeatJitter
+	HeartbeatJitter float64 `json:"heartbeat_jitter"`
+
+	AutoRegister *AutoRegisterFileConfig `json:"auto_register"`
+
+	AdminToken  string `json:"admin_token"`
+	AdminPrefix string `json:"admin_prefix"`
+}
+
+// BackendFileConfig 后端配置，按 type 使用对应的字段
+type BackendFileConfig struct {
+	Type       string   `json:"type"`
+	Address    string   `json:"address"`   // redis、consul
+	Password   string   `json:"password"`  // redis、etcd
+	DB         int      `json:"db"`        // redis
+	Endpoints  []string `json:"endpoints"` // etcd
+	Username   string   `json:"username"`  // etcd
+	Token      string   `json:"token"`     // consul
+	Datacenter string   `json:"datacenter"`
+}
+
+// TransportFileConfig 传输层配置，type 目前只支持 http；grpc_insecure 以明文连接 gRPC 后端（仅用于本地开发）
+type TransportFileConfig struct {
+	Type         string   `json:"type"`
+	Timeout      Duration `json:"timeout"`
+	GRPCTimeout  Duration `json:"grpc_timeout"`
+	GRPCInsecure bool     `json:"grpc_insecure"`
+}
+
+// AutoRegisterFileConfig 自动注册配置，对应 WithAutoRegister
+type AutoRegisterFileConfig struct {
+	Color   string `json:"color"`
+	Address string `json:"address"`
+	Token   string `json:"token"`
+	Owner   string `json:"owner"`
+
+	// address 只有端口时补全 IP 的方式，对应 WithAdvertiseAddress / WithAdvertiseInterface / WithAdvertiseExclude
+	AdvertiseAddress   string   `json:"advertise_address"`
+	AdvertiseInterface string   `json:"advertise_interface"`
+	AdvertiseExclude   []string `json:"advertise_exclude"`
+}
+
+// Duration 配置文件中的时长，可以写作 "30s"、"2m" 或秒数
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*d = Duration(v * float64(time.Second))
+	case string:
+		parsed, err := parseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	case nil:
+	default:
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	return nil
+}
+
+// parseDuration 解析 "30s" 形式的时长，纯数字按秒处理
+func parseDuration(s string) (time.Duration, error) {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// LoadConfig 读取配置文件，.yaml / .yml 按 YAML 解析，其他按 JSON 解析；未知字段返回错误
+func LoadConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if data, err = yaml.YAMLToJSON(data); err != nil {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	}
+
+	fc := &FileConfig{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(fc); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return fc, nil
+}
+
+// 环境变量，NewFromEnv 使用；COLORPROXY_CONFIG 指定的配置文件先加载，其余变量覆盖文件中的值
+const (
+	envConfig            = "COLORPROXY_CONFIG"
+	envBackend           = "COLORPROXY_BACKEND"
+	envBackendAddress    = "COLORPROXY_BACKEND_ADDRESS"
+	envBackendPassword   = "COLORPROXY_BACKEND_PASSWORD"
+	envBackendDB         = "COLORPROXY_BACKEND_DB"
+	envBackendEndpoints  = "COLORPROXY_BACKEND_ENDPOINTS" // 逗号分隔
+	envBackendUsername   = "COLORPROXY_BACKEND_USERNAME"
+	envBackendToken      = "COLORPROXY_BACKEND_TOKEN"
+	envBackendDatacenter = "COLORPROXY_BACKEND_DATACENTER"
+	envTransport         = "COLORPROXY_TRANSPORT"
+	envHTTPTimeout       = "COLORPROXY_HTTP_TIMEOUT"
+	envGRPCTimeout       = "COLORPROXY_GRPC_TIMEOUT"
+	envGRPCInsecure      = "COLORPROXY_GRPC_INSECURE"
+	envStrategy          = "COLORPROXY_STRATEGY"
+	envHashHeader        = "COLORPROXY_HASH_HEADER"
+	envTTL               = "COLORPROXY_TTL"
+	envSelfTTL           = "COLORPROXY_SELF_TTL"
+	envHeartbeatRate     = "COLORPROXY_HEARTBEAT_RATE"
+	envCleanupRate       = "COLORPROXY_CLEANUP_RATE"
+	envHeartbeatJitter   = "COLORPROXY_HEARTBEAT_JITTER"
+	envColor             = "COLORPROXY_COLOR"
+	envAddress           = "COLORPROXY_ADDRESS"
+	envToken             = "COLORPROXY_TOKEN"
+	envOwner             = "COLORPROXY_OWNER"
+	envAdvertiseAddress  = "COLORPROXY_ADVERTISE_ADDRESS"
+	envAdvertiseIface    = "COLORPROXY_ADVERTISE_INTERFACE"
+	envAdminToken        = "COLORPROXY_ADMIN_TOKEN"
+	envAdminPrefix       = "COLORPROXY_ADMIN_PREFIX"
+)
+
+// LoadEnv 从环境变量读取配置，见 envConfig 等常量
+func LoadEnv() (*FileConfig, error) {
+	fc := &FileConfig{}
+	if path := os.Getenv(envConfig); path != "" {
+		loaded, err := LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		fc = loaded
+	}
+
+	setString := func(dst *string, key string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*dst = v
+		}
+	}
+	var err error
+	setDuration := func(dst *Duration, key string) {
+		if v, ok := os.LookupEnv(key); ok && err == nil {
+			var d time.Duration
+			if d, err = parseDuration(v); err != nil {
+				err = fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = Duration(d)
+		}
+	}
+
+	setString(&fc.Backend.Type, envBackend)
+	setString(&fc.Backend.Address, envBackendAddress)
+	setString(&fc.Backend.Password, envBackendPassword)
+	setString(&fc.Backend.Username, envBackendUsername)
+	setString(&fc.Backend.Token, envBackendToken)
+	setString(&fc.Backend.Datacenter, envBackendDatacenter)
+	if v, ok := os.LookupEnv(envBackendDB); ok {
+		db, perr := strconv.Atoi(v)
+		if perr != nil {
+			return nil, fmt.Errorf("%s: invalid db %q", envBackendDB, v)
+		}
+		fc.Backend.DB = db
+	}
+	if v, ok := os.LookupEnv(envBackendEndpoints); ok {
+		fc.Backend.Endpoints = nil
+		for _, ep := range strings.Split(v, ",") {
+			if ep = strings.TrimSpace(ep); ep != "" {
+				fc.Backend.Endpoints = append(fc.Backend.Endpoints, ep)
+			}
+		}
+	}
+
+	setString(&fc.Transport.Type, envTransport)
+	setDuration(&fc.Transport.Timeout, envHTTPTimeout)
+	setDuration(&fc.Transport.GRPCTimeout, envGRPCTimeout)
+	if v, ok := os.LookupEnv(envGRPCInsecure); ok {
+		insecure, perr := strconv.ParseBool(v)
+		if perr != nil {
+			return nil, fmt.Errorf("%s: invalid bool %q", envGRPCInsecure, v)
+		}
+		fc.Transport.GRPCInsecure = insecure
+	}
+	setString(&fc.Strategy, envStrategy)
+	setString(&fc.HashHeader, envHashHeader)
+	setDuration(&fc.TTL, envTTL)
+	setDuration(&fc.SelfTTL, envSelfTTL)
+	setDuration(&fc.HeartbeatRate, envHeartbeatRate)
+	setDuration(&fc.CleanupRate, envCleanupRate)
+	if v, ok := os.LookupEnv(envHeartbeatJitter); ok {
+		jitter, perr := strconv.ParseFloat(v, 64)
+		if perr != nil {
+			return nil, fmt.Errorf("%s: invalid number %q", envHeartbeatJitter, v)
+		}
+		fc.HeartbeatJitter = jitter
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if v := os.Getenv(envColor); v != "" {
+		if fc.AutoRegister == nil {
+			fc.AutoRegister = &AutoRegisterFileConfig{}
+		}
+		fc.AutoRegister.Color = v
+	}
+	if fc.AutoRegister != nil {
+		setString(&fc.AutoRegister.Address, envAddress)
+		setString(&fc.AutoRegister.Token, envToken)
+		setString(&fc.AutoRegister.Owner, envOwner)
+		setString(&fc.AutoRegister.AdvertiseAddress, envAdvertiseAddress)
+		setString(&fc.AutoRegister.AdvertiseInterface, envAdvertiseIface)
+	}
+
+	setString(&fc.AdminToken, envAdminToken)
+	setString(&fc.AdminPrefix, envAdminPrefix)
+	return fc, nil
+}
+
+// Options 把配置转换为 Option；会按配置连接后端，未知的后端、传输层或策略返回错误
+// 返回的 Option 中已包含连接好的后端，调用方在 New 失败时负责关闭（见 NewFromConfig）
+func (fc *FileConfig) Options() ([]Option, error) {
+	var opts []Option
+
+	switch strings.ToLower(fc.Transport.Type) {
+	case "", "http":
+		if fc.Transport.Timeout > 0 {
+			opts = append(opts, WithHTTPTransport(time.Duration(fc.Transport.Timeout)))
+		}
+	default:
+		return nil, fmt.Errorf("unknown transport %q (supported: http)", fc.Transport.Type)
+	}
+	if fc.Transport.GRPCTimeout > 0 {
+		opts = append(opts, WithGRPCTransport(time.Duration(fc.Transport.GRPCTimeout)))
+	}
+	if fc.Transport.GRPCInsecure {
+		opts = append(opts, WithGRPCInsecure())
+	}
+
+	switch strings.ToLower(fc.Strategy) {
+	case "", "simple":
+	case "weighted":
+		opts = append(opts, WithWeightedStrategy())
+	case "roundrobin":
+		opts = append(opts, WithRoundRobinStrategy())
+	case "consistenthash":
+		var keyFunc func(*http.Request) string
+		if header := fc.HashHeader; header != "" {
+			keyFunc = func(r *http.Request) string { return r.Header.Get(header) }
+		}
+		opts = append(opts, WithConsistentHashStrategy(keyFunc))
+	default:
+		return nil, fmt.Errorf("unknown strategy %q (supported: simple, weighted, roundrobin, consistenthash)", fc.Strategy)
+	}
+
+	if fc.TTL > 0 {
+		opts = append(opts, WithTTL(time.Duration(fc.TTL)))
+	}
+	if fc.SelfTTL > 0 {
+		opts = append(opts, WithSelfTTL(time.Duration(fc.SelfTTL)))
+	}
+	if fc.HeartbeatRate > 0 || fc.CleanupRate > 0 {
+		opts = append(opts, func(c *Config) {
+			if fc.HeartbeatRate > 0 {
+				c.HeartbeatRate = time.Duration(fc.HeartbeatRate)
+			}
+			if fc.CleanupRate > 0 {
+				c.CleanupRate = time.Duration(fc.CleanupRate)
+			}
+		})
+	}
+	if fc.HeartbeatJitter != 0 {
+		opts = append(opts, WithHeartbeatJitter(fc.HeartbeatJitter))
+	}
+	if ar := fc.AutoRegister; ar != nil {
+		opts = append(opts,
+			WithAutoRegister(ar.Color, ar.Address, ar.Token, ar.Owner),
+			WithAdvertiseAddress(ar.AdvertiseAddress),
+			WithAdvertiseInterface(ar.AdvertiseInterface),
+			WithAdvertiseExclude(ar.AdvertiseExclude...),
+		)
+	}
+	if fc.AdminToken != "" {
+		opts = append(opts, WithAdminToken(fc.AdminToken))
+	}
+	if fc.AdminPrefix != "" {
+		opts = append(opts, WithAdminPrefix(fc.AdminPrefix))
+	}
+
+	// 未配置后端时由 New 的 opts 提供（如 WithSQL）
+	if fc.Backend.Type == "" {
+		return opts, nil
+	}
+	// 后端最后创建：前面的配置有误时不会留下连接
+	b, err := fc.Backend.open()
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, WithBackend(b)), nil
+}
+
+// open 按 type 创建后端
+func (bc *BackendFileConfig) open() (backend.Backend, error) {
+	var (
+		b   backend.Backend
+		err error
+	)
+	switch strings.ToLower(bc.Type) {
+	case "memory":
+		b = backend.NewMemoryBackend()
+	case "redis":
+		b, err = backend.NewRedisBackend(&backend.RedisConfig{Addr: bc.Address, Password: bc.Password, DB: bc.DB})
+	case "etcd":
+		b, err = backend.NewEtcdBackend(&backend.EtcdConfig{Endpoints: bc.Endpoints, Username: bc.Username, Password: bc.Password})
+	case "consul":
+		b, err = backend.NewConsulBackend(&backend.ConsulConfig{Address: bc.Address, Token: bc.Token, Datacenter: bc.Datacenter})
+	default:
+		// SQL 后端需要调用方创建 *sql.DB 并引入驱动，只能通过 WithSQL 配置
+		return nil, fmt.Errorf("unknown backend %q (supported: memory, redis, etcd, consul)", bc.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s backend: %w", bc.Type, err)
+	}
+	return b, nil
+}
+
+// NewFromConfig 读取配置文件（JSON 或 YAML，见 FileConfig）创建代理
+// opts 在配置文件之后应用，可用于补充或覆盖文件中的配置
+func NewFromConfig(path string, opts ...Option) (*Proxy, error) {
+	fc, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return newFromFileConfig(fc, opts)
+}
+
+// NewFromEnv 从环境变量创建代理：COLORPROXY_CONFIG 指定的配置文件先加载，
+// 其余 COLORPROXY_* 变量（如 COLORPROXY_BACKEND、COLORPROXY_BACKEND_ADDRESS、COLORPROXY_TTL）覆盖文件中的值
+func NewFromEnv(opts ...Option) (*Proxy, error) {
+	fc, err := LoadEnv()
+	if err != nil {
+		return nil, err
+	}
+	return newFromFileConfig(fc, opts)
+}
+
+func newFromFileConfig(fc *FileConfig, opts []Option) (*Proxy, error) {
+	fileOpts, err := fc.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	var opened backend.Backend
+	fileOpts = append(fileOpts, func(c *Config) { opened = c.Backend })
+	p, err := New(append(fileOpts, opts...)...)
+	if err != nil {
+		if opened != nil {
+			opened.Close()
+		}
+		return nil, err
+	}
+	return p, nil
+}
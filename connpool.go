@@ -0,0 +1,54 @@
+package color
+
+import (
+	"time"
+
+	"github.com/asam264/color/internal/transport"
+)
+
+// defaultConnPoolInterval 连接池采样的默认间隔
+const defaultConnPoolInterval = 15 * time.Second
+
+// ConnPoolStat 单个后端 host 的连接池状态：空闲连接数与使用中连接数
+type ConnPoolStat = transport.ConnStat
+
+// WithConnPoolMetrics 按 interval（<= 0 时为 15 秒）定期采样内置 HTTP 传输层连接池中各 host 的空闲与使用中连接数，
+// 交给 MetricsCollector.ObserveConnPool，并在 Stats 中返回最新状态，用于在连接池耗尽导致拨号错误之前发现压力
+// 统计在每次拨号与请求时计数，只在开启后生效；HTTP/2 下使用中的数量为进行中的流数
+func WithConnPoolMetrics(interval time.Duration) Option {
+	return func(c *Config) {
+		if interval <= 0 {
+			interval = defaultConnPoolInterval
+		}
+		c.ConnPoolInterval = interval
+		c.HTTPOptions = append(c.HTTPOptions, transport.WithConnStats(true))
+	}
+}
+
+// connStater 传输层可选接口：返回各 host 的连接池状态
+type connStater interface {
+	ConnStats() map[string]transport.ConnStat
+}
+
+// connPoolStats 返回传输层的连接池状态，未启用或传输层不支持时返回 nil
+func (p *Proxy) connPoolStats() map[string]ConnPoolStat {
+	cs, ok := p.http.(connStater)
+	if !ok {
+		return nil
+	}
+	return cs.ConnStats()
+}
+
+// sampleConnPool 定期把连接池状态交给指标采集，直到代理关闭
+func (p *Proxy) sampleConnPool() {
+	ticker := time.NewTicker(p.config.ConnPoolInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.config.Metrics.ObserveConnPool(p.connPoolStats())
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package color
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// contentTypeColor 根据请求的 Content-Type 查找映射的 color
+// 先匹配完整 media type（如 multipart/form-data），再匹配主类型通配（如 image/*）；
+// 缺失或无法解析的 Content-Type 返回空字符串
+func (p *Proxy) contentTypeColor(req *http.Request) string {
+	if len(p.config.ContentTypeRoutes) == 0 {
+		return ""
+	}
+
+	raw := req.Header.Get("Content-Type")
+	if raw == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(raw)
+	if err != nil {
+		return ""
+	}
+
+	if color, ok := p.config.ContentTypeRoutes[mediaType]; ok {
+		return color
+	}
+	if i := strings.IndexByte(mediaType, '/'); i > 0 {
+		if color, ok := p.config.ContentTypeRoutes[mediaType[:i]+"/*"]; ok {
+			return color
+		}
+	}
+	return ""
+}
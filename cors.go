@@ -0,0 +1,126 @@
+package color
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig 代理本地应答跨域预检请求时使用的配置，见 WithCORS
+type CORSConfig struct {
+	// 允许的来源（如 "https://app.example.com"），"*" 表示任意来源
+	AllowedOrigins []string
+	// 允许的方法，为空时为 GET、HEAD、POST、PUT、PATCH、DELETE
+	AllowedMethods []string
+	// 允许的请求头，为空时回显预检请求的 Access-Control-Request-Headers
+	AllowedHeaders []string
+	// 允许浏览器读取的响应头
+	ExposedHeaders []string
+	// 是否允许携带 cookie 等凭据；开启时不返回 "*"，而是回显请求的 Origin
+	AllowCredentials bool
+	// 预检结果的缓存时间，0 表示不设置 Access-Control-Max-Age
+	MaxAge time.Duration
+}
+
+var defaultCORSMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// isPreflight 判断是否为跨域预检请求
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get("Origin") != "" && r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// localPreflight 是否由代理本地应答预检请求：配置了 CORS 且未开启透传
+func (p *Proxy) localPreflight(r *http.Request) bool {
+	return p.config.CORS != nil && !p.config.CORSPassthrough && isPreflight(r)
+}
+
+// allowOrigin 返回 Access-Control-Allow-Origin 的值，来源不被允许时返回空
+func (c *CORSConfig) allowOrigin(origin string) string {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			if c.AllowCredentials {
+				return origin
+			}
+			return "*"
+		}
+		if strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// servePreflight 本地应答预检请求：来源或方法不被允许时返回 403，否则返回 204 与允许的方法和请求头
+func (p *Proxy) servePreflight(w http.ResponseWriter, r *http.Request) {
+	c := p.config.CORS
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	origin := c.allowOrigin(r.Header.Get("Origin"))
+	if origin == "" {
+		p.writeError(w, http.StatusForbidden, "cors origin not allowed", "", jsonMap{"origin": r.Header.Get("Origin")})
+		return
+	}
+	methods := c.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	method := r.Header.Get("Access-Control-Request-Method")
+	if !containsFold(methods, method) {
+		p.writeError(w, http.StatusForbidden, "cors method not allowed", "", jsonMap{"method": method})
+		return
+	}
+
+	h.Set("Access-Control-Allow-Origin", origin)
+	h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(c.AllowedHeaders) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(c.AllowedHeaders, ", "))
+	} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		h.Set("Access-Control-Allow-Headers", requested)
+	}
+	if c.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if c.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// corsResponseModifier 为转发的响应补充 CORS 头；后端已设置 Access-Control-Allow-Origin 时不做处理
+func (c *CORSConfig) corsResponseModifier(res *http.Response) error {
+	if res.Request == nil || res.Header.Get("Access-Control-Allow-Origin") != "" {
+		return nil
+	}
+	origin := res.Request.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	res.Header.Add("Vary", "Origin")
+	allowed := c.allowOrigin(origin)
+	if allowed == "" {
+		return nil
+	}
+	res.Header.Set("Access-Control-Allow-Origin", allowed)
+	if c.AllowCredentials {
+		res.Header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(c.ExposedHeaders) > 0 {
+		res.Header.Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+	}
+	return nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
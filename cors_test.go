@@ -0,0 +1,102 @@
+package color
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// countingBackend 记录收到的请求数与最后一次请求的方法，GET 与 HEAD 都返回相同的 body
+func countingBackend(t *testing.T) (*httptest.Server, *atomic.Int32, *atomic.Value) {
+	t.Helper()
+	var hits atomic.Int32
+	var method atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		method.Store(r.Method)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", "7")
+		w.Write([]byte("payload"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits, &method
+}
+
+func preflightRequest(origin, method string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, "/api", nil)
+	req.Header.Set("color", "blue")
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	req.Header.Set("Access-Control-Request-Headers", "X-Trace")
+	return req
+}
+
+func TestCORSPreflightAnsweredLocally(t *testing.T) {
+	srv, hits, _ := countingBackend(t)
+	p := newTestProxy(t, WithCORS(CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		MaxAge:         10 * time.Minute,
+	}))
+	register(t, p, &backend.Route{Color: "blue", Address: srv.URL, Token: "t"})
+
+	rec := serve(p, preflightRequest("https://app.example.com", http.MethodPut))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", rec.Code)
+	}
+	for name, want := range map[string]string{
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "GET, HEAD, POST, PUT, PATCH, DELETE",
+		"Access-Control-Allow-Headers": "X-Trace",
+		"Access-Control-Max-Age":       "600",
+	} {
+		if got := rec.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	if rec := serve(p, preflightRequest("https://evil.example.com", http.MethodPut)); rec.Code != http.StatusForbidden {
+		t.Errorf("disallowed origin: status = %d, want 403", rec.Code)
+	}
+	if hits.Load() != 0 {
+		t.Errorf("backend received %d preflight requests, want none", hits.Load())
+	}
+}
+
+func TestCORSPassthroughForwardsPreflight(t *testing.T) {
+	srv, hits, method := countingBackend(t)
+	p := newTestProxy(t, WithCORS(CORSConfig{AllowedOrigins: []string{"*"}}), WithCORSPassthrough(true))
+	register(t, p, &backend.Route{Color: "blue", Address: srv.URL, Token: "t"})
+
+	serve(p, preflightRequest("https://app.example.com", http.MethodPut))
+	if hits.Load() != 1 || method.Load() != http.MethodOptions {
+		t.Errorf("backend hits = %d method = %v, want the preflight forwarded", hits.Load(), method.Load())
+	}
+}
+
+func TestHEADForwardedWithoutBody(t *testing.T) {
+	srv, hits, method := countingBackend(t)
+	p := newTestProxy(t, WithCORS(CORSConfig{AllowedOrigins: []string{"*"}}))
+	register(t, p, &backend.Route{Color: "blue", Address: srv.URL, Token: "t"})
+
+	req := httptest.NewRequest(http.MethodHead, "/api", nil)
+	req.Header.Set("color", "blue")
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := serve(p, req)
+
+	if hits.Load() != 1 || method.Load() != http.MethodHead {
+		t.Fatalf("backend hits = %d method = %v, want one HEAD", hits.Load(), method.Load())
+	}
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("status = %d body = %q, want 200 with no body", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Length"); got != "7" {
+		t.Errorf("Content-Length = %q, want the backend's 7", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+}
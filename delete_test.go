@@ -0,0 +1,109 @@
+package color
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+func deleteRequest(color, token string) *http.Request {
+	req := httptest.NewRequest(http.MethodDelete, "/colorproxy/routes/"+color, nil)
+	if token != "" {
+		req.Header.Set("X-Route-Token", token)
+	}
+	return req
+}
+
+func routeExists(p *Proxy, color string) bool {
+	route, _ := p.backend.Get(context.Background(), color)
+	return route != nil
+}
+
+func TestDeleteRouteTokenMismatch(t *testing.T) {
+	p := newTestProxy(t)
+	register(t, p, &backend.Route{Color: "blue", Address: "http://10.0.0.1", Token: "owner"})
+
+	if rec := serve(p, deleteRequest("blue", "intruder")); rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", rec.Code)
+	}
+	if rec := serve(p, deleteRequest("blue", "")); rec.Code != http.StatusForbidden {
+		t.Errorf("status without token = %d, want 403", rec.Code)
+	}
+	if !routeExists(p, "blue") {
+		t.Error("route deleted with a wrong token")
+	}
+}
+
+func TestDeleteRouteTokenMatch(t *testing.T) {
+	p := newTestProxy(t)
+	register(t, p, &backend.Route{Color: "blue", Address: "http://10.0.0.1", Token: "owner"})
+
+	if rec := serve(p, deleteRequest("blue", "owner")); rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+	if routeExists(p, "blue") {
+		t.Error("route still registered")
+	}
+}
+
+func TestDeleteRouteTokenInBody(t *testing.T) {
+	p := newTestProxy(t)
+	register(t, p, &backend.Route{Color: "blue", Address: "http://10.0.0.1", Token: "owner"})
+
+	req := httptest.NewRequest(http.MethodDelete, "/colorproxy/routes/blue", strings.NewReader(`{"token":"owner"}`))
+	if rec := serve(p, req); rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
+
+func TestDeleteRouteMissing(t *testing.T) {
+	p := newTestProxy(t)
+	if rec := serve(p, deleteRequest("ghost", "any")); rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
+
+func TestDeleteRouteAdminForce(t *testing.T) {
+	p := newTestProxy(t, WithAdminToken("secret"))
+	register(t, p, &backend.Route{Color: "blue", Address: "http://10.0.0.1", Token: "owner"})
+
+	req := httptest.NewRequest(http.MethodDelete, "/colorproxy/routes/blue?force=true", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	if rec := serve(p, req); rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 for an admin force-delete", rec.Code)
+	}
+	if routeExists(p, "blue") {
+		t.Error("route still registered after admin delete")
+	}
+}
+
+func TestDeleteRouteAdminWithoutForceChecksToken(t *testing.T) {
+	p := newTestProxy(t, WithAdminToken("secret"))
+	register(t, p, &backend.Route{Color: "blue", Address: "http://10.0.0.1", Token: "owner"})
+
+	// 管理端点都要求 admin 认证，不带 ?force=true 时仍校验路由 token
+	req := deleteRequest("blue", "intruder")
+	req.Header.Set("Authorization", "Bearer secret")
+	if rec := serve(p, req); rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403 for an admin request with a wrong route token", rec.Code)
+	}
+
+	// 未通过 admin 认证时 ?force=true 无效
+	req = httptest.NewRequest(http.MethodDelete, "/colorproxy/routes/blue?force=true", nil)
+	if rec := serve(p, req); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status without admin token = %d, want 401", rec.Code)
+	}
+	if !routeExists(p, "blue") {
+		t.Error("route deleted without force or a matching token")
+	}
+
+	req = deleteRequest("blue", "owner")
+	req.Header.Set("Authorization", "Bearer secret")
+	if rec := serve(p, req); rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 for an admin request with the route token", rec.Code)
+	}
+}
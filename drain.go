@@ -0,0 +1,58 @@
+package color
+
+import (
+	"context"
+	"sync"
+)
+
+// drainState 跟踪进行中的转发请求，Shutdown 时拒绝新的转发并等待已有转发完成
+type drainState struct {
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// enter 登记一次转发，已开始关闭时返回 false
+func (d *drainState) enter() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	d.wg.Add(1)
+	return true
+}
+
+func (d *drainState) leave() {
+	d.wg.Done()
+}
+
+// close 停止接收新的转发；加锁保证 close 之后不会再有 wg.Add，wait 不会与 Add 竞争
+func (d *drainState) close() {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+}
+
+// wait 等待进行中的转发完成，ctx 结束时返回 ctx.Err()
+func (d *drainState) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return nil
+	}
+}
+
+// closing 是否已开始关闭
+func (d *drainState) closing() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.closed
+}
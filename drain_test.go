@@ -0,0 +1,87 @@
+package color
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// slowBackend 收到请求后通知 started，等 release 关闭后才返回响应
+func slowBackend(t *testing.T) (*httptest.Server, chan struct{}, chan struct{}) {
+	t.Helper()
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.Write([]byte("done"))
+	}))
+	t.Cleanup(up.Close)
+	return up, started, release
+}
+
+// startSlowRequest 发起一次经代理的慢请求，等后端收到后返回结果通道
+func startSlowRequest(t *testing.T, p *Proxy, started chan struct{}) chan *httptest.ResponseRecorder {
+	t.Helper()
+	result := make(chan *httptest.ResponseRecorder, 1)
+	go func() { result <- serve(p, colorRequest(http.MethodGet, "blue")) }()
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("slow request never reached the backend")
+	}
+	return result
+}
+
+func TestShutdownWaitsForInFlightRequest(t *testing.T) {
+	up, started, release := slowBackend(t)
+	p := newTestProxy(t)
+	register(t, p, &backend.Route{Color: "blue", Address: up.URL, Token: "t"})
+
+	result := startSlowRequest(t, p, started)
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- p.Shutdown(context.Background()) }()
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned %v before the in-flight request finished", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// 关闭期间的新请求直接拒绝
+	if rec := serve(p, colorRequest(http.MethodGet, "blue")); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status during shutdown = %d, want 503", rec.Code)
+	}
+
+	close(release)
+	if rec := <-result; rec.Code != http.StatusOK || rec.Body.String() != "done" {
+		t.Errorf("in-flight request = %d %q, want 200 done", rec.Code, rec.Body.String())
+	}
+	select {
+	case err := <-shutdown:
+		if err != nil {
+			t.Errorf("Shutdown = %v, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown did not return after the request finished")
+	}
+}
+
+func TestShutdownDeadlineWithInFlightRequest(t *testing.T) {
+	up, started, release := slowBackend(t)
+	defer close(release)
+	p := newTestProxy(t)
+	register(t, p, &backend.Route{Color: "blue", Address: up.URL, Token: "t"})
+
+	startSlowRequest(t, p, started)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want context.DeadlineExceeded", err)
+	}
+}
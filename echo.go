@@ -0,0 +1,56 @@
+package color
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AttachEcho 集成到 Echo
+func (p *Proxy) AttachEcho(e *echo.Echo) {
+	// 注册管理端点
+	api := e.Group(p.config.AdminPrefix)
+	for _, r := range p.managementRoutes() {
+		api.Add(r.method, colonPath(r.path), echoHandler(r.handler))
+	}
+
+	// 全局代理中间件
+	e.Use(p.echoProxyMiddleware())
+
+	p.config.Logger.Info("attached to Echo")
+}
+
+// echoHandler 把 net/http handler 适配为 Echo handler，并透传路径参数（r.PathValue）
+func echoHandler(h http.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		values := c.ParamValues()
+		for i, name := range c.ParamNames() {
+			if i < len(values) {
+				c.Request().SetPathValue(name, values[i])
+			}
+		}
+		h(c.Response(), c.Request())
+		return nil
+	}
+}
+
+func (p *Proxy) echoProxyMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Echo 的 Use 中间件同样作用于管理端点，这里跳过，与 Gin 集成保持一致
+			if p.isAdminPath(c.Request().URL.Path) {
+				return next(c)
+			}
+
+			// 响应已经写出，说明请求已被处理
+			if c.Response().Committed {
+				return next(c)
+			}
+
+			// 已由代理处理时不再调用 next，相当于 Gin 的 c.Abort()
+			var err error
+			p.serveProxy(c.Response(), c.Request(), func() { err = next(c) })
+			return err
+		}
+	}
+}
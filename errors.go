@@ -0,0 +1,45 @@
+package color
+
+import (
+	"errors"
+	"net/http"
+)
+
+// writeError 输出代理层错误响应（唯一的错误格式化入口）
+// 默认格式：{"error": title, "detail": detail, ...ext}
+// 启用 WithProblemJSON 时输出 RFC 7807 application/problem+json：
+// {"type": "about:blank", "title": title, "status": status, "detail": detail, ...ext}
+func (p *Proxy) writeError(w http.ResponseWriter, status int, title, detail string, ext jsonMap) {
+	body := jsonMap{}
+	for k, v := range ext {
+		body[k] = v
+	}
+
+	if p.config.ProblemJSON {
+		body["type"] = "about:blank"
+		body["title"] = title
+		body["status"] = status
+		if detail != "" {
+			body["detail"] = detail
+		}
+		w.Header().Set("Content-Type", "application/problem+json")
+		writeJSON(w, status, body)
+		return
+	}
+
+	body["error"] = title
+	if detail != "" {
+		body["detail"] = detail
+	}
+	writeJSON(w, status, body)
+}
+
+// writeAuthorizerError 输出授权钩子的拒绝响应
+func (p *Proxy) writeAuthorizerError(w http.ResponseWriter, err error) {
+	status := p.config.AuthorizerDenyStatus
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.Status != 0 {
+		status = httpErr.Status
+	}
+	p.writeError(w, status, "request not authorized", err.Error(), nil)
+}
@@ -0,0 +1,197 @@
+package color
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// eventBufferSize 事件缓冲区大小，超出后丢弃新事件
+const eventBufferSize = 256
+
+// RouteEventType 路由事件类型
+type RouteEventType int
+
+const (
+	RouteRegistered RouteEventType = iota
+	RouteRenewed
+	RouteDeleted
+	RouteExpired
+	// CanaryRolledBack canary 错误率超过阈值，流量比例已降为 0（见 WithAutoRollback），Address 为触发的地址
+	CanaryRolledBack
+	// BreakerStateChanged 后端地址的熔断器状态变化（见 WithCircuitBreaker），Address 为 target，Color 为触发变化的请求的 color
+	BreakerStateChanged
+)
+
+func (t RouteEventType) String() string {
+	switch t {
+	case RouteRegistered:
+		return "registered"
+	case RouteRenewed:
+		return "renewed"
+	case RouteDeleted:
+		return "deleted"
+	case RouteExpired:
+		return "expired"
+	case CanaryRolledBack:
+		return "canary_rolled_back"
+	case BreakerStateChanged:
+		return "breaker_state_changed"
+	default:
+		return "unknown"
+	}
+}
+
+// RouteEvent 路由变化事件
+type RouteEvent struct {
+	Type    RouteEventType
+	Color   string // 路由 key，带版本的路由为 "<color>:<version>"
+	Address string
+	Time    time.Time
+
+	// CanaryRolledBack：触发时窗口内的错误率与请求数
+	ErrorRate float64
+	Requests  uint64
+
+	// BreakerStateChanged：变化前后的状态（closed / open / half-open）与变化时的连续失败次数
+	BreakerFrom string
+	BreakerTo   string
+	Failures    int
+}
+
+// RouteListener 路由事件回调，在独立的 goroutine 中按顺序调用
+type RouteListener func(ev RouteEvent)
+
+// eventBus 路由事件分发：事件进入有界缓冲区，由单独的 goroutine 依次投递给所有监听者
+// 监听者处理过慢导致缓冲区满时丢弃新事件并记录日志，不阻塞请求处理
+type eventBus struct {
+	ch chan RouteEvent
+
+	// 上一轮清理时看到的路由（color -> 地址），用于发现过期的路由
+	// 同时作为 Stats 读取的路由表快照，updated 为最近一次加载的时间
+	mu      sync.Mutex
+	known   map[string][]string
+	ready   bool
+	updated time.Time
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		ch:    make(chan RouteEvent, eventBufferSize),
+		known: make(map[string][]string),
+	}
+}
+
+// emit 投递路由事件，未配置监听者时直接返回
+func (p *Proxy) emit(typ RouteEventType, color, address string) {
+	p.emitEvent(RouteEvent{Type: typ, Color: color, Address: address})
+}
+
+// emitEvent 投递携带附加信息的事件，Time 为空时使用当前时间
+func (p *Proxy) emitEvent(ev RouteEvent) {
+	if len(p.config.RouteListeners) == 0 {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	select {
+	case p.events.ch <- ev:
+	default:
+		p.config.Logger.Error("route event buffer full, event dropped: type=%s, color=%s", ev.Type, ev.Color)
+	}
+}
+
+// dispatchEvents 依次把事件交给所有监听者，直到代理关闭
+func (p *Proxy) dispatchEvents() {
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case ev := <-p.events.ch:
+			for _, listener := range p.config.RouteListeners {
+				listener(ev)
+			}
+		}
+	}
+}
+
+// forgetRoute 路由已由本实例删除，避免下一轮清理再报告为过期；返回剩余路由的快照
+func (p *Proxy) forgetRoute(color string) map[string][]string {
+	p.events.mu.Lock()
+	defer p.events.mu.Unlock()
+	delete(p.events.known, color)
+	return maps.Clone(p.events.known)
+}
+
+// refreshRoutes 以后台任务加载的路由表更新路由快照（Stats、过期检测、指标标签与自动回滚的 canary 地址）
+func (p *Proxy) refreshRoutes(routes []*backend.Route) {
+	p.detectExpired(routes)
+	if p.labels != nil {
+		p.labels.replace(routes)
+	}
+	if p.rollback != nil {
+		p.rollback.updateTargets(routes)
+	}
+}
+
+// detectExpired 以本轮清理得到的路由表对比上一轮，找出消失的路由（TTL 到期或被其他实例删除）：
+// 发出 RouteExpired 事件并释放到这些地址的连接
+func (p *Proxy) detectExpired(routes []*backend.Route) {
+	now := time.Now()
+	current := make(map[string][]string, len(routes))
+	for _, route := range routes {
+		if !route.ExpiresAt.IsZero() && now.After(route.ExpiresAt) {
+			continue
+		}
+		current[route.Key()] = endpointAddresses(route)
+	}
+
+	p.events.mu.Lock()
+	previous, ready := p.events.known, p.events.ready
+	p.events.known, p.events.ready, p.events.updated = current, true, now
+	p.events.mu.Unlock()
+
+	// 第一轮只记录基线
+	if !ready {
+		return
+	}
+	for color, addresses := range previous {
+		if _, ok := current[color]; ok {
+			continue
+		}
+		address := ""
+		if len(addresses) > 0 {
+			address = addresses[0]
+		}
+		p.emit(RouteExpired, color, address)
+		p.releaseTargets(addresses, current)
+	}
+}
+
+// releaseTargets 释放 addresses 中不再被 routes 里任何路由引用的地址，
+// 同一地址可能由多个路由共享（如同一实例上的 blue:v1 与 blue:v2），仍在使用时保留其连接与熔断状态
+func (p *Proxy) releaseTargets(addresses []string, routes map[string][]string) {
+	inUse := make(map[string]bool)
+	for _, addrs := range routes {
+		for _, addr := range addrs {
+			inUse[addr] = true
+		}
+	}
+	for _, addr := range addresses {
+		if !inUse[addr] {
+			p.removeTarget(addr)
+		}
+	}
+}
+
+func endpointAddresses(route *backend.Route) []string {
+	endpoints := route.EndpointList()
+	addresses := make([]string, 0, len(endpoints))
+	for _, ep := range endpoints {
+		addresses = append(addresses, ep.Address)
+	}
+	return addresses
+}
@@ -0,0 +1,104 @@
+package color
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+func TestDetectExpiredReportsVanishedRoutes(t *testing.T) {
+	events := make(chan RouteEvent, 8)
+	p := newTestProxy(t, WithRouteListener(func(ev RouteEvent) { events <- ev }))
+	waitRoutesLoaded(t, p)
+
+	blue := &backend.Route{Color: "blue", Address: "http://10.0.0.1:8080"}
+	green := &backend.Route{Color: "green", Address: "http://10.0.0.2:8080"}
+	p.detectExpired([]*backend.Route{blue, green})
+	p.detectExpired([]*backend.Route{green})
+
+	select {
+	case ev := <-events:
+		if ev.Type != RouteExpired || ev.Color != "blue" || ev.Address != "http://10.0.0.1:8080" {
+			t.Errorf("event = %+v, want blue expired", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no expired event")
+	}
+	if stats := p.Stats(); stats.Routes != 1 || stats.RoutesByColor["green"] != 1 {
+		t.Errorf("stats routes = %d %v, want only green", stats.Routes, stats.RoutesByColor)
+	}
+}
+
+// removalRecorder 记录 RemoveTarget 调用的传输层
+type removalRecorder struct {
+	mu      sync.Mutex
+	removed []string
+}
+
+func (r *removalRecorder) Proxy(ctx context.Context, target string, req *http.Request, w http.ResponseWriter) error {
+	return nil
+}
+
+func (r *removalRecorder) Close() error { return nil }
+
+func (r *removalRecorder) RemoveTarget(target string) {
+	r.mu.Lock()
+	r.removed = append(r.removed, target)
+	r.mu.Unlock()
+}
+
+func (r *removalRecorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.removed)
+}
+
+func TestExpiredRouteKeepsSharedAddress(t *testing.T) {
+	rec := &removalRecorder{}
+	p := newTestProxy(t, WithTransport(rec))
+	waitRoutesLoaded(t, p)
+
+	blue := &backend.Route{Color: "blue", Address: "http://10.0.0.1:8080"}
+	green := &backend.Route{Color: "green", Address: "http://10.0.0.1:8080"}
+	p.detectExpired([]*backend.Route{blue, green})
+	p.detectExpired([]*backend.Route{green})
+	if got := rec.list(); len(got) != 0 {
+		t.Fatalf("removed %v while green still uses the address", got)
+	}
+
+	p.detectExpired(nil)
+	if got := rec.list(); !slices.Equal(got, []string{"http://10.0.0.1:8080"}) {
+		t.Errorf("removed %v, want the address once no route uses it", got)
+	}
+}
+
+func TestDeletedRouteKeepsSharedAddress(t *testing.T) {
+	rec := &removalRecorder{}
+	p := newTestProxy(t, WithTransport(rec))
+	waitRoutesLoaded(t, p)
+
+	blue := &backend.Route{Color: "blue", Address: "http://10.0.0.1:8080", Token: "t1"}
+	green := &backend.Route{Color: "green", Address: "http://10.0.0.1:8080", Token: "t2"}
+	register(t, p, blue)
+	register(t, p, green)
+	p.detectExpired([]*backend.Route{blue, green})
+
+	if err := p.deleteRoute(context.Background(), "blue", "t1", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.list(); len(got) != 0 {
+		t.Fatalf("removed %v while green still uses the address", got)
+	}
+
+	if err := p.deleteRoute(context.Background(), "green", "t2", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.list(); !slices.Equal(got, []string{"http://10.0.0.1:8080"}) {
+		t.Errorf("removed %v, want the address once no route uses it", got)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/asam264/color"
+	"github.com/labstack/echo/v4"
+)
+
+func main() {
+	// 创建代理实例
+	proxy, err := color.New(
+		// 内存后端，无需外部依赖
+		color.WithMemoryBackend(),
+
+		color.WithHTTPTransport(30*time.Second),
+		color.WithTTL(2*time.Minute),
+
+		// 自动注册本服务
+		color.WithAutoRegister(
+			"blue",                  // color
+			"http://localhost:8080", // address
+			"my-secret-token",       // token
+			"service-blue",          // owner
+		),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer proxy.Close()
+
+	e := echo.New()
+
+	// 挂载 /colorproxy 管理端点与代理中间件
+	proxy.AttachEcho(e)
+
+	// 业务路由
+	e.GET("/ping", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": "pong",
+			"service": "blue",
+		})
+	})
+
+	log.Println("Server started at :8080")
+	log.Fatal(e.Start(":8080"))
+}
@@ -11,8 +11,9 @@ import (
 func main() {
 	// 创建代理实例 - 使用 Option 模式配置
 	proxy, err := color.New(
-		// 选择存储后端：Redis
-		color.WithRedis("localhost:6379", "", 0),
+		// 选择存储后端：内存（无需外部依赖，重启后路由丢失）
+		// 多实例部署请改用 Redis：color.WithRedis("localhost:6379", "", 0)
+		color.WithMemoryBackend(),
 
 		// 选择传输层：HTTP
 		color.WithHTTPTransport(30*time.Second),
@@ -1,14 +1,13 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"time"
 
 	"github.com/asam264/color"
+	"github.com/asam264/color/client"
 	"github.com/gin-gonic/gin"
 )
 
@@ -63,33 +62,14 @@ func startMockBackend(port int, colorName string) {
 
 // 注册服务到 Redis
 func registerService(proxyAddr, color, targetAddr, token string) error {
-	url := fmt.Sprintf("http://localhost%s/colorproxy/register", proxyAddr)
-
-	payload := map[string]string{
-		"color":   color,
-		"address": targetAddr,
-		"token":   token,
-		"owner":   "test",
-	}
-
-	jsonData, _ := json.Marshal(payload)
-
-	client := &http.Client{Timeout: 5 * time.Second}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("registration failed with status %d", resp.StatusCode)
-	}
-	return nil
+	c := client.New(fmt.Sprintf("http://localhost%s", proxyAddr))
+	_, err := c.Register(context.Background(), &client.RegisterRequest{
+		Color:   color,
+		Address: targetAddr,
+		Token:   token,
+		Owner:   "test",
+	})
+	return err
 }
 
 func main() {
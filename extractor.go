@@ -0,0 +1,33 @@
+package color
+
+import (
+	"net/http"
+)
+
+// ColorExtractor 从请求中提取路由 color，返回空字符串表示请求未指定 color
+type ColorExtractor func(req *http.Request) string
+
+// FromHeader 从指定 header 提取 color（header 名不区分大小写）
+func FromHeader(name string) ColorExtractor {
+	return func(req *http.Request) string {
+		return req.Header.Get(name)
+	}
+}
+
+// FromQuery 从指定 query 参数提取 color
+func FromQuery(name string) ColorExtractor {
+	return func(req *http.Request) string {
+		return req.URL.Query().Get(name)
+	}
+}
+
+// FromCookie 从指定 cookie 提取 color
+func FromCookie(name string) ColorExtractor {
+	return func(req *http.Request) string {
+		cookie, err := req.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}
+}
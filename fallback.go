@@ -0,0 +1,97 @@
+package color
+
+import (
+	"context"
+	"errors"
+
+	"github.com/asam264/color/internal/backend"
+	"github.com/asam264/color/internal/strategy"
+)
+
+// WithFallback 配置 color 的回退链：color 没有可用路由时按顺序尝试 fallbacks[color] 中的 color，
+// 回退 color 自身也可以配置回退（如 canary -> beta -> stable）；整条链都没有路由时才交给本地处理。
+// 回退到本地 color 时直接本地处理；存在环时每个 color 最多尝试一次。
+func WithFallback(fallbacks map[string][]string) Option {
+	return func(c *Config) {
+		c.Fallbacks = fallbacks
+	}
+}
+
+// errFallbackLocal 回退链到达本地 color，应交给本地处理
+var errFallbackLocal = errors.New("fallback reached local color")
+
+// selectWithFallback 选择目标，返回目标与实际命中的 color（命中版本路由时为 "<color>:<version>"）
+// 请求带版本时先尝试该版本的路由；color 没有可用路由时沿回退链查找，查询超时等其他错误直接返回，不会触发回退
+func (p *Proxy) selectWithFallback(ctx context.Context, req strategy.RoutingRequest) (string, string, error) {
+	if req.Version != "" {
+		key := backend.RouteKey(req.Color, req.Version)
+		target, err := p.selectTarget(ctx, req.WithColor(key))
+		if err == nil || !isNoRoute(err) {
+			return target, key, err
+		}
+	}
+
+	target, err := p.selectTarget(ctx, req)
+	if err == nil || len(p.config.Fallbacks) == 0 || !isNoRoute(err) {
+		return target, req.Color, err
+	}
+
+	visited := map[string]bool{req.Color: true}
+	target, used, ferr := p.selectFallback(ctx, req, req.Color, visited)
+	if ferr != nil {
+		if errors.Is(ferr, errFallbackLocal) {
+			return "", used, ferr
+		}
+		// 整条链都没有路由：返回原 color 的错误
+		return "", req.Color, err
+	}
+	p.config.Logger.Info("route fallback: color=%s -> %s, target=%s", req.Color, used, target)
+	return target, used, nil
+}
+
+// resolveRoute 按转发时的流程解析请求的目标，供管理端点的 resolve 使用，不转发
+// 返回目标与实际命中的路由；color 或回退链到达本地 color 时 local 为 true，目标为空
+func (p *Proxy) resolveRoute(ctx context.Context, req strategy.RoutingRequest) (target, route string, local bool, err error) {
+	if p.config.LocalColor != "" && req.Color == p.config.LocalColor {
+		return "", req.Color, true, nil
+	}
+	target, route, err = p.selectWithFallback(ctx, req)
+	if errors.Is(err, errFallbackLocal) {
+		return "", route, true, nil
+	}
+	return target, route, false, err
+}
+
+// selectFallback 深度优先尝试 color 的回退链
+func (p *Proxy) selectFallback(ctx context.Context, req strategy.RoutingRequest, color string, visited map[string]bool) (string, string, error) {
+	lastErr := error(backend.ErrRouteNotFound)
+	for _, fb := range p.config.Fallbacks[color] {
+		if visited[fb] {
+			continue
+		}
+		visited[fb] = true
+
+		if p.config.LocalColor != "" && fb == p.config.LocalColor {
+			return "", fb, errFallbackLocal
+		}
+
+		target, err := p.selectTarget(ctx, req.WithColor(fb))
+		if err == nil {
+			return target, fb, nil
+		}
+		if !isNoRoute(err) {
+			return "", fb, err
+		}
+
+		if target, used, err := p.selectFallback(ctx, req, fb, visited); err == nil || !isNoRoute(err) {
+			return target, used, err
+		}
+		lastErr = err
+	}
+	return "", color, lastErr
+}
+
+// isNoRoute 路由不存在或没有可用地址
+func isNoRoute(err error) bool {
+	return errors.Is(err, backend.ErrRouteNotFound) || errors.Is(err, strategy.ErrNoEndpoint)
+}
@@ -0,0 +1,52 @@
+package color
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttachGin 集成到 Gin 引擎
+func (p *Proxy) AttachGin(engine *gin.Engine) {
+	// 注册管理端点
+	api := engine.Group(p.config.AdminPrefix)
+	for _, r := range p.managementRoutes() {
+		api.Handle(r.method, colonPath(r.path), ginHandler(r.handler))
+	}
+
+	// 全局代理中间件
+	engine.Use(p.ginProxyMiddleware())
+
+	p.config.Logger.Info("attached to Gin engine")
+}
+
+// ginHandler 把 net/http handler 适配为 Gin handler，并透传路径参数（r.PathValue）
+func ginHandler(h http.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, param := range c.Params {
+			c.Request.SetPathValue(param.Key, param.Value)
+		}
+		h(c.Writer, c.Request)
+	}
+}
+
+func (p *Proxy) ginProxyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 关键修复：检查请求是否已经被处理过（防止重复处理）
+		// 如果响应头已经写入，说明请求已经被处理，直接返回
+		if c.Writer.Written() {
+			return
+		}
+
+		// 管理端点前缀下的请求（如未匹配的方法走到 NoRoute）不转发
+		if p.isAdminPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		// 已由代理处理时终止后续 handler，与转发前调用 c.Abort() 等价
+		if p.serveProxy(c.Writer, c.Request, c.Next) {
+			c.Abort()
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package color
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/asam264/color/internal/backend"
+	"github.com/asam264/color/internal/strategy"
+	"github.com/asam264/color/internal/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServerOptions 返回 gRPC 代理所需的服务端选项
+// 未在本服务注册的方法会按 metadata 中的 color 选择目标并转发，
+// 已注册的服务不受影响（codec 对普通 protobuf 消息按标准方式编解码）：
+//
+//	s := grpc.NewServer(proxy.GRPCServerOptions()...)
+//	pb.RegisterMyServiceServer(s, impl) // 可选：本地服务
+//	s.Serve(lis)
+//
+// grpc.Server 创建后无法再挂载 UnknownServiceHandler，因此以 ServerOption 的形式提供。
+func (p *Proxy) GRPCServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ForceServerCodec(transport.RawCodec()),
+		grpc.UnknownServiceHandler(p.grpcProxyHandler),
+	}
+}
+
+// grpcProxyHandler 转发未知服务的调用（Unary 与流式）
+func (p *Proxy) grpcProxyHandler(srv interface{}, stream grpc.ServerStream) error {
+	method, ok := grpc.MethodFromServerStream(stream)
+	if !ok {
+		return status.Error(codes.Internal, "method not found in stream")
+	}
+
+	ctx := stream.Context()
+	md, _ := metadata.FromIncomingContext(ctx)
+	color := ""
+	if values := md.Get("color"); len(values) > 0 {
+		color = values[0]
+	}
+
+	// 没有 color 或 color 为本地：本地没有该方法，与 gRPC 默认行为一致
+	if color == "" || (p.config.LocalColor != "" && color == p.config.LocalColor) {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+
+	if !p.drain.enter() {
+		return status.Error(codes.Unavailable, "proxy is shutting down")
+	}
+	defer p.drain.leave()
+
+	if p.inMaintenance(color) {
+		return status.Errorf(codes.Unavailable, "color %s is under maintenance", color)
+	}
+
+	// metadata 的 key 为小写，转换为规范形式后可直接用 Header.Get 读取
+	req := strategy.RoutingRequest{Color: color, Header: make(http.Header, len(md))}
+	for k, v := range md {
+		req.Header[http.CanonicalHeaderKey(k)] = v
+	}
+	if pr, ok := peer.FromContext(ctx); ok && pr.Addr != nil {
+		req.RemoteAddr = pr.Addr.String()
+	}
+	if p.config.VersionHeader != "" {
+		req.Version = req.Header.Get(p.config.VersionHeader)
+	}
+	target, _, err := p.selectWithFallback(ctx, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errFallbackLocal):
+			return status.Errorf(codes.Unimplemented, "unknown method %s", method)
+		case errors.Is(err, ErrLookupTimeout):
+			return status.Errorf(codes.DeadlineExceeded, "route lookup timed out for color %s", color)
+		case errors.Is(err, backend.ErrRouteNotFound):
+			return status.Errorf(codes.Unavailable, "no route for color %s", color)
+		}
+		return status.Errorf(codes.Unavailable, "select target for color %s failed: %v", color, err)
+	}
+
+	outCtx := metadata.NewOutgoingContext(ctx, forwardMetadata(md))
+
+	// 优先按流转发：无法区分调用类型时按双向流处理，同样适用于 Unary
+	if st, ok := p.grpc.(transport.StreamGRPCTransporter); ok {
+		desc := &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}
+		if err := st.ProxyStream(outCtx, target, method, desc, stream); err != nil {
+			return grpcStatusError(err)
+		}
+		return nil
+	}
+
+	raw, ok := p.grpc.(transport.RawGRPCTransporter)
+	if !ok {
+		return status.Error(codes.Unimplemented, "grpc transport does not support raw proxying")
+	}
+
+	var in []byte
+	if err := stream.RecvMsg(&in); err != nil {
+		return err
+	}
+
+	out, err := raw.ProxyRaw(outCtx, target, method, in)
+	if err != nil {
+		return grpcStatusError(err)
+	}
+	return stream.SendMsg(&out)
+}
+
+// forwardMetadata 复制 incoming metadata，去掉伪头（如 :authority）
+func forwardMetadata(md metadata.MD) metadata.MD {
+	out := md.Copy()
+	for key := range out {
+		if strings.HasPrefix(key, ":") {
+			delete(out, key)
+		}
+	}
+	return out
+}
+
+// grpcStatusError 原样返回后端的 status（保留 code 与 message），非 status 错误视为连接失败返回 Unavailable
+func grpcStatusError(err error) error {
+	var se interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &se) {
+		return se.GRPCStatus().Err()
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
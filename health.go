@@ -0,0 +1,88 @@
+package color
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthState 主动健康检查结果：color -> 不健康的地址集合
+// 只记录不健康的地址，未探测或探测成功的地址都视为健康
+type healthState struct {
+	mu        sync.RWMutex
+	unhealthy map[string]map[string]bool
+
+	client *http.Client
+}
+
+func newHealthState(timeout time.Duration) *healthState {
+	return &healthState{
+		unhealthy: make(map[string]map[string]bool),
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Healthy 实现 strategy.HealthChecker
+func (h *healthState) Healthy(color, address string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return !h.unhealthy[color][address]
+}
+
+// runHealthChecks 探测所有路由的全部地址，并用本轮结果整体替换健康状态
+// 已下线的 color/地址不会残留在状态中
+func (p *Proxy) runHealthChecks(ctx context.Context) {
+	routes, err := p.backend.List(ctx)
+	if err != nil {
+		p.config.Logger.Error("health check list routes failed: %v", err)
+		return
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		unhealthy = make(map[string]map[string]bool)
+	)
+	for _, route := range routes {
+		for _, ep := range route.EndpointList() {
+			wg.Add(1)
+			go func(color, address string) {
+				defer wg.Done()
+				if p.probe(ctx, address) {
+					return
+				}
+				mu.Lock()
+				if unhealthy[color] == nil {
+					unhealthy[color] = make(map[string]bool)
+				}
+				unhealthy[color][address] = true
+				mu.Unlock()
+			}(route.Key(), ep.Address)
+		}
+	}
+	wg.Wait()
+
+	p.health.mu.Lock()
+	p.health.unhealthy = unhealthy
+	p.health.mu.Unlock()
+}
+
+// probe 请求 address + HealthCheckPath，2xx/3xx 视为健康；非 HTTP 地址不探测
+func (p *Proxy) probe(ctx context.Context, address string) bool {
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		return true
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(address, "/")+p.config.HealthCheckPath, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := p.health.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < 400
+}
@@ -0,0 +1,100 @@
+package color
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+func heartbeat(p *Proxy, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/colorproxy/heartbeat", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return serve(p, req)
+}
+
+func TestHeartbeatReregistersMissingRoute(t *testing.T) {
+	p := newTestProxy(t, WithHeartbeatReregister(true))
+
+	rec := heartbeat(p, `{"color":"blue","address":"http://10.0.0.1:8080","token":"t1"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	var resp struct{ Message string }
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Message != "route re-registered" {
+		t.Errorf("message = %q, want route re-registered", resp.Message)
+	}
+
+	routes, _ := p.backend.List(context.Background())
+	if len(routes) != 1 || routes[0].Token != "t1" {
+		t.Fatalf("routes = %+v, want the re-registered route", routes)
+	}
+}
+
+func TestHeartbeatMissWithoutReregister(t *testing.T) {
+	p := newTestProxy(t)
+	if rec := heartbeat(p, `{"color":"blue","address":"http://10.0.0.1:8080","token":"t1"}`); rec.Code == http.StatusOK {
+		t.Fatalf("status = 200 for a missing route without re-registration")
+	}
+}
+
+func TestHeartbeatReregisterKeepsOtherOwner(t *testing.T) {
+	p := newTestProxy(t, WithHeartbeatReregister(true))
+	if err := p.backend.Register(context.Background(), &backend.Route{Color: "blue", Address: "http://10.0.0.2:8080", Token: "other"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := heartbeat(p, `{"color":"blue","address":"http://10.0.0.1:8080","token":"t1"}`)
+	if rec.Code == http.StatusOK {
+		t.Fatalf("heartbeat with a foreign token succeeded: %s", rec.Body)
+	}
+	routes, _ := p.backend.List(context.Background())
+	if len(routes) != 1 || routes[0].Token != "other" {
+		t.Fatalf("routes = %+v, want the other owner's route untouched", routes)
+	}
+}
+
+func TestHeartbeatReregisterRestoresRegistration(t *testing.T) {
+	p := newTestProxy(t, WithHeartbeatReregister(true))
+
+	rec := heartbeat(p, `{"color":"blue","address":"http://10.0.0.1:8080","token":"t1","owner":"team-a",
+		"labels":{"region":"eu"},"ttl_seconds":90,"endpoints":[{"address":"http://10.0.0.2:8080/","weight":3}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	routes, _ := p.backend.List(context.Background())
+	if len(routes) != 1 {
+		t.Fatalf("routes = %+v, want the re-registered route", routes)
+	}
+	got := routes[0]
+	if got.Owner != "team-a" || got.Labels["region"] != "eu" || got.TTL != 90*time.Second {
+		t.Errorf("route = %+v, want owner, labels and TTL from the heartbeat", got)
+	}
+	if len(got.Endpoints) != 1 || got.Endpoints[0].Address != "http://10.0.0.2:8080" || got.Endpoints[0].Weight != 3 {
+		t.Errorf("endpoints = %+v, want the normalized endpoint from the heartbeat", got.Endpoints)
+	}
+}
+
+func TestHeartbeatRejectsMalformedAddress(t *testing.T) {
+	p := newTestProxy(t, WithHeartbeatReregister(true))
+
+	for _, body := range []string{
+		`{"color":"blue","address":"not an address","token":"t1"}`,
+		`{"color":"blue","address":"http://10.0.0.1:8080","token":"t1","endpoints":[{"address":"bad","weight":1}]}`,
+		`{"color":"blue","address":"http://10.0.0.1:8080","token":"t1","ttl_seconds":-1}`,
+	} {
+		if rec := heartbeat(p, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("heartbeat %s: status = %d, want 400", body, rec.Code)
+		}
+	}
+	if routes, _ := p.backend.List(context.Background()); len(routes) != 0 {
+		t.Fatalf("routes = %+v, want nothing registered from a malformed heartbeat", routes)
+	}
+}
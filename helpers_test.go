@@ -0,0 +1,61 @@
+package color
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// newTestProxy 创建使用内存后端的代理，测试结束时关闭
+func newTestProxy(t *testing.T, opts ...Option) *Proxy {
+	t.Helper()
+	p, err := New(append([]Option{WithMemoryBackend(), WithLogger(nopLogger{})}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { p.Shutdown(context.Background()) })
+	return p
+}
+
+// register 向代理的后端注册路由
+func register(t *testing.T, p *Proxy, route *backend.Route) {
+	t.Helper()
+	if err := p.backend.Register(context.Background(), route, time.Minute); err != nil {
+		t.Fatalf("register %s: %v", route.Key(), err)
+	}
+}
+
+// serve 通过 Handler 处理请求，返回响应记录
+func serve(p *Proxy, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+// waitRoutesLoaded 等待后台任务启动时的首次路由表加载完成，
+// 之后直接调用 refreshRoutes / detectExpired 不会与其并发覆盖路由快照
+func waitRoutesLoaded(t *testing.T, p *Proxy) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		p.events.mu.Lock()
+		ready := p.events.ready
+		p.events.mu.Unlock()
+		if ready {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("initial route load did not complete")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
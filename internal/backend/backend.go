@@ -2,38 +2,188 @@ package backend
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"time"
 )
 
+// ErrRouteNotFound 路由不存在（后端可达，但没有该 color 的记录）
+var ErrRouteNotFound = errors.New("route not found")
+
+// ErrTokenMismatch token 与已注册路由不一致
+var ErrTokenMismatch = errors.New("token mismatch")
+
+// ErrRouteConflict color 已被其他 token 注册
+var ErrRouteConflict = errors.New("route already registered by another token")
+
+// ExclusiveRegisterer 可选接口：仅当路由不存在（或已过期）或 token 与已注册路由一致时才注册，
+// 判断与写入为原子操作；已被其他 token 占用时返回 ErrRouteConflict
+type ExclusiveRegisterer interface {
+	RegisterExclusive(ctx context.Context, route *Route, ttl time.Duration) error
+}
+
+// TokenDeleter 可选接口：校验 token 与已注册路由一致后再删除，校验与删除为原子操作
+// 路由不存在返回 ErrRouteNotFound，token 不一致返回 ErrTokenMismatch
+type TokenDeleter interface {
+	DeleteWithToken(ctx context.Context, color, token string) error
+}
+
+// FilterByOwner 保留 owner 的路由（原地过滤）
+func FilterByOwner(routes []*Route, owner string) []*Route {
+	out := routes[:0]
+	for _, route := range routes {
+		if route.Owner == owner {
+			out = append(out, route)
+		}
+	}
+	return out
+}
+
+// ErrWatchUnsupported 后端不支持监听路由变化
+var ErrWatchUnsupported = errors.New("backend does not support watch")
+
+// RouteEventType 路由变化类型
+type RouteEventType int
+
+const (
+	RouteEventPut    RouteEventType = iota // 注册、覆盖或续期
+	RouteEventDelete                       // 删除或过期
+)
+
+// RouteEvent 后端推送的路由变化
+type RouteEvent struct {
+	Type  RouteEventType
+	Key   string // 路由 key（见 RouteKey）
+	Route *Route // RouteEventPut 时为最新的路由，RouteEventDelete 时为 nil
+}
+
+// watchBufferSize Watch 返回的 channel 的缓冲大小
+const watchBufferSize = 256
+
+// Watcher 可选接口：监听路由变化，供调用方维护本地路由缓存
+// 返回的 channel 在 ctx 结束或监听中断时关闭；中断期间的变化可能丢失，
+// 调用方应在 channel 关闭后重新 List 并重新 Watch
+type Watcher interface {
+	Watch(ctx context.Context) (<-chan RouteEvent, error)
+}
+
 // Route 路由信息
+// 一个 color 可以对应多个带权重的 Endpoint；只有一个地址时可以只设置 Address（向后兼容）。
+// Address 始终为主地址（第一个 Endpoint 的地址），用于心跳校验和只支持单地址的策略。
 type Route struct {
 	Color     string
 	Address   string
+	Endpoints []Endpoint
 	Owner     string
 	Token     string
 	ExpiresAt time.Time
+
+	// TTL 路由自身的过期时间，0 表示使用注册时传入的默认 TTL；心跳按该值续期
+	TTL time.Duration
+
+	// Version 路由版本（可选），同一 color 的不同版本是相互独立的路由
+	Version string
+
+	// Labels 路由标签（可选，如 region），用于指标等按标签汇总
+	Labels map[string]string
+}
+
+// VersionSeparator 带版本路由的 key 分隔符：color "blue" 的版本 "v2" 存储为 "blue:v2"
+// color 与 version 中不能包含该字符
+const VersionSeparator = ":"
+
+// RouteKey 返回路由的存储 key：没有版本时为 color，否则为 "<color>:<version>"
+// Backend 接口中 color 参数均指该 key
+func RouteKey(color, version string) string {
+	if version == "" {
+		return color
+	}
+	return color + VersionSeparator + version
+}
+
+// Key 返回路由的存储 key，见 RouteKey
+func (r *Route) Key() string {
+	return RouteKey(r.Color, r.Version)
+}
+
+// Endpoint 带权重的后端地址
+// Weight 为 0 表示不再向该地址分配新流量（但仍是路由的成员）
+type Endpoint struct {
+	Address string
+	Weight  int
+}
+
+// Normalize 统一 Address 与 Endpoints：
+// 只有 Address 时补全为权重 1 的单个 Endpoint；只有 Endpoints 时用第一个地址作为 Address
+func (r *Route) Normalize() {
+	if len(r.Endpoints) == 0 && r.Address != "" {
+		r.Endpoints = []Endpoint{{Address: r.Address, Weight: 1}}
+	}
+	if r.Address == "" && len(r.Endpoints) > 0 {
+		r.Address = r.Endpoints[0].Address
+	}
+}
+
+// EffectiveTTL 返回路由实际使用的 TTL：设置了 TTL 时使用自身的值，否则使用 def
+func (r *Route) EffectiveTTL(def time.Duration) time.Duration {
+	if r.TTL > 0 {
+		return r.TTL
+	}
+	return def
+}
+
+// EndpointList 返回路由的全部 Endpoint（兼容只设置了 Address 的旧数据）
+func (r *Route) EndpointList() []Endpoint {
+	if len(r.Endpoints) == 0 && r.Address != "" {
+		return []Endpoint{{Address: r.Address, Weight: 1}}
+	}
+	return r.Endpoints
 }
 
 // Backend 存储后端接口
 type Backend interface {
-	// Register 注册路由
+	// Register 注册路由，route.TTL 大于 0 时使用路由自身的 TTL，否则使用 ttl
 	Register(ctx context.Context, route *Route, ttl time.Duration) error
 
 	// Get 获取路由
+	// 约定：不得返回已过期（ExpiresAt 早于当前时间）的路由，
+	// 即使清理任务尚未运行也应视为不存在并返回 ErrRouteNotFound
 	Get(ctx context.Context, color string) (*Route, error)
 
-	// Heartbeat 心跳续期
+	// Heartbeat 心跳续期，按路由注册时的 TTL 续期，未设置时使用 ttl
 	Heartbeat(ctx context.Context, color, address, token string, ttl time.Duration) error
 
 	// List 列出所有路由
+	// 约定：结果按 SortRoutes 的规则（color 升序，其次 address 升序）排序，保证输出稳定
 	List(ctx context.Context) ([]*Route, error)
 
+	// ListByOwner 列出 owner 的路由，排序与 List 一致
+	// 后端可以借助索引避免全量 List；没有索引时过滤 List 的结果（见 FilterByOwner）
+	ListByOwner(ctx context.Context, owner string) ([]*Route, error)
+
 	// Delete 删除路由
 	Delete(ctx context.Context, color string) error
 
-	// DeleteExpired 清理过期路由
-	DeleteExpired(ctx context.Context) error
+	// DeleteExpired 清理过期路由，返回清理后仍有效的路由（与 List 的结果和排序一致），
+	// 清理任务直接复用该结果对比路由变化，不必再调用一次 List
+	DeleteExpired(ctx context.Context) ([]*Route, error)
+
+	// Ping 检查后端连接是否可用，供就绪探针使用，应比 List 更轻量
+	Ping(ctx context.Context) error
 
 	// Close 关闭连接
 	Close() error
 }
+
+// SortRoutes 按 color、version、address 升序排序，所有 Backend 的 List 结果都应使用该顺序
+func SortRoutes(routes []*Route) {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Color != routes[j].Color {
+			return routes[i].Color < routes[j].Color
+		}
+		if routes[i].Version != routes[j].Version {
+			return routes[i].Version < routes[j].Version
+		}
+		return routes[i].Address < routes[j].Address
+	})
+}
@@ -0,0 +1,132 @@
+package backend
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func registerRoutes(t testing.TB, b Backend, routes ...*Route) {
+	t.Helper()
+	for _, route := range routes {
+		if err := b.Register(context.Background(), route, time.Minute); err != nil {
+			t.Fatalf("register %s: %v", route.Key(), err)
+		}
+	}
+}
+
+func TestListByOwner(t *testing.T) {
+	mem := NewMemoryBackend()
+	defer mem.Close()
+	registerRoutes(t, mem,
+		&Route{Color: "blue", Address: "http://10.0.0.1", Owner: "team-a", Token: "t"},
+		&Route{Color: "green", Address: "http://10.0.0.2", Owner: "team-b", Token: "t"},
+		&Route{Color: "red", Address: "http://10.0.0.3", Owner: "team-a", Token: "t"},
+	)
+
+	cache := NewCacheBackend(mem, time.Minute)
+	defer cache.Close()
+	snapshot, err := NewSnapshotBackend(mem, t.TempDir()+"/routes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, b := range map[string]Backend{"memory": mem, "cache": cache, "snapshot": snapshot} {
+		routes, err := b.ListByOwner(context.Background(), "team-a")
+		if err != nil {
+			t.Fatalf("%s: ListByOwner: %v", name, err)
+		}
+		if len(routes) != 2 || routes[0].Color != "blue" || routes[1].Color != "red" {
+			t.Errorf("%s: ListByOwner(team-a) = %v, want blue and red", name, routes)
+		}
+	}
+}
+
+func TestDeleteExpiredReturnsLiveRoutes(t *testing.T) {
+	mem := NewMemoryBackend()
+	defer mem.Close()
+	registerRoutes(t, mem,
+		&Route{Color: "blue", Address: "http://10.0.0.1", Token: "t", TTL: time.Millisecond},
+		&Route{Color: "green", Address: "http://10.0.0.2", Token: "t"},
+	)
+	time.Sleep(5 * time.Millisecond)
+
+	routes, err := mem.DeleteExpired(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 || routes[0].Color != "green" {
+		t.Fatalf("DeleteExpired = %v, want only green", routes)
+	}
+	if _, err := mem.Get(context.Background(), "blue"); err != ErrRouteNotFound {
+		t.Errorf("expired route still present: %v", err)
+	}
+}
+
+func TestMemoryGetIgnoresExpiredBeforeCleanup(t *testing.T) {
+	mem := NewMemoryBackend()
+	defer mem.Close()
+	registerRoutes(t, mem, &Route{Color: "blue", Address: "http://10.0.0.1", Token: "t", TTL: time.Millisecond})
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := mem.Get(context.Background(), "blue"); err != ErrRouteNotFound {
+		t.Errorf("Get = %v, want ErrRouteNotFound before DeleteExpired runs", err)
+	}
+	if routes, _ := mem.List(context.Background()); len(routes) != 0 {
+		t.Errorf("List = %v, want no expired routes", routes)
+	}
+}
+
+func TestMemoryDeleteExpiredDuringHeartbeats(t *testing.T) {
+	mem := NewMemoryBackend()
+	defer mem.Close()
+	const ttl = 50 * time.Millisecond
+	registerRoutes(t, mem, &Route{Color: "blue", Address: "http://10.0.0.1", Token: "t"})
+	if err := mem.Heartbeat(context.Background(), "blue", "http://10.0.0.1", "t", ttl); err != nil {
+		t.Fatal(err)
+	}
+
+	// 心跳远早于 TTL 到期，与清理交替执行时路由不应被删除
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for deadline := time.Now().Add(100 * time.Millisecond); time.Now().Before(deadline); {
+			mem.DeleteExpired(context.Background())
+			time.Sleep(100 * time.Microsecond)
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			if _, err := mem.Get(context.Background(), "blue"); err != nil {
+				t.Errorf("route removed while heartbeating: %v", err)
+			}
+			return
+		default:
+		}
+		if err := mem.Heartbeat(context.Background(), "blue", "http://10.0.0.1", "t", ttl); err != nil {
+			t.Fatalf("heartbeat failed while cleanup runs: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRouteLabelsAreCopied(t *testing.T) {
+	mem := NewMemoryBackend()
+	defer mem.Close()
+	labels := map[string]string{"region": "eu"}
+	registerRoutes(t, mem, &Route{Color: "blue", Address: "http://10.0.0.1", Token: "t", Labels: labels})
+	labels["region"] = "us"
+
+	route, err := mem.Get(context.Background(), "blue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route.Labels["region"] != "eu" {
+		t.Errorf("Labels = %v, want region=eu unaffected by caller changes", route.Labels)
+	}
+	route.Labels["region"] = "ap"
+	if again, _ := mem.Get(context.Background(), "blue"); again.Labels["region"] != "eu" {
+		t.Errorf("Labels = %v, modifying a returned route changed the stored one", again.Labels)
+	}
+}
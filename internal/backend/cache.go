@@ -0,0 +1,344 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// 路由缓存的重新同步间隔与监听失败后的重试退避
+const (
+	cacheResyncInterval = time.Minute
+	cacheMinBackoff     = time.Second
+	cacheMaxBackoff     = 30 * time.Second
+
+	// cacheMaxEntries 按 TTL 缓存的 color 数量上限
+	cacheMaxEntries = 10000
+)
+
+// errWatchClosed 监听 channel 被后端关闭
+var errWatchClosed = errors.New("watch channel closed")
+
+// CacheBackend 本地路由缓存包装层
+// 1. 通过内部后端的 Watcher 接口监听路由变化，先 List 全量加载，再按事件增量更新
+// 2. 缓存同步期间 Get 直接读取缓存，不访问后端；其他方法透传给内部后端
+// 3. 监听中断或尚未同步时 Get 回退为访问后端，并按退避重试监听
+// 4. 每隔 cacheResyncInterval 重新 List 一次，修正监听期间可能丢失的变化
+// 5. 未同步时（后端不支持 Watch 或监听中断），ttl > 0 则按 ttl 缓存 Get 的结果（含路由不存在），
+// 本实例的注册、续期与删除立即使对应的缓存失效
+type CacheBackend struct {
+	Backend
+
+	ttl time.Duration
+
+	mu      sync.RWMutex
+	routes  map[string]*Route
+	synced  bool
+	entries map[string]cacheEntry
+	gen     uint64 // 本实例每次写入递增，避免写入前发起的读取把旧结果写回缓存
+}
+
+// cacheEntry 按 TTL 缓存的 Get 结果，route 为 nil 表示路由不存在
+type cacheEntry struct {
+	route   *Route
+	expires time.Time
+}
+
+// NewCacheBackend 创建路由缓存包装层，需调用 Run 启动同步；ttl <= 0 时只使用基于 Watch 的缓存
+func NewCacheBackend(inner Backend, ttl time.Duration) *CacheBackend {
+	return &CacheBackend{
+		Backend: inner,
+		ttl:     ttl,
+		routes:  make(map[string]*Route),
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Run 持续同步缓存直到 ctx 结束；onError 接收同步失败的原因
+// 内部后端不支持 Watch 时立即返回，此后 Get 只使用 TTL 缓存（ttl > 0 时）
+func (b *CacheBackend) Run(ctx context.Context, onError func(err error)) {
+	w, ok := b.Backend.(Watcher)
+	if !ok {
+		onError(ErrWatchUnsupported)
+		return
+	}
+
+	backoff := cacheMinBackoff
+	for {
+		start := time.Now()
+		err := b.sync(ctx, w)
+		b.invalidate()
+		if ctx.Err() != nil {
+			return
+		}
+		onError(err)
+		if errors.Is(err, ErrWatchUnsupported) {
+			return
+		}
+
+		// 同步维持了一段时间后再中断，视为偶发故障，退避从头开始
+		if time.Since(start) > cacheMaxBackoff {
+			backoff = cacheMinBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, cacheMaxBackoff)
+	}
+}
+
+// sync 建立监听后全量加载，此后按事件更新缓存，直到监听中断
+// 先 Watch 再 List：加载期间发生的变化会在之后按顺序应用，不会遗漏
+func (b *CacheBackend) sync(ctx context.Context, w Watcher) error {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := w.Watch(watchCtx)
+	if err != nil {
+		return err
+	}
+	if err := b.reload(ctx); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(cacheResyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-events:
+			if !ok {
+				return errWatchClosed
+			}
+			b.apply(ev)
+		case <-ticker.C:
+			if err := b.reload(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// reload 以内部后端 List 的结果替换缓存
+func (b *CacheBackend) reload(ctx context.Context) error {
+	routes, err := b.Backend.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	m := make(map[string]*Route, len(routes))
+	for _, route := range routes {
+		m[route.Key()] = cloneRoute(route)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.routes = m
+	b.synced = true
+	b.entries = make(map[string]cacheEntry)
+	return nil
+}
+
+// apply 应用一条路由变化
+func (b *CacheBackend) apply(ev RouteEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ev.Type == RouteEventDelete || ev.Route == nil {
+		delete(b.routes, ev.Key)
+		return
+	}
+	b.routes[ev.Key] = cloneRoute(ev.Route)
+}
+
+// invalidate 监听中断，缓存不再可信
+func (b *CacheBackend) invalidate() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.synced = false
+	b.routes = make(map[string]*Route)
+	b.entries = make(map[string]cacheEntry)
+}
+
+// Synced 缓存是否处于同步状态
+func (b *CacheBackend) Synced() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.synced
+}
+
+func (b *CacheBackend) Get(ctx context.Context, color string) (*Route, error) {
+	b.mu.RLock()
+	if !b.synced {
+		b.mu.RUnlock()
+		return b.getTTL(ctx, color)
+	}
+	route, ok := b.routes[color]
+	b.mu.RUnlock()
+
+	// 后端按 TTL 过期的 key 不一定产生事件，这里按 ExpiresAt 判断
+	if !ok || (!route.ExpiresAt.IsZero() && time.Now().After(route.ExpiresAt)) {
+		return nil, ErrRouteNotFound
+	}
+	return cloneRoute(route), nil
+}
+
+// ListByOwner 透传给内部后端
+func (b *CacheBackend) ListByOwner(ctx context.Context, owner string) ([]*Route, error) {
+	return b.Backend.ListByOwner(ctx, owner)
+}
+
+// Ping 透传给内部后端
+func (b *CacheBackend) Ping(ctx context.Context) error {
+	return b.Backend.Ping(ctx)
+}
+
+// getTTL 未同步时的读取：命中未过期的缓存直接返回，否则访问后端并缓存结果
+// 后端错误（非 ErrRouteNotFound）不缓存
+func (b *CacheBackend) getTTL(ctx context.Context, color string) (*Route, error) {
+	if b.ttl <= 0 {
+		return b.Backend.Get(ctx, color)
+	}
+
+	now := time.Now()
+	b.mu.RLock()
+	e, ok := b.entries[color]
+	gen := b.gen
+	b.mu.RUnlock()
+	if ok && now.Before(e.expires) {
+		if e.route == nil || (!e.route.ExpiresAt.IsZero() && now.After(e.route.ExpiresAt)) {
+			return nil, ErrRouteNotFound
+		}
+		return cloneRoute(e.route), nil
+	}
+
+	route, err := b.Backend.Get(ctx, color)
+	if err != nil && !errors.Is(err, ErrRouteNotFound) {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.synced && b.gen == gen {
+		if len(b.entries) >= cacheMaxEntries {
+			b.evict(now)
+		}
+		e := cacheEntry{expires: now.Add(b.ttl)}
+		if route != nil {
+			e.route = cloneRoute(route)
+		}
+		b.entries[color] = e
+	}
+	return route, err
+}
+
+// evict 缓存已满时先清理过期的条目，仍然满时随机淘汰一条，调用方需持有写锁
+func (b *CacheBackend) evict(now time.Time) {
+	for color, e := range b.entries {
+		if !now.Before(e.expires) {
+			delete(b.entries, color)
+		}
+	}
+	for color := range b.entries {
+		if len(b.entries) < cacheMaxEntries {
+			return
+		}
+		delete(b.entries, color)
+	}
+}
+
+// Register 写入后同步更新缓存，本实例的注册立即可见
+func (b *CacheBackend) Register(ctx context.Context, route *Route, ttl time.Duration) error {
+	if err := b.Backend.Register(ctx, route, ttl); err != nil {
+		return err
+	}
+	b.store(route)
+	return nil
+}
+
+// RegisterExclusive 透传给内部后端；内部后端不支持时退化为先读后写
+func (b *CacheBackend) RegisterExclusive(ctx context.Context, route *Route, ttl time.Duration) error {
+	if er, ok := b.Backend.(ExclusiveRegisterer); ok {
+		if err := er.RegisterExclusive(ctx, route, ttl); err != nil {
+			return err
+		}
+		b.store(route)
+		return nil
+	}
+	existing, err := b.Backend.Get(ctx, route.Key())
+	switch {
+	case err == nil && existing.Token != route.Token:
+		return ErrRouteConflict
+	case err != nil && !errors.Is(err, ErrRouteNotFound):
+		return err
+	}
+	return b.Register(ctx, route, ttl)
+}
+
+// Heartbeat 续期后同步延长缓存中的过期时间
+func (b *CacheBackend) Heartbeat(ctx context.Context, color, address, token string, ttl time.Duration) error {
+	if err := b.Backend.Heartbeat(ctx, color, address, token, ttl); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gen++
+	delete(b.entries, color)
+	if route, ok := b.routes[color]; ok && b.synced {
+		r := cloneRoute(route)
+		r.ExpiresAt = time.Now().Add(r.EffectiveTTL(ttl))
+		b.routes[color] = r
+	}
+	return nil
+}
+
+func (b *CacheBackend) Delete(ctx context.Context, color string) error {
+	if err := b.Backend.Delete(ctx, color); err != nil {
+		return err
+	}
+	b.remove(color)
+	return nil
+}
+
+// DeleteWithToken 透传给内部后端；内部后端不支持时退化为先读后删
+func (b *CacheBackend) DeleteWithToken(ctx context.Context, color, token string) error {
+	if td, ok := b.Backend.(TokenDeleter); ok {
+		if err := td.DeleteWithToken(ctx, color, token); err != nil {
+			return err
+		}
+		b.remove(color)
+		return nil
+	}
+	route, err := b.Backend.Get(ctx, color)
+	if err != nil {
+		return err
+	}
+	if route.Token != token {
+		return ErrTokenMismatch
+	}
+	return b.Delete(ctx, color)
+}
+
+// store 缓存同步期间写入路由，未同步时使 TTL 缓存失效
+func (b *CacheBackend) store(route *Route) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gen++
+	delete(b.entries, route.Key())
+	if b.synced {
+		b.routes[route.Key()] = cloneRoute(route)
+	}
+}
+
+// remove 删除缓存中的路由
+func (b *CacheBackend) remove(color string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gen++
+	delete(b.routes, color)
+	delete(b.entries, color)
+}
@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingBackend 统计 Get 调用次数；只嵌入 Backend 接口，不暴露 Watch，缓存只使用 TTL 模式
+type countingBackend struct {
+	Backend
+	gets atomic.Int64
+}
+
+func (b *countingBackend) Get(ctx context.Context, color string) (*Route, error) {
+	b.gets.Add(1)
+	return b.Backend.Get(ctx, color)
+}
+
+func newCountingCache(t testing.TB, ttl time.Duration) (*CacheBackend, *countingBackend) {
+	t.Helper()
+	inner := &countingBackend{Backend: NewMemoryBackend()}
+	cache := NewCacheBackend(inner, ttl)
+	t.Cleanup(func() { cache.Close() })
+	return cache, inner
+}
+
+func TestCacheEvictsDeletedRoute(t *testing.T) {
+	cache, inner := newCountingCache(t, time.Minute)
+	ctx := context.Background()
+	registerRoutes(t, cache, &Route{Color: "blue", Address: "http://10.0.0.1", Token: "t"})
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Get(ctx, "blue"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := inner.gets.Load(); n != 1 {
+		t.Errorf("backend gets = %d, want 1 within the cache ttl", n)
+	}
+
+	if err := cache.Delete(ctx, "blue"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Get(ctx, "blue"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Get after delete: err = %v, want ErrRouteNotFound", err)
+	}
+}
+
+func TestCacheRegisterInvalidatesMiss(t *testing.T) {
+	cache, _ := newCountingCache(t, time.Minute)
+	ctx := context.Background()
+
+	if _, err := cache.Get(ctx, "blue"); !errors.Is(err, ErrRouteNotFound) {
+		t.Fatalf("err = %v, want ErrRouteNotFound", err)
+	}
+	registerRoutes(t, cache, &Route{Color: "blue", Address: "http://10.0.0.1", Token: "t"})
+	if route, err := cache.Get(ctx, "blue"); err != nil || route.Address != "http://10.0.0.1" {
+		t.Errorf("Get after register = %v, %v, want the new route", route, err)
+	}
+}
+
+func benchmarkRouteGet(b *testing.B, ttl time.Duration) {
+	cache, inner := newCountingCache(b, ttl)
+	registerRoutes(b, cache, &Route{Color: "blue", Address: "http://10.0.0.1", Token: "t"})
+	inner.gets.Store(0)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := cache.Get(ctx, "blue"); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.ReportMetric(float64(inner.gets.Load())/float64(b.N), "backend-gets/op")
+}
+
+func BenchmarkRouteGetUncached(b *testing.B) { benchmarkRouteGet(b, 0) }
+
+func BenchmarkRouteGetCached(b *testing.B) { benchmarkRouteGet(b, time.Second) }
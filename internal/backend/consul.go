@@ -0,0 +1,291 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const consulKeyPrefix = "colorproxy/route/"
+
+// Consul session TTL 的取值范围
+const (
+	consulMinSessionTTL = 10 * time.Second
+	consulMaxSessionTTL = 24 * time.Hour
+)
+
+// ConsulBackend Consul 后端：路由存储在 KV 中并由带 TTL 的 session 持有，
+// session 过期（未续期）时 Consul 自动删除 key
+type ConsulBackend struct {
+	client *api.Client
+}
+
+type ConsulConfig struct {
+	Address    string
+	Token      string
+	Datacenter string
+}
+
+func NewConsulBackend(cfg *ConsulConfig) (*ConsulBackend, error) {
+	if cfg.Address == "" {
+		cfg.Address = "localhost:8500"
+	}
+
+	conf := api.DefaultConfig()
+	conf.Address = cfg.Address
+	conf.Token = cfg.Token
+	conf.Datacenter = cfg.Datacenter
+	client, err := api.NewClient(conf)
+	if err != nil {
+		return nil, fmt.Errorf("consul connection failed: %w", err)
+	}
+
+	// 测试连接
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if _, err := client.Status().LeaderWithQueryOptions((&api.QueryOptions{}).WithContext(ctx)); err != nil {
+		return nil, fmt.Errorf("consul connection failed: %w", err)
+	}
+
+	return &ConsulBackend{client: client}, nil
+}
+
+// Register 为路由创建新的 session 并以其持有 key；覆盖注册时先销毁旧 session
+func (b *ConsulBackend) Register(ctx context.Context, route *Route, ttl time.Duration) error {
+	route.Normalize()
+	ttl = route.EffectiveTTL(ttl)
+	route.ExpiresAt = time.Now().Add(ttl)
+
+	data, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+
+	key := consulKeyPrefix + route.Key()
+	wo := (&api.WriteOptions{}).WithContext(ctx)
+	old, _, err := b.client.KV().Get(key, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if old != nil && old.Session != "" {
+		if _, err := b.client.Session().Destroy(old.Session, wo); err != nil {
+			return err
+		}
+	}
+
+	session, _, err := b.client.Session().CreateNoChecks(&api.SessionEntry{
+		Name:     "colorproxy:" + route.Key(),
+		TTL:      consulSessionTTL(ttl),
+		Behavior: api.SessionBehaviorDelete,
+	}, wo)
+	if err != nil {
+		return err
+	}
+
+	ok, _, err := b.client.KV().Acquire(&api.KVPair{Key: key, Value: data, Session: session}, wo)
+	if err != nil || !ok {
+		b.client.Session().Destroy(session, wo)
+		if err == nil {
+			err = fmt.Errorf("consul: failed to acquire %s", key)
+		}
+		return err
+	}
+	return nil
+}
+
+func (b *ConsulBackend) Get(ctx context.Context, color string) (*Route, error) {
+	route, _, err := b.get(ctx, color)
+	return route, err
+}
+
+// get 读取路由及其 KV 元数据（session、版本号）
+func (b *ConsulBackend) get(ctx context.Context, color string) (*Route, *api.KVPair, error) {
+	pair, _, err := b.client.KV().Get(consulKeyPrefix+color, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	if pair == nil {
+		return nil, nil, ErrRouteNotFound
+	}
+
+	var route Route
+	if err := json.Unmarshal(pair.Value, &route); err != nil {
+		return nil, nil, err
+	}
+
+	// Consul 可能在 TTL 的两倍内才使 session 失效，这里按 ExpiresAt 兜底
+	if !route.ExpiresAt.IsZero() && time.Now().After(route.ExpiresAt) {
+		return nil, nil, ErrRouteNotFound
+	}
+
+	return &route, pair, nil
+}
+
+// Heartbeat 续期路由的 session，续期时长为注册时的 TTL
+func (b *ConsulBackend) Heartbeat(ctx context.Context, color, address, token string, ttl time.Duration) error {
+	route, pair, err := b.get(ctx, color)
+	if err != nil {
+		return err
+	}
+
+	if route.Address != address || route.Token != token {
+		return errors.New("address or token mismatch")
+	}
+
+	wo := (&api.WriteOptions{}).WithContext(ctx)
+	entry, _, err := b.client.Session().Renew(pair.Session, wo)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return ErrRouteNotFound
+	}
+
+	// 同步更新 ExpiresAt；以同一 session 重新持有 key，期间被重新注册（session 已变）则失败
+	route.ExpiresAt = time.Now().Add(route.EffectiveTTL(ttl))
+	data, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+	ok, _, err := b.client.KV().Acquire(&api.KVPair{Key: pair.Key, Value: data, Session: pair.Session}, wo)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRouteNotFound
+	}
+	return nil
+}
+
+func (b *ConsulBackend) List(ctx context.Context) ([]*Route, error) {
+	pairs, _, err := b.client.KV().List(consulKeyPrefix, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	routes := make([]*Route, 0, len(pairs))
+	for _, pair := range pairs {
+		var route Route
+		if err := json.Unmarshal(pair.Value, &route); err != nil {
+			continue
+		}
+		if !route.ExpiresAt.IsZero() && now.After(route.ExpiresAt) {
+			continue
+		}
+		routes = append(routes, &route)
+	}
+
+	SortRoutes(routes)
+	return routes, nil
+}
+
+// Delete 删除 key 并销毁持有它的 session
+// ListByOwner 没有 owner 索引，过滤 List 的结果
+func (b *ConsulBackend) ListByOwner(ctx context.Context, owner string) ([]*Route, error) {
+	routes, err := b.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return FilterByOwner(routes, owner), nil
+}
+
+func (b *ConsulBackend) Delete(ctx context.Context, color string) error {
+	key := consulKeyPrefix + color
+	pair, _, err := b.client.KV().Get(key, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if pair == nil {
+		return nil
+	}
+
+	wo := (&api.WriteOptions{}).WithContext(ctx)
+	if _, err := b.client.KV().Delete(key, wo); err != nil {
+		return err
+	}
+	if pair.Session != "" {
+		_, err = b.client.Session().Destroy(pair.Session, wo)
+	}
+	return err
+}
+
+// DeleteWithToken 读取并校验 token 后，以版本号比较删除，期间路由被重新注册则重新校验
+func (b *ConsulBackend) DeleteWithToken(ctx context.Context, color, token string) error {
+	wo := (&api.WriteOptions{}).WithContext(ctx)
+	for {
+		route, pair, err := b.get(ctx, color)
+		if err != nil {
+			return err
+		}
+		if route.Token != token {
+			return ErrTokenMismatch
+		}
+
+		ok, _, err := b.client.KV().DeleteCAS(pair, wo)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if pair.Session != "" {
+				b.client.Session().Destroy(pair.Session, wo)
+			}
+			return nil
+		}
+	}
+}
+
+// DeleteExpired session 失效后 Consul 自动删除 key，这里只清理已按 ExpiresAt 过期但 session 尚未失效的 key
+func (b *ConsulBackend) DeleteExpired(ctx context.Context) ([]*Route, error) {
+	pairs, _, err := b.client.KV().List(consulKeyPrefix, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	wo := (&api.WriteOptions{}).WithContext(ctx)
+	routes := make([]*Route, 0, len(pairs))
+	for _, pair := range pairs {
+		var route Route
+		if err := json.Unmarshal(pair.Value, &route); err != nil {
+			continue
+		}
+		if route.ExpiresAt.IsZero() || !now.After(route.ExpiresAt) {
+			routes = append(routes, &route)
+			continue
+		}
+		// 仅当 key 未被续期或重新注册时删除
+		if ok, _, err := b.client.KV().DeleteCAS(pair, wo); err == nil && ok && pair.Session != "" {
+			b.client.Session().Destroy(pair.Session, wo)
+		}
+	}
+
+	SortRoutes(routes)
+	return routes, nil
+}
+
+// Ping 查询集群 leader，与创建时的连接检查一致
+func (b *ConsulBackend) Ping(ctx context.Context) error {
+	_, err := b.client.Status().LeaderWithQueryOptions((&api.QueryOptions{}).WithContext(ctx))
+	return err
+}
+
+// Close Consul 客户端基于 HTTP，无需关闭
+func (b *ConsulBackend) Close() error {
+	return nil
+}
+
+// consulSessionTTL 将 TTL 转换为 Consul session 接受的格式，并限制在 [10s, 24h]
+func consulSessionTTL(ttl time.Duration) string {
+	if ttl < consulMinSessionTTL {
+		ttl = consulMinSessionTTL
+	}
+	if ttl > consulMaxSessionTTL {
+		ttl = consulMaxSessionTTL
+	}
+	return fmt.Sprintf("%ds", int64(ttl/time.Second))
+}
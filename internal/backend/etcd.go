@@ -0,0 +1,324 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const etcdKeyPrefix = "colorproxy/route/"
+
+// EtcdBackend etcd 后端：路由绑定 lease，过期由 etcd 自动删除
+type EtcdBackend struct {
+	client *clientv3.Client
+}
+
+type EtcdConfig struct {
+	Endpoints   []string
+	Username    string
+	Password    string
+	DialTimeout time.Duration
+}
+
+func NewEtcdBackend(cfg *EtcdConfig) (*EtcdBackend, error) {
+	if len(cfg.Endpoints) == 0 {
+		cfg.Endpoints = []string{"localhost:2379"}
+	}
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = 3 * time.Second
+	}
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   cfg.Endpoints,
+		Username:    cfg.Username,
+		Password:    cfg.Password,
+		DialTimeout: cfg.DialTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("etcd connection failed: %w", err)
+	}
+
+	// 测试连接
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if _, err := client.Status(ctx, cfg.Endpoints[0]); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("etcd connection failed: %w", err)
+	}
+
+	return &EtcdBackend{client: client}, nil
+}
+
+func (b *EtcdBackend) Register(ctx context.Context, route *Route, ttl time.Duration) error {
+	route.Normalize()
+	ttl = route.EffectiveTTL(ttl)
+	route.ExpiresAt = time.Now().Add(ttl)
+
+	data, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+
+	lease, err := b.client.Grant(ctx, leaseSeconds(ttl))
+	if err != nil {
+		return err
+	}
+
+	// 覆盖注册时 key 会绑定到新 lease，旧 lease 到期后自行回收
+	_, err = b.client.Put(ctx, etcdKeyPrefix+route.Key(), string(data), clientv3.WithLease(lease.ID))
+	return err
+}
+
+// RegisterExclusive key 不存在时以创建版本号比较写入；已存在时校验 token 后以版本号比较覆盖，期间被并发修改则重试
+func (b *EtcdBackend) RegisterExclusive(ctx context.Context, route *Route, ttl time.Duration) error {
+	route.Normalize()
+	ttl = route.EffectiveTTL(ttl)
+	route.ExpiresAt = time.Now().Add(ttl)
+
+	data, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+
+	lease, err := b.client.Grant(ctx, leaseSeconds(ttl))
+	if err != nil {
+		return err
+	}
+
+	key := etcdKeyPrefix + route.Key()
+	put := clientv3.OpPut(key, string(data), clientv3.WithLease(lease.ID))
+	for {
+		resp, err := b.client.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		cmp := clientv3.Compare(clientv3.CreateRevision(key), "=", 0)
+		if len(resp.Kvs) > 0 {
+			kv := resp.Kvs[0]
+			var existing Route
+			// 已按 ExpiresAt 过期但 lease 尚未回收的路由视为未注册
+			if err := json.Unmarshal(kv.Value, &existing); err != nil ||
+				(existing.Token != route.Token && (existing.ExpiresAt.IsZero() || time.Now().Before(existing.ExpiresAt))) {
+				b.client.Revoke(ctx, lease.ID)
+				return ErrRouteConflict
+			}
+			cmp = clientv3.Compare(clientv3.ModRevision(key), "=", kv.ModRevision)
+		}
+
+		txn, err := b.client.Txn(ctx).If(cmp).Then(put).Commit()
+		if err != nil {
+			return err
+		}
+		if txn.Succeeded {
+			return nil
+		}
+	}
+}
+
+func (b *EtcdBackend) Get(ctx context.Context, color string) (*Route, error) {
+	route, _, err := b.get(ctx, color)
+	return route, err
+}
+
+// get 读取路由及其 key 元数据（lease、版本号）
+func (b *EtcdBackend) get(ctx context.Context, color string) (*Route, *clientv3.GetResponse, error) {
+	resp, err := b.client.Get(ctx, etcdKeyPrefix+color)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil, ErrRouteNotFound
+	}
+
+	var route Route
+	if err := json.Unmarshal(resp.Kvs[0].Value, &route); err != nil {
+		return nil, nil, err
+	}
+
+	// lease 到期后 key 会被删除，这里再按 ExpiresAt 兜底
+	if !route.ExpiresAt.IsZero() && time.Now().After(route.ExpiresAt) {
+		return nil, nil, ErrRouteNotFound
+	}
+
+	return &route, resp, nil
+}
+
+// Heartbeat 续期路由绑定的 lease，续期时长为注册时的 TTL
+func (b *EtcdBackend) Heartbeat(ctx context.Context, color, address, token string, ttl time.Duration) error {
+	route, resp, err := b.get(ctx, color)
+	if err != nil {
+		return err
+	}
+
+	if route.Address != address || route.Token != token {
+		return errors.New("address or token mismatch")
+	}
+
+	kv := resp.Kvs[0]
+	leaseID := clientv3.LeaseID(kv.Lease)
+	ka, err := b.client.KeepAliveOnce(ctx, leaseID)
+	if err != nil {
+		if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+			return ErrRouteNotFound
+		}
+		return err
+	}
+
+	// 同步更新 ExpiresAt；仅当 key 未被并发修改时写入，避免覆盖新的注册
+	route.ExpiresAt = time.Now().Add(time.Duration(ka.TTL) * time.Second)
+	data, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+	key := etcdKeyPrefix + color
+	_, err = b.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", kv.ModRevision)).
+		Then(clientv3.OpPut(key, string(data), clientv3.WithLease(leaseID))).
+		Commit()
+	return err
+}
+
+func (b *EtcdBackend) List(ctx context.Context) ([]*Route, error) {
+	resp, err := b.client.Get(ctx, etcdKeyPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	routes := make([]*Route, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var route Route
+		if err := json.Unmarshal(kv.Value, &route); err != nil {
+			continue
+		}
+		if !route.ExpiresAt.IsZero() && now.After(route.ExpiresAt) {
+			continue
+		}
+		routes = append(routes, &route)
+	}
+
+	SortRoutes(routes)
+	return routes, nil
+}
+
+// ListByOwner 没有 owner 索引，过滤 List 的结果
+func (b *EtcdBackend) ListByOwner(ctx context.Context, owner string) ([]*Route, error) {
+	routes, err := b.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return FilterByOwner(routes, owner), nil
+}
+
+func (b *EtcdBackend) Delete(ctx context.Context, color string) error {
+	_, err := b.client.Delete(ctx, etcdKeyPrefix+color)
+	return err
+}
+
+// DeleteWithToken 读取并校验 token 后，以版本号比较删除，期间路由被重新注册则重新校验
+func (b *EtcdBackend) DeleteWithToken(ctx context.Context, color, token string) error {
+	key := etcdKeyPrefix + color
+	for {
+		route, resp, err := b.get(ctx, color)
+		if err != nil {
+			return err
+		}
+		if route.Token != token {
+			return ErrTokenMismatch
+		}
+
+		txn, err := b.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision)).
+			Then(clientv3.OpDelete(key)).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if txn.Succeeded {
+			return nil
+		}
+	}
+}
+
+// DeleteExpired lease 到期后 etcd 自动删除 key，无需清理，只返回当前路由
+func (b *EtcdBackend) DeleteExpired(ctx context.Context) ([]*Route, error) {
+	return b.List(ctx)
+}
+
+// Watch 监听路由前缀下的变化，lease 到期删除的 key 同样产生删除事件
+func (b *EtcdBackend) Watch(ctx context.Context) (<-chan RouteEvent, error) {
+	wch := b.client.Watch(clientv3.WithRequireLeader(ctx), etcdKeyPrefix, clientv3.WithPrefix(), clientv3.WithCreatedNotify())
+	// 等待 watch 建立，确保返回后发生的变化都能收到
+	select {
+	case resp, ok := <-wch:
+		if !ok {
+			return nil, ctx.Err()
+		}
+		if err := resp.Err(); err != nil {
+			return nil, err
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	ch := make(chan RouteEvent, watchBufferSize)
+	go func() {
+		defer close(ch)
+		for resp := range wch {
+			if resp.Err() != nil || resp.Canceled {
+				// watch 中断（如 compaction），由调用方重新同步
+				return
+			}
+			for _, e := range resp.Events {
+				ev := RouteEvent{Key: strings.TrimPrefix(string(e.Kv.Key), etcdKeyPrefix)}
+				if e.Type == clientv3.EventTypeDelete {
+					ev.Type = RouteEventDelete
+				} else {
+					var route Route
+					if err := json.Unmarshal(e.Kv.Value, &route); err != nil {
+						continue
+					}
+					ev.Type = RouteEventPut
+					ev.Route = &route
+				}
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// Ping 依次查询各 endpoint 的状态，任意一个可用即视为可达
+func (b *EtcdBackend) Ping(ctx context.Context) error {
+	var err error
+	for _, ep := range b.client.Endpoints() {
+		if _, err = b.client.Status(ctx, ep); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (b *EtcdBackend) Close() error {
+	return b.client.Close()
+}
+
+// leaseSeconds lease 以秒为单位，向上取整且至少 1 秒
+func leaseSeconds(ttl time.Duration) int64 {
+	secs := int64((ttl + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
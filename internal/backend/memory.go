@@ -0,0 +1,214 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// MemoryBackend 内存后端：适用于测试和单节点部署，进程退出后数据丢失
+type MemoryBackend struct {
+	mu       sync.RWMutex
+	routes   map[string]*Route
+	watchers map[chan RouteEvent]struct{}
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		routes:   make(map[string]*Route),
+		watchers: make(map[chan RouteEvent]struct{}),
+	}
+}
+
+// Watch 监听路由变化；消费过慢导致缓冲区满时关闭 channel，由调用方重新同步
+func (b *MemoryBackend) Watch(ctx context.Context) (<-chan RouteEvent, error) {
+	ch := make(chan RouteEvent, watchBufferSize)
+	b.mu.Lock()
+	b.watchers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.watchers[ch]; ok {
+			delete(b.watchers, ch)
+			close(ch)
+		}
+	}()
+	return ch, nil
+}
+
+// notify 通知所有监听者，调用方需持有写锁
+func (b *MemoryBackend) notify(typ RouteEventType, key string, route *Route) {
+	for ch := range b.watchers {
+		ev := RouteEvent{Type: typ, Key: key}
+		if route != nil {
+			ev.Route = cloneRoute(route)
+		}
+		select {
+		case ch <- ev:
+		default:
+			delete(b.watchers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *MemoryBackend) Register(ctx context.Context, route *Route, ttl time.Duration) error {
+	route.Normalize()
+	route.ExpiresAt = time.Now().Add(route.EffectiveTTL(ttl))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.routes[route.Key()] = cloneRoute(route)
+	b.notify(RouteEventPut, route.Key(), route)
+	return nil
+}
+
+func (b *MemoryBackend) RegisterExclusive(ctx context.Context, route *Route, ttl time.Duration) error {
+	route.Normalize()
+	route.ExpiresAt = time.Now().Add(route.EffectiveTTL(ttl))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := route.Key()
+	if existing, ok := b.routes[key]; ok && !time.Now().After(existing.ExpiresAt) && existing.Token != route.Token {
+		return ErrRouteConflict
+	}
+	b.routes[key] = cloneRoute(route)
+	b.notify(RouteEventPut, key, route)
+	return nil
+}
+
+func (b *MemoryBackend) Get(ctx context.Context, color string) (*Route, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	route, ok := b.routes[color]
+	if !ok || time.Now().After(route.ExpiresAt) {
+		return nil, ErrRouteNotFound
+	}
+	return cloneRoute(route), nil
+}
+
+func (b *MemoryBackend) Heartbeat(ctx context.Context, color, address, token string, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	route, ok := b.routes[color]
+	if !ok || time.Now().After(route.ExpiresAt) {
+		return ErrRouteNotFound
+	}
+
+	if route.Address != address || route.Token != token {
+		return errors.New("address or token mismatch")
+	}
+
+	route.ExpiresAt = time.Now().Add(route.EffectiveTTL(ttl))
+	b.notify(RouteEventPut, color, route)
+	return nil
+}
+
+func (b *MemoryBackend) List(ctx context.Context) ([]*Route, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	now := time.Now()
+	routes := make([]*Route, 0, len(b.routes))
+	for _, route := range b.routes {
+		if now.After(route.ExpiresAt) {
+			continue
+		}
+		routes = append(routes, cloneRoute(route))
+	}
+
+	SortRoutes(routes)
+	return routes, nil
+}
+
+func (b *MemoryBackend) ListByOwner(ctx context.Context, owner string) ([]*Route, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	now := time.Now()
+	var routes []*Route
+	for _, route := range b.routes {
+		if route.Owner != owner || now.After(route.ExpiresAt) {
+			continue
+		}
+		routes = append(routes, cloneRoute(route))
+	}
+
+	SortRoutes(routes)
+	return routes, nil
+}
+
+func (b *MemoryBackend) Delete(ctx context.Context, color string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.routes[color]; ok {
+		delete(b.routes, color)
+		b.notify(RouteEventDelete, color, nil)
+	}
+	return nil
+}
+
+func (b *MemoryBackend) DeleteWithToken(ctx context.Context, color, token string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	route, ok := b.routes[color]
+	if !ok || time.Now().After(route.ExpiresAt) {
+		return ErrRouteNotFound
+	}
+	if route.Token != token {
+		return ErrTokenMismatch
+	}
+	delete(b.routes, color)
+	b.notify(RouteEventDelete, color, nil)
+	return nil
+}
+
+func (b *MemoryBackend) DeleteExpired(ctx context.Context) ([]*Route, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 在写锁内判断并删除，与 Heartbeat 续期互斥，不会误删刚续期的路由
+	now := time.Now()
+	routes := make([]*Route, 0, len(b.routes))
+	for color, route := range b.routes {
+		if now.After(route.ExpiresAt) {
+			delete(b.routes, color)
+			b.notify(RouteEventDelete, color, nil)
+			continue
+		}
+		routes = append(routes, cloneRoute(route))
+	}
+
+	SortRoutes(routes)
+	return routes, nil
+}
+
+// Ping 内存后端始终可用
+func (b *MemoryBackend) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (b *MemoryBackend) Close() error {
+	return nil
+}
+
+// cloneRoute 复制路由，避免调用方修改内部数据
+func cloneRoute(route *Route) *Route {
+	r := *route
+	r.Endpoints = append([]Endpoint(nil), route.Endpoints...)
+	if route.Labels != nil {
+		r.Labels = make(map[string]string, len(route.Labels))
+		for k, v := range route.Labels {
+			r.Labels[k] = v
+		}
+	}
+	return &r
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,6 +13,15 @@ import (
 
 const redisKeyPrefix = "colorproxy:route:"
 
+// redisOwnerPrefix owner 索引集合的 key 前缀，集合成员为该 owner 的路由 key
+const redisOwnerPrefix = "colorproxy:owner:"
+
+// redisEventChannel 路由变化通知的 pub/sub channel，消息内容为路由 key
+const redisEventChannel = "colorproxy:route-events"
+
+// redisScanCount 每次 SCAN 建议返回的 key 数量
+const redisScanCount = 100
+
 type RedisBackend struct {
 	client *redis.Client
 }
@@ -44,7 +54,52 @@ func NewRedisBackend(cfg *RedisConfig) (*RedisBackend, error) {
 }
 
 func (b *RedisBackend) Register(ctx context.Context, route *Route, ttl time.Duration) error {
-	key := redisKeyPrefix + route.Color
+	key := redisKeyPrefix + route.Key()
+	route.Normalize()
+	ttl = route.EffectiveTTL(ttl)
+	route.ExpiresAt = time.Now().Add(ttl)
+
+	data, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+
+	if _, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, ttl)
+		b.indexOwner(ctx, pipe, route)
+		return nil
+	}); err != nil {
+		return err
+	}
+	b.publish(ctx, route.Key())
+	return nil
+}
+
+// indexOwner 把路由加入 owner 索引；过期或换了 owner 的成员在 ListByOwner 时清理
+func (b *RedisBackend) indexOwner(ctx context.Context, c redis.Cmdable, route *Route) {
+	if route.Owner != "" {
+		c.SAdd(ctx, redisOwnerPrefix+route.Owner, route.Key())
+	}
+}
+
+// registerOwnedScript 路由已存在时仅当 token 一致才覆盖：0 token 不一致，1 已写入
+var registerOwnedScript = redis.NewScript(`
+local data = redis.call("GET", KEYS[1])
+if data then
+	local ok, route = pcall(cjson.decode, data)
+	if not ok or route["Token"] ~= ARGV[2] then
+		return 0
+	end
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[3])
+return 1
+`)
+
+// RegisterExclusive 先以 SET NX 抢占 color，已存在时再校验 token 后覆盖
+func (b *RedisBackend) RegisterExclusive(ctx context.Context, route *Route, ttl time.Duration) error {
+	key := redisKeyPrefix + route.Key()
+	route.Normalize()
+	ttl = route.EffectiveTTL(ttl)
 	route.ExpiresAt = time.Now().Add(ttl)
 
 	data, err := json.Marshal(route)
@@ -52,7 +107,27 @@ func (b *RedisBackend) Register(ctx context.Context, route *Route, ttl time.Dura
 		return err
 	}
 
-	return b.client.Set(ctx, key, data, ttl).Err()
+	ok, err := b.client.SetNX(ctx, key, data, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if ok {
+		b.indexOwner(ctx, b.client, route)
+		b.publish(ctx, route.Key())
+		return nil
+	}
+
+	// color 已被注册：同一 token 的重复注册照常覆盖（如实例重启）
+	res, err := registerOwnedScript.Run(ctx, b.client, []string{key}, data, route.Token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if res == 0 {
+		return ErrRouteConflict
+	}
+	b.indexOwner(ctx, b.client, route)
+	b.publish(ctx, route.Key())
+	return nil
 }
 
 func (b *RedisBackend) Get(ctx context.Context, color string) (*Route, error) {
@@ -60,7 +135,7 @@ func (b *RedisBackend) Get(ctx context.Context, color string) (*Route, error) {
 	data, err := b.client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return nil, errors.New("route not found")
+			return nil, ErrRouteNotFound
 		}
 		return nil, err
 	}
@@ -70,6 +145,11 @@ func (b *RedisBackend) Get(ctx context.Context, color string) (*Route, error) {
 		return nil, err
 	}
 
+	// Redis TTL 通常已经保证过期 key 不可见，这里再按 ExpiresAt 兜底（如时钟偏差、PERSIST 等情况）
+	if !route.ExpiresAt.IsZero() && time.Now().After(route.ExpiresAt) {
+		return nil, ErrRouteNotFound
+	}
+
 	return &route, nil
 }
 
@@ -83,20 +163,73 @@ func (b *RedisBackend) Heartbeat(ctx context.Context, color, address, token stri
 		return errors.New("address or token mismatch")
 	}
 
-	route.ExpiresAt = time.Now().Add(ttl)
+	// Register 按路由保存的 TTL 续期
 	return b.Register(ctx, route, ttl)
 }
 
+// routeKeys 以 SCAN 遍历全部路由 key，避免 KEYS 在 key 较多时阻塞 Redis
+// SCAN 可能返回重复的 key，这里去重
+func (b *RedisBackend) routeKeys(ctx context.Context) ([]string, error) {
+	var keys []string
+	seen := make(map[string]struct{})
+	iter := b.client.Scan(ctx, 0, redisKeyPrefix+"*", redisScanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// routeValues 通过 pipeline 批量读取 key 的值，N 个 key 只需一次往返
+// 结果与 keys 一一对应，读取失败（如 SCAN 之后已过期）的 key 对应空字符串
+func (b *RedisBackend) routeValues(ctx context.Context, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	pipe := b.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+	// Exec 返回第一个失败命令的错误：key 不存在（redis.Nil）等命令级错误只影响该 key，连接错误才整体失败
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		var rerr redis.Error
+		if !errors.As(err, &rerr) {
+			return nil, err
+		}
+	}
+
+	values := make([]string, len(keys))
+	for i, cmd := range cmds {
+		if data, err := cmd.Result(); err == nil {
+			values[i] = data
+		}
+	}
+	return values, nil
+}
+
 func (b *RedisBackend) List(ctx context.Context) ([]*Route, error) {
-	keys, err := b.client.Keys(ctx, redisKeyPrefix+"*").Result()
+	keys, err := b.routeKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := b.routeValues(ctx, keys)
 	if err != nil {
 		return nil, err
 	}
 
 	routes := make([]*Route, 0, len(keys))
-	for _, key := range keys {
-		data, err := b.client.Get(ctx, key).Result()
-		if err != nil {
+	for _, data := range values {
+		if data == "" {
 			continue
 		}
 
@@ -108,28 +241,193 @@ func (b *RedisBackend) List(ctx context.Context) ([]*Route, error) {
 		routes = append(routes, &route)
 	}
 
+	SortRoutes(routes)
+	return routes, nil
+}
+
+// ListByOwner 通过 owner 索引集合读取路由，只访问该 owner 的 key
+// 已过期、已删除或已换 owner 的成员从集合中移除
+func (b *RedisBackend) ListByOwner(ctx context.Context, owner string) ([]*Route, error) {
+	index := redisOwnerPrefix + owner
+	members, err := b.client.SMembers(ctx, index).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(members))
+	for i, member := range members {
+		keys[i] = redisKeyPrefix + member
+	}
+	values, err := b.routeValues(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var routes []*Route
+	var stale []interface{}
+	for i, data := range values {
+		var route Route
+		if data == "" || json.Unmarshal([]byte(data), &route) != nil || route.Owner != owner {
+			stale = append(stale, members[i])
+			continue
+		}
+		if !route.ExpiresAt.IsZero() && now.After(route.ExpiresAt) {
+			continue
+		}
+		routes = append(routes, &route)
+	}
+	if len(stale) > 0 {
+		b.client.SRem(ctx, index, stale...)
+	}
+
+	SortRoutes(routes)
 	return routes, nil
 }
 
 func (b *RedisBackend) Delete(ctx context.Context, color string) error {
 	key := redisKeyPrefix + color
-	return b.client.Del(ctx, key).Err()
+	if err := b.client.Del(ctx, key).Err(); err != nil {
+		return err
+	}
+	b.publish(ctx, color)
+	return nil
 }
 
-func (b *RedisBackend) DeleteExpired(ctx context.Context) error {
-	routes, err := b.List(ctx)
+// deleteWithTokenScript token 一致才删除：-1 路由不存在，0 token 不一致，1 已删除
+var deleteWithTokenScript = redis.NewScript(`
+local data = redis.call("GET", KEYS[1])
+if not data then
+	return -1
+end
+local ok, route = pcall(cjson.decode, data)
+if not ok or route["Token"] ~= ARGV[1] then
+	return 0
+end
+redis.call("DEL", KEYS[1])
+return 1
+`)
+
+func (b *RedisBackend) DeleteWithToken(ctx context.Context, color, token string) error {
+	res, err := deleteWithTokenScript.Run(ctx, b.client, []string{redisKeyPrefix + color}, token).Int()
 	if err != nil {
 		return err
 	}
+	switch res {
+	case -1:
+		return ErrRouteNotFound
+	case 0:
+		return ErrTokenMismatch
+	}
+	b.publish(ctx, color)
+	return nil
+}
+
+// deleteIfUnchangedScript 仅当 key 的值与读取时一致才删除，
+// 避免清理过程中刚被心跳续期的路由被误删
+var deleteIfUnchangedScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (b *RedisBackend) DeleteExpired(ctx context.Context) ([]*Route, error) {
+	keys, err := b.routeKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := b.routeValues(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
 
 	now := time.Now()
-	for _, route := range routes {
-		if now.After(route.ExpiresAt) {
-			b.Delete(ctx, route.Color)
+	routes := make([]*Route, 0, len(keys))
+	for i, key := range keys {
+		data := values[i]
+		if data == "" {
+			continue
+		}
+
+		var route Route
+		if err := json.Unmarshal([]byte(data), &route); err != nil {
+			continue
+		}
+
+		if !now.After(route.ExpiresAt) {
+			routes = append(routes, &route)
+			continue
+		}
+		if n, err := deleteIfUnchangedScript.Run(ctx, b.client, []string{key}, data).Int(); err == nil && n > 0 {
+			b.publish(ctx, strings.TrimPrefix(key, redisKeyPrefix))
 		}
 	}
 
-	return nil
+	SortRoutes(routes)
+	return routes, nil
+}
+
+// publish 通知路由变化，失败不影响写入结果（监听方会定期重新同步）
+func (b *RedisBackend) publish(ctx context.Context, key string) {
+	b.client.Publish(ctx, redisEventChannel, key)
+}
+
+// Watch 订阅路由变化通知，收到通知后读取该 key 的最新值
+// 写入方需同为 RedisBackend；TTL 到期的 key 不产生通知，由调用方按 ExpiresAt 判断。
+// 连接断开后 go-redis 会自动重新订阅，期间的通知会丢失，调用方需定期重新同步
+func (b *RedisBackend) Watch(ctx context.Context) (<-chan RouteEvent, error) {
+	pubsub := b.client.Subscribe(ctx, redisEventChannel)
+	// 等待订阅确认，确保返回后发生的变化都能收到
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	ch := make(chan RouteEvent, watchBufferSize)
+	go func() {
+		defer close(ch)
+		defer pubsub.Close()
+
+		msgs := pubsub.Channel()
+		for {
+			var msg *redis.Message
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-msgs:
+				if !ok {
+					return
+				}
+				msg = m
+			}
+
+			ev := RouteEvent{Type: RouteEventPut, Key: msg.Payload}
+			route, err := b.Get(ctx, msg.Payload)
+			switch {
+			case errors.Is(err, ErrRouteNotFound):
+				ev.Type = RouteEventDelete
+			case err != nil:
+				// 无法读取最新值，终止监听，由调用方重新同步
+				return
+			default:
+				ev.Route = route
+			}
+
+			select {
+			case ch <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// Ping 检查 Redis 连接
+func (b *RedisBackend) Ping(ctx context.Context) error {
+	return b.client.Ping(ctx).Err()
 }
 
 func (b *RedisBackend) Close() error {
@@ -0,0 +1,382 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis 只支持 PING、CLIENT、GET、SET、DEL、SCAN（按 COUNT 分页）、PUBLISH 与 DeleteExpired 的比较删除脚本的 RESP2 服务，
+// 每批请求（读缓冲排空时）回复前等待 rtt，模拟网络往返
+type fakeRedis struct {
+	addr string
+	rtt  time.Duration
+
+	// beforeEval 执行脚本前调用，用于模拟在读取与删除之间发生的写入
+	beforeEval func()
+
+	mu       sync.Mutex
+	data     map[string]string
+	commands map[string]int
+}
+
+func newFakeRedis(t testing.TB, rtt time.Duration) *fakeRedis {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+
+	f := &fakeRedis{addr: lis.Addr().String(), rtt: rtt, data: make(map[string]string),
+		commands: make(map[string]int)}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) set(key, value string) {
+	f.mu.Lock()
+	f.data[key] = value
+	f.mu.Unlock()
+}
+
+// calls 返回收到的某个命令的次数
+func (f *fakeRedis) calls(cmd string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.commands[cmd]
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if strings.EqualFold(args[0], "EVAL") && f.beforeEval != nil {
+			f.beforeEval()
+		}
+		f.handle(w, args)
+		if r.Buffered() == 0 {
+			time.Sleep(f.rtt)
+			if w.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+func (f *fakeRedis) handle(w *bufio.Writer, args []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands[strings.ToUpper(args[0])]++
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		w.WriteString("+PONG\r\n")
+	case "CLIENT":
+		w.WriteString("+OK\r\n")
+	case "GET":
+		if v, ok := f.data[args[1]]; ok {
+			fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+		} else {
+			w.WriteString("$-1\r\n")
+		}
+	case "SET":
+		f.data[args[1]] = args[2]
+		w.WriteString("+OK\r\n")
+	case "DEL":
+		n := 0
+		for _, k := range args[1:] {
+			if _, ok := f.data[k]; ok {
+				delete(f.data, k)
+				n++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", n)
+	case "PUBLISH":
+		w.WriteString(":0\r\n")
+	case "EVALSHA":
+		// 不缓存脚本，客户端回退到 EVAL
+		w.WriteString("-NOSCRIPT No matching script\r\n")
+	case "EVAL":
+		// 只实现 deleteIfUnchangedScript：EVAL script 1 key value
+		if !strings.Contains(args[1], "== ARGV[1]") || len(args) != 5 {
+			w.WriteString("-ERR unsupported script\r\n")
+			return
+		}
+		if v, ok := f.data[args[3]]; ok && v == args[4] {
+			delete(f.data, args[3])
+			w.WriteString(":1\r\n")
+		} else {
+			w.WriteString(":0\r\n")
+		}
+	case "SCAN":
+		// SCAN cursor MATCH pattern COUNT n：按 key 排序分页，cursor 为下一页的起始下标
+		cursor, _ := strconv.Atoi(args[1])
+		prefix, count := "", 10
+		for i := 2; i+1 < len(args); i += 2 {
+			switch strings.ToUpper(args[i]) {
+			case "MATCH":
+				prefix = strings.TrimSuffix(args[i+1], "*")
+			case "COUNT":
+				count, _ = strconv.Atoi(args[i+1])
+			}
+		}
+		var keys []string
+		for k := range f.data {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		end, next := cursor+count, cursor+count
+		if end >= len(keys) {
+			end, next = len(keys), 0
+		}
+		page := keys[min(cursor, len(keys)):end]
+		nextCursor := strconv.Itoa(next)
+		fmt.Fprintf(w, "*2\r\n$%d\r\n%s\r\n*%d\r\n", len(nextCursor), nextCursor, len(page))
+		for _, k := range page {
+			fmt.Fprintf(w, "$%d\r\n%s\r\n", len(k), k)
+		}
+	default:
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+// readCommand 读取一条 RESP 数组形式的命令
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func newFakeRedisBackend(t testing.TB, f *fakeRedis) *RedisBackend {
+	t.Helper()
+	b, err := NewRedisBackend(&RedisConfig{Addr: f.addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func seedRoutes(f *fakeRedis, n int) {
+	for i := 0; i < n; i++ {
+		color := fmt.Sprintf("color-%03d", i)
+		f.set(redisKeyPrefix+color, fmt.Sprintf(`{"Color":%q,"Address":"http://10.0.0.1","ExpiresAt":%q}`,
+			color, time.Now().Add(time.Hour).Format(time.RFC3339Nano)))
+	}
+}
+
+func TestRedisListSkipsCorruptEntries(t *testing.T) {
+	f := newFakeRedis(t, 0)
+	seedRoutes(f, 3)
+	f.set(redisKeyPrefix+"broken", "{not json")
+	b := newFakeRedisBackend(t, f)
+
+	routes, err := b.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 3 || routes[0].Color != "color-000" {
+		t.Errorf("List = %v, want the 3 valid routes in order", routes)
+	}
+}
+
+func TestRedisGetIgnoresStaleExpiresAt(t *testing.T) {
+	f := newFakeRedis(t, 0)
+	// Redis 没有让 key 过期（如 PERSIST 或时钟偏差），但 ExpiresAt 已经过去
+	f.set(redisKeyPrefix+"blue", fmt.Sprintf(`{"Color":"blue","Address":"http://10.0.0.1","ExpiresAt":%q}`,
+		time.Now().Add(-time.Second).Format(time.RFC3339Nano)))
+	b := newFakeRedisBackend(t, f)
+
+	if _, err := b.Get(context.Background(), "blue"); err != ErrRouteNotFound {
+		t.Errorf("Get = %v, want ErrRouteNotFound for a route past its ExpiresAt", err)
+	}
+}
+
+func TestRedisDeleteExpiredKeepsRenewedRoute(t *testing.T) {
+	f := newFakeRedis(t, 0)
+	key := redisKeyPrefix + "blue"
+	f.set(key, fmt.Sprintf(`{"Color":"blue","Address":"http://10.0.0.1","ExpiresAt":%q}`,
+		time.Now().Add(-time.Second).Format(time.RFC3339Nano)))
+	renewed := fmt.Sprintf(`{"Color":"blue","Address":"http://10.0.0.1","ExpiresAt":%q}`,
+		time.Now().Add(time.Hour).Format(time.RFC3339Nano))
+	// 心跳在 DeleteExpired 读取之后、删除之前续期
+	var evals int
+	f.beforeEval = func() {
+		evals++
+		f.set(key, renewed)
+	}
+	b := newFakeRedisBackend(t, f)
+
+	if _, err := b.DeleteExpired(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if evals == 0 {
+		t.Fatal("DeleteExpired did not run the compare-and-delete script")
+	}
+	if _, err := b.Get(context.Background(), "blue"); err != nil {
+		t.Errorf("renewed route was deleted: %v", err)
+	}
+}
+
+func TestRedisDeleteExpiredRemovesUnchangedRoute(t *testing.T) {
+	f := newFakeRedis(t, 0)
+	f.set(redisKeyPrefix+"blue", fmt.Sprintf(`{"Color":"blue","Address":"http://10.0.0.1","ExpiresAt":%q}`,
+		time.Now().Add(-time.Second).Format(time.RFC3339Nano)))
+	seedRoutes(f, 1)
+	b := newFakeRedisBackend(t, f)
+
+	routes, err := b.DeleteExpired(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 || routes[0].Color != "color-000" {
+		t.Errorf("DeleteExpired = %v, want only the live route", routes)
+	}
+	f.mu.Lock()
+	_, ok := f.data[redisKeyPrefix+"blue"]
+	f.mu.Unlock()
+	if ok {
+		t.Error("expired route still stored")
+	}
+}
+
+func TestRedisListScansAllKeys(t *testing.T) {
+	f := newFakeRedis(t, 0)
+	seedRoutes(f, 350)
+	f.set("other:key", "ignored")
+	b := newFakeRedisBackend(t, f)
+
+	routes, err := b.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 350 {
+		t.Fatalf("List returned %d routes, want 350", len(routes))
+	}
+	seen := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		seen[r.Color] = true
+	}
+	if len(seen) != 350 {
+		t.Errorf("List returned %d distinct routes, want 350", len(seen))
+	}
+	if f.calls("KEYS") != 0 {
+		t.Error("List used KEYS")
+	}
+	// 350 个 key、每页 redisScanCount 个，需要多轮 SCAN
+	if n := f.calls("SCAN"); n != (350+redisScanCount-1)/redisScanCount {
+		t.Errorf("SCAN called %d times, want %d", n, (350+redisScanCount-1)/redisScanCount)
+	}
+}
+
+func TestRedisDeleteExpiredScansAllKeys(t *testing.T) {
+	f := newFakeRedis(t, 0)
+	seedRoutes(f, 250)
+	for i := 0; i < 50; i++ {
+		f.set(fmt.Sprintf("%sexpired-%02d", redisKeyPrefix, i), fmt.Sprintf(`{"Color":"expired-%02d","ExpiresAt":%q}`,
+			i, time.Now().Add(-time.Second).Format(time.RFC3339Nano)))
+	}
+	b := newFakeRedisBackend(t, f)
+
+	routes, err := b.DeleteExpired(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 250 {
+		t.Errorf("DeleteExpired returned %d live routes, want 250", len(routes))
+	}
+	f.mu.Lock()
+	remaining := len(f.data)
+	f.mu.Unlock()
+	if remaining != 250 {
+		t.Errorf("%d keys remaining, want the 50 expired routes deleted", remaining)
+	}
+	if f.calls("KEYS") != 0 {
+		t.Error("DeleteExpired used KEYS")
+	}
+}
+
+// 每次往返模拟 200µs 的网络延迟：逐 key GET 需要 N 次往返，pipeline 只需要一次
+const benchRTT = 200 * time.Microsecond
+
+func BenchmarkRedisListPerKey(b *testing.B) {
+	f := newFakeRedis(b, benchRTT)
+	seedRoutes(f, 100)
+	rb := newFakeRedisBackend(b, f)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		keys, err := rb.routeKeys(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, key := range keys {
+			if err := rb.client.Get(ctx, key).Err(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkRedisListPipelined(b *testing.B) {
+	f := newFakeRedis(b, benchRTT)
+	seedRoutes(f, 100)
+	rb := newFakeRedisBackend(b, f)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := rb.List(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SnapshotBackend 本地快照包装层
+// 1. 定期把后端 List 的结果写入本地文件
+// 2. 启动时从文件加载上一次的路由表，作为内存覆盖层（overlay）
+// 3. 后端不可达时，Get/List 使用覆盖层兜底
+// 4. 后端第一次成功响应 List 后丢弃覆盖层，此后完全以后端数据为准
+//
+// 注意：覆盖层中的数据可能是陈旧的（快照时间 + 停机时间），
+// 其中的 ExpiresAt 不作为过滤条件，只用于冷启动期间的临时路由。
+// 这是 Backend.Get "不返回过期路由" 约定的唯一例外，且仅在后端不可达时生效。
+type SnapshotBackend struct {
+	Backend
+
+	path string
+
+	mu         sync.RWMutex
+	overlay    map[string]*Route
+	reconciled bool
+}
+
+// NewSnapshotBackend 创建快照包装层，并尝试从 path 加载上次的快照
+func NewSnapshotBackend(inner Backend, path string) (*SnapshotBackend, error) {
+	b := &SnapshotBackend{
+		Backend: inner,
+		path:    path,
+		overlay: make(map[string]*Route),
+	}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// load 读取快照文件，文件不存在视为空快照
+func (b *SnapshotBackend) load() error {
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var routes []*Route
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, route := range routes {
+		b.overlay[route.Key()] = route
+	}
+	return nil
+}
+
+// Save 从后端拉取最新路由表并写入快照文件（先写临时文件再 rename，保证原子性）
+func (b *SnapshotBackend) Save(ctx context.Context) error {
+	routes, err := b.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	b.mu.RLock()
+	reconciled := b.reconciled
+	b.mu.RUnlock()
+	if !reconciled {
+		// 后端仍不可达，List 返回的是覆盖层，无需回写
+		return nil
+	}
+
+	data, err := json.Marshal(routes)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), filepath.Base(b.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path)
+}
+
+func (b *SnapshotBackend) Get(ctx context.Context, color string) (*Route, error) {
+	route, err := b.Backend.Get(ctx, color)
+	if err == nil || errors.Is(err, ErrRouteNotFound) {
+		// 后端给出了明确结果，以后端为准
+		return route, err
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if cached, ok := b.overlay[color]; ok {
+		r := *cached
+		return &r, nil
+	}
+	return nil, err
+}
+
+func (b *SnapshotBackend) List(ctx context.Context) ([]*Route, error) {
+	routes, err := b.Backend.List(ctx)
+	if err == nil {
+		b.reconcile()
+		return routes, nil
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.overlay) == 0 {
+		return nil, err
+	}
+	routes = make([]*Route, 0, len(b.overlay))
+	for _, cached := range b.overlay {
+		r := *cached
+		routes = append(routes, &r)
+	}
+	SortRoutes(routes)
+	return routes, nil
+}
+
+// ListByOwner 优先使用内部后端的 owner 查询，后端不可达时过滤覆盖层
+func (b *SnapshotBackend) ListByOwner(ctx context.Context, owner string) ([]*Route, error) {
+	routes, err := b.Backend.ListByOwner(ctx, owner)
+	if err == nil {
+		return routes, nil
+	}
+	routes, err = b.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return FilterByOwner(routes, owner), nil
+}
+
+// DeleteWithToken 透传给内部后端；内部后端不支持时退化为先读后删
+func (b *SnapshotBackend) DeleteWithToken(ctx context.Context, color, token string) error {
+	if td, ok := b.Backend.(TokenDeleter); ok {
+		return td.DeleteWithToken(ctx, color, token)
+	}
+	route, err := b.Backend.Get(ctx, color)
+	if err != nil {
+		return err
+	}
+	if route.Token != token {
+		return ErrTokenMismatch
+	}
+	return b.Backend.Delete(ctx, color)
+}
+
+// RegisterExclusive 透传给内部后端；内部后端不支持时退化为先读后写
+func (b *SnapshotBackend) RegisterExclusive(ctx context.Context, route *Route, ttl time.Duration) error {
+	if er, ok := b.Backend.(ExclusiveRegisterer); ok {
+		return er.RegisterExclusive(ctx, route, ttl)
+	}
+	existing, err := b.Backend.Get(ctx, route.Key())
+	switch {
+	case err == nil && existing.Token != route.Token:
+		return ErrRouteConflict
+	case err != nil && !errors.Is(err, ErrRouteNotFound):
+		return err
+	}
+	return b.Backend.Register(ctx, route, ttl)
+}
+
+// Watch 透传给内部后端；内部后端不支持时返回 ErrWatchUnsupported
+func (b *SnapshotBackend) Watch(ctx context.Context) (<-chan RouteEvent, error) {
+	if w, ok := b.Backend.(Watcher); ok {
+		return w.Watch(ctx)
+	}
+	return nil, ErrWatchUnsupported
+}
+
+// Ping 检查内部后端；后端不可用时 List 会返回快照，不能以 List 代替
+func (b *SnapshotBackend) Ping(ctx context.Context) error {
+	return b.Backend.Ping(ctx)
+}
+
+// reconcile 后端已恢复，丢弃覆盖层
+func (b *SnapshotBackend) reconcile() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.reconciled {
+		b.reconciled = true
+		b.overlay = make(map[string]*Route)
+	}
+}
@@ -0,0 +1,147 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+var errBackendDown = errors.New("backend unavailable")
+
+// outageBackend 在 down 为 true 时 Get/List 返回连接错误，模拟不可达的后端
+type outageBackend struct {
+	Backend
+	down atomic.Bool
+}
+
+func (b *outageBackend) Get(ctx context.Context, color string) (*Route, error) {
+	if b.down.Load() {
+		return nil, errBackendDown
+	}
+	return b.Backend.Get(ctx, color)
+}
+
+func (b *outageBackend) List(ctx context.Context) ([]*Route, error) {
+	if b.down.Load() {
+		return nil, errBackendDown
+	}
+	return b.Backend.List(ctx)
+}
+
+func newOutageBackend(t *testing.T, routes ...*Route) *outageBackend {
+	t.Helper()
+	mem := NewMemoryBackend()
+	t.Cleanup(func() { mem.Close() })
+	registerRoutes(t, mem, routes...)
+	return &outageBackend{Backend: mem}
+}
+
+// writeSnapshot 经 Save 把 routes 写入临时目录下的快照文件，返回文件路径
+func writeSnapshot(t *testing.T, routes ...*Route) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "routes.json")
+	sb, err := NewSnapshotBackend(newOutageBackend(t, routes...), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.Save(context.Background()); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return path
+}
+
+func TestSnapshotSaveDumpsRoutes(t *testing.T) {
+	path := writeSnapshot(t,
+		&Route{Color: "blue", Address: "http://10.0.0.1", Token: "t"},
+		&Route{Color: "green", Address: "http://10.0.0.2", Token: "t"})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var routes []*Route
+	if err := json.Unmarshal(data, &routes); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 || routes[0].Color != "blue" || routes[1].Color != "green" {
+		t.Errorf("snapshot = %+v, want blue and green", routes)
+	}
+	if leftovers, _ := filepath.Glob(path + ".tmp*"); len(leftovers) != 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+}
+
+func TestSnapshotSeedsOverlayWhileBackendDown(t *testing.T) {
+	path := writeSnapshot(t, &Route{Color: "blue", Address: "http://10.0.0.1", Token: "t"})
+	before, _ := os.ReadFile(path)
+
+	inner := newOutageBackend(t)
+	inner.down.Store(true)
+	sb, err := NewSnapshotBackend(inner, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if route, err := sb.Get(ctx, "blue"); err != nil || route.Address != "http://10.0.0.1" {
+		t.Errorf("Get(blue) = %+v, %v, want the snapshot route", route, err)
+	}
+	if _, err := sb.Get(ctx, "green"); !errors.Is(err, errBackendDown) {
+		t.Errorf("Get(green) err = %v, want the backend error for a color not in the snapshot", err)
+	}
+	if routes, err := sb.List(ctx); err != nil || len(routes) != 1 || routes[0].Color != "blue" {
+		t.Errorf("List = %+v, %v, want the snapshot routes", routes, err)
+	}
+
+	// 后端不可达时不回写快照
+	if err := sb.Save(ctx); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Errorf("snapshot rewritten while the backend was down: %s", after)
+	}
+}
+
+func TestSnapshotReconcilesWhenBackendResponds(t *testing.T) {
+	path := writeSnapshot(t, &Route{Color: "blue", Address: "http://10.0.0.1", Token: "t"})
+
+	inner := newOutageBackend(t, &Route{Color: "green", Address: "http://10.0.0.2", Token: "t"})
+	inner.down.Store(true)
+	sb, err := NewSnapshotBackend(inner, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// 后端恢复但尚未 List：后端给出明确的 not found，以后端为准
+	inner.down.Store(false)
+	if _, err := sb.Get(ctx, "blue"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Get(blue) with backend up err = %v, want ErrRouteNotFound", err)
+	}
+
+	if routes, err := sb.List(ctx); err != nil || len(routes) != 1 || routes[0].Color != "green" {
+		t.Fatalf("List = %+v, %v, want the backend routes", routes, err)
+	}
+	if err := sb.Save(ctx); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// 覆盖层已丢弃：后端再次不可达时不再返回陈旧的快照路由
+	inner.down.Store(true)
+	if _, err := sb.Get(ctx, "blue"); !errors.Is(err, errBackendDown) {
+		t.Errorf("Get(blue) after reconcile err = %v, want the backend error", err)
+	}
+
+	// 新的快照以后端数据为准，下次启动加载 green
+	restarted, err := NewSnapshotBackend(inner, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route, err := restarted.Get(ctx, "green"); err != nil || route.Address != "http://10.0.0.2" {
+		t.Errorf("Get(green) from the new snapshot = %+v, %v", route, err)
+	}
+}
@@ -0,0 +1,280 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQL 方言，决定占位符与建表语句
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+)
+
+const defaultSQLTable = "colorproxy_routes"
+
+// sqlIdentifier 表名只允许字母、数字与下划线，避免拼接 SQL 时注入
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLBackend 关系型数据库后端：路由持久化在表中，过期时间由 DeleteExpired 清理
+// 只依赖 database/sql，驱动由调用方引入；MySQL 的 DSN 需要 parseTime=true
+type SQLBackend struct {
+	db      *sql.DB
+	dialect string
+	table   string
+}
+
+type SQLConfig struct {
+	Dialect string // DialectPostgres 或 DialectMySQL
+	Table   string // 默认 colorproxy_routes
+}
+
+func NewSQLBackend(db *sql.DB, cfg *SQLConfig) (*SQLBackend, error) {
+	if cfg.Table == "" {
+		cfg.Table = defaultSQLTable
+	}
+	if cfg.Dialect != DialectPostgres && cfg.Dialect != DialectMySQL {
+		return nil, fmt.Errorf("unsupported sql dialect %q", cfg.Dialect)
+	}
+	if !sqlIdentifier.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("invalid sql table name %q", cfg.Table)
+	}
+
+	// 测试连接
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("sql connection failed: %w", err)
+	}
+
+	return &SQLBackend{db: db, dialect: cfg.Dialect, table: cfg.Table}, nil
+}
+
+// Migrate 创建路由表（已存在时不做处理）
+// route_key 为路由的存储 key（见 RouteKey），endpoints 与 labels 以 JSON 保存
+//
+// 迁移说明：旧版创建的表没有 labels 列，升级前需执行
+// ALTER TABLE <table> ADD COLUMN labels TEXT NOT NULL DEFAULT '{}'（MySQL 的 TEXT 列不支持默认值，去掉 DEFAULT 即可）
+func (b *SQLBackend) Migrate(ctx context.Context) error {
+	timeType := "TIMESTAMPTZ"
+	if b.dialect == DialectMySQL {
+		timeType = "DATETIME(6)"
+	}
+	_, err := b.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+b.table+` (
+	route_key VARCHAR(255) NOT NULL PRIMARY KEY,
+	color VARCHAR(255) NOT NULL,
+	version VARCHAR(255) NOT NULL,
+	address TEXT NOT NULL,
+	endpoints TEXT NOT NULL,
+	owner VARCHAR(255) NOT NULL,
+	token VARCHAR(255) NOT NULL,
+	ttl_ms BIGINT NOT NULL,
+	expires_at `+timeType+` NOT NULL,
+	labels TEXT NOT NULL
+)`)
+	return err
+}
+
+// query 把以 ? 书写的语句转换为当前方言的占位符（Postgres 为 $1, $2...）
+func (b *SQLBackend) query(q string) string {
+	if b.dialect != DialectPostgres {
+		return q
+	}
+	var sb strings.Builder
+	n := 0
+	for _, c := range q {
+		if c == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+const sqlColumns = "color, version, address, endpoints, owner, token, ttl_ms, expires_at, labels"
+
+// Register 在事务中先删除再插入，兼容不同数据库的 upsert 语法
+func (b *SQLBackend) Register(ctx context.Context, route *Route, ttl time.Duration) error {
+	route.Normalize()
+	route.ExpiresAt = time.Now().Add(route.EffectiveTTL(ttl))
+
+	endpoints, err := json.Marshal(route.Endpoints)
+	if err != nil {
+		return err
+	}
+	labels, err := marshalLabels(route.Labels)
+	if err != nil {
+		return err
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, b.query(`DELETE FROM `+b.table+` WHERE route_key = ?`), route.Key()); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		b.query(`INSERT INTO `+b.table+` (route_key, `+sqlColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		route.Key(), route.Color, route.Version, route.Address, string(endpoints),
+		route.Owner, route.Token, route.TTL.Milliseconds(), route.ExpiresAt.UTC(), labels,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (b *SQLBackend) Get(ctx context.Context, color string) (*Route, error) {
+	row := b.db.QueryRowContext(ctx,
+		b.query(`SELECT `+sqlColumns+` FROM `+b.table+` WHERE route_key = ? AND expires_at >= ?`),
+		color, time.Now().UTC())
+	route, err := scanRoute(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRouteNotFound
+	}
+	return route, err
+}
+
+// Heartbeat 校验 address 与 token 后更新 expires_at，续期时长为注册时的 TTL
+func (b *SQLBackend) Heartbeat(ctx context.Context, color, address, token string, ttl time.Duration) error {
+	route, err := b.Get(ctx, color)
+	if err != nil {
+		return err
+	}
+
+	if route.Address != address || route.Token != token {
+		return errors.New("address or token mismatch")
+	}
+
+	res, err := b.db.ExecContext(ctx,
+		b.query(`UPDATE `+b.table+` SET expires_at = ? WHERE route_key = ? AND token = ?`),
+		time.Now().Add(route.EffectiveTTL(ttl)).UTC(), color, token)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		// 期间被删除或被其他 token 重新注册
+		return ErrRouteNotFound
+	}
+	return nil
+}
+
+func (b *SQLBackend) List(ctx context.Context) ([]*Route, error) {
+	return b.listWhere(ctx, `expires_at >= ?`, time.Now().UTC())
+}
+
+// ListByOwner 以 owner 作为查询条件
+func (b *SQLBackend) ListByOwner(ctx context.Context, owner string) ([]*Route, error) {
+	return b.listWhere(ctx, `owner = ? AND expires_at >= ?`, owner, time.Now().UTC())
+}
+
+// listWhere 查询满足条件的路由，按 SortRoutes 排序
+func (b *SQLBackend) listWhere(ctx context.Context, where string, args ...interface{}) ([]*Route, error) {
+	rows, err := b.db.QueryContext(ctx, b.query(`SELECT `+sqlColumns+` FROM `+b.table+` WHERE `+where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var routes []*Route
+	for rows.Next() {
+		route, err := scanRoute(rows)
+		if err != nil {
+			continue
+		}
+		routes = append(routes, route)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	SortRoutes(routes)
+	return routes, nil
+}
+
+func (b *SQLBackend) Delete(ctx context.Context, color string) error {
+	_, err := b.db.ExecContext(ctx, b.query(`DELETE FROM `+b.table+` WHERE route_key = ?`), color)
+	return err
+}
+
+// DeleteWithToken 以 token 作为删除条件，没有删除任何行时再区分路由不存在与 token 不一致
+func (b *SQLBackend) DeleteWithToken(ctx context.Context, color, token string) error {
+	res, err := b.db.ExecContext(ctx,
+		b.query(`DELETE FROM `+b.table+` WHERE route_key = ? AND token = ? AND expires_at >= ?`),
+		color, token, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	if _, err := b.Get(ctx, color); err != nil {
+		return err
+	}
+	return ErrTokenMismatch
+}
+
+func (b *SQLBackend) DeleteExpired(ctx context.Context) ([]*Route, error) {
+	if _, err := b.db.ExecContext(ctx, b.query(`DELETE FROM `+b.table+` WHERE expires_at < ?`), time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return b.List(ctx)
+}
+
+// Ping 检查数据库连接
+func (b *SQLBackend) Ping(ctx context.Context) error {
+	return b.db.PingContext(ctx)
+}
+
+// Close 数据库连接由调用方创建，也由调用方关闭
+func (b *SQLBackend) Close() error {
+	return nil
+}
+
+// rowScanner sql.Row 与 sql.Rows 的公共接口
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRoute(row rowScanner) (*Route, error) {
+	var (
+		route     Route
+		endpoints string
+		labels    string
+		ttlMs     int64
+	)
+	if err := row.Scan(&route.Color, &route.Version, &route.Address, &endpoints,
+		&route.Owner, &route.Token, &ttlMs, &route.ExpiresAt, &labels); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(endpoints), &route.Endpoints); err != nil {
+		return nil, err
+	}
+	if labels != "" && labels != "{}" {
+		if err := json.Unmarshal([]byte(labels), &route.Labels); err != nil {
+			return nil, err
+		}
+	}
+	route.TTL = time.Duration(ttlMs) * time.Millisecond
+	return &route, nil
+}
+
+// marshalLabels 标签以 JSON 保存，没有标签时为 "{}"
+func marshalLabels(labels map[string]string) (string, error) {
+	if len(labels) == 0 {
+		return "{}", nil
+	}
+	data, err := json.Marshal(labels)
+	return string(data), err
+}
@@ -0,0 +1,315 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSQLQueryPlaceholders(t *testing.T) {
+	for _, tc := range []struct {
+		dialect string
+		in      string
+		want    string
+	}{
+		{DialectPostgres, `DELETE FROM t WHERE route_key = ?`, `DELETE FROM t WHERE route_key = $1`},
+		{DialectPostgres, `UPDATE t SET expires_at = ? WHERE route_key = ? AND token = ?`, `UPDATE t SET expires_at = $1 WHERE route_key = $2 AND token = $3`},
+		{DialectPostgres, `SELECT color FROM t`, `SELECT color FROM t`},
+		{DialectMySQL, `DELETE FROM t WHERE route_key = ?`, `DELETE FROM t WHERE route_key = ?`},
+		{DialectMySQL, `UPDATE t SET expires_at = ? WHERE route_key = ? AND token = ?`, `UPDATE t SET expires_at = ? WHERE route_key = ? AND token = ?`},
+	} {
+		b := &SQLBackend{dialect: tc.dialect}
+		if got := b.query(tc.in); got != tc.want {
+			t.Errorf("%s query(%q) = %q, want %q", tc.dialect, tc.in, got, tc.want)
+		}
+	}
+}
+
+// fakeSQL 只支持 SQLBackend 所用语句形式（DELETE/INSERT/SELECT/UPDATE，条件为 AND 连接的 col op ?）的内存 database/sql 驱动，
+// 按 dialect 校验占位符：Postgres 只接受 $1..$n，MySQL 只接受 ?
+type fakeSQL struct {
+	dialect string
+
+	mu   sync.Mutex
+	rows map[string]map[string]driver.Value // route_key -> 列 -> 值
+}
+
+var (
+	postgresPlaceholder = regexp.MustCompile(`\$(\d+)`)
+	sqlInsert           = regexp.MustCompile(`^INSERT INTO \w+ \(([^)]*)\) VALUES \(([^)]*)\)$`)
+	sqlSelect           = regexp.MustCompile(`^SELECT (.+) FROM \w+ WHERE (.+)$`)
+	sqlDelete           = regexp.MustCompile(`^DELETE FROM \w+ WHERE (.+)$`)
+	sqlUpdate           = regexp.MustCompile(`^UPDATE \w+ SET (\w+) = \? WHERE (.+)$`)
+)
+
+func newFakeSQLBackend(t *testing.T, dialect string) (*SQLBackend, *fakeSQL) {
+	t.Helper()
+	f := &fakeSQL{dialect: dialect, rows: make(map[string]map[string]driver.Value)}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { db.Close() })
+	b, err := NewSQLBackend(db, &SQLConfig{Dialect: dialect})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, f
+}
+
+func (f *fakeSQL) Connect(context.Context) (driver.Conn, error) { return fakeSQLConn{f}, nil }
+func (f *fakeSQL) Driver() driver.Driver                        { return nil }
+
+// normalize 校验占位符风格与参数个数，并统一转换为 ?
+func (f *fakeSQL) normalize(q string, args []driver.NamedValue) (string, error) {
+	if f.dialect == DialectPostgres {
+		if strings.Contains(q, "?") {
+			return "", fmt.Errorf("postgres statement uses ?: %s", q)
+		}
+		n, ordered := 0, true
+		q = postgresPlaceholder.ReplaceAllStringFunc(q, func(m string) string {
+			n++
+			ordered = ordered && m == "$"+strconv.Itoa(n)
+			return "?"
+		})
+		if !ordered || n != len(args) {
+			return "", fmt.Errorf("postgres placeholders are not $1..$%d: %s", len(args), q)
+		}
+	} else if strings.Contains(q, "$") || strings.Count(q, "?") != len(args) {
+		return "", fmt.Errorf("mysql statement must use %d ? placeholders: %s", len(args), q)
+	}
+	return strings.Join(strings.Fields(q), " "), nil
+}
+
+// match 判断一行是否满足 "col op ? AND ..." 条件，依次消耗参数
+func match(row map[string]driver.Value, where string, args []driver.NamedValue) (bool, error) {
+	conds := strings.Split(where, " AND ")
+	if len(conds) != len(args) {
+		return false, fmt.Errorf("unsupported condition %q", where)
+	}
+	for i, cond := range conds {
+		parts := strings.Fields(cond)
+		if len(parts) != 3 || parts[2] != "?" {
+			return false, fmt.Errorf("unsupported condition %q", cond)
+		}
+		ok, err := compare(row[parts[0]], parts[1], args[i].Value)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func compare(v driver.Value, op string, arg driver.Value) (bool, error) {
+	if t, ok := v.(time.Time); ok {
+		at, ok := arg.(time.Time)
+		if !ok {
+			return false, fmt.Errorf("comparing time with %T", arg)
+		}
+		switch op {
+		case ">=":
+			return !t.Before(at), nil
+		case "<":
+			return t.Before(at), nil
+		}
+	} else if op == "=" {
+		return v == arg, nil
+	}
+	return false, fmt.Errorf("unsupported operator %q for %T", op, v)
+}
+
+type fakeSQLConn struct{ f *fakeSQL }
+
+func (c fakeSQLConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c fakeSQLConn) Close() error              { return nil }
+func (c fakeSQLConn) Begin() (driver.Tx, error) { return c, nil }
+
+// 事务内的语句直接生效，Register 的删除与插入总是一起提交
+func (c fakeSQLConn) Commit() error   { return nil }
+func (c fakeSQLConn) Rollback() error { return nil }
+
+func (c fakeSQLConn) ExecContext(_ context.Context, q string, args []driver.NamedValue) (driver.Result, error) {
+	f := c.f
+	q, err := f.normalize(q, args)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var affected int64
+	switch {
+	case sqlInsert.MatchString(q):
+		cols := strings.Split(sqlInsert.FindStringSubmatch(q)[1], ", ")
+		row := make(map[string]driver.Value, len(cols))
+		for i, col := range cols {
+			row[col] = args[i].Value
+		}
+		f.rows[row["route_key"].(string)] = row
+		affected = 1
+	case sqlDelete.MatchString(q):
+		where := sqlDelete.FindStringSubmatch(q)[1]
+		for key, row := range f.rows {
+			ok, err := match(row, where, args)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				delete(f.rows, key)
+				affected++
+			}
+		}
+	case sqlUpdate.MatchString(q):
+		m := sqlUpdate.FindStringSubmatch(q)
+		for _, row := range f.rows {
+			ok, err := match(row, m[2], args[1:])
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				row[m[1]] = args[0].Value
+				affected++
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported statement: %s", q)
+	}
+	return driver.RowsAffected(affected), nil
+}
+
+func (c fakeSQLConn) QueryContext(_ context.Context, q string, args []driver.NamedValue) (driver.Rows, error) {
+	f := c.f
+	q, err := f.normalize(q, args)
+	if err != nil {
+		return nil, err
+	}
+	m := sqlSelect.FindStringSubmatch(q)
+	if m == nil {
+		return nil, fmt.Errorf("unsupported statement: %s", q)
+	}
+	cols := strings.Split(m[1], ", ")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := &fakeSQLRows{cols: cols}
+	for _, row := range f.rows {
+		ok, err := match(row, m[2], args)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			values := make([]driver.Value, len(cols))
+			for i, col := range cols {
+				values[i] = row[col]
+			}
+			rows.values = append(rows.values, values)
+		}
+	}
+	return rows, nil
+}
+
+type fakeSQLRows struct {
+	cols   []string
+	values [][]driver.Value
+}
+
+func (r *fakeSQLRows) Columns() []string { return r.cols }
+func (r *fakeSQLRows) Close() error      { return nil }
+
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestSQLBackendDialects(t *testing.T) {
+	for _, dialect := range []string{DialectPostgres, DialectMySQL} {
+		t.Run(dialect, func(t *testing.T) {
+			ctx := context.Background()
+			b, _ := newFakeSQLBackend(t, dialect)
+
+			route := &Route{Color: "blue", Version: "v2", Address: "http://10.0.0.1:8080", Owner: "team-a", Token: "t1",
+				Endpoints: []Endpoint{{Address: "http://10.0.0.1:8080", Weight: 2}, {Address: "http://10.0.0.2:8080", Weight: 1}},
+				Labels:    map[string]string{"region": "eu"}}
+			if err := b.Register(ctx, route, time.Minute); err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+
+			got, err := b.Get(ctx, "blue:v2")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if got.Owner != "team-a" || got.Token != "t1" || len(got.Endpoints) != 2 || got.Labels["region"] != "eu" {
+				t.Errorf("Get = %+v, want the registered route", got)
+			}
+			if _, err := b.Get(ctx, "green"); !errors.Is(err, ErrRouteNotFound) {
+				t.Errorf("Get missing: err = %v, want ErrRouteNotFound", err)
+			}
+
+			before := got.ExpiresAt
+			time.Sleep(2 * time.Millisecond)
+			if err := b.Heartbeat(ctx, "blue:v2", route.Address, "t1", time.Minute); err != nil {
+				t.Fatalf("Heartbeat: %v", err)
+			}
+			if got, _ := b.Get(ctx, "blue:v2"); !got.ExpiresAt.After(before) {
+				t.Errorf("Heartbeat did not extend expires_at: %v -> %v", before, got.ExpiresAt)
+			}
+			if err := b.Heartbeat(ctx, "blue:v2", route.Address, "other", time.Minute); err == nil {
+				t.Error("Heartbeat with a foreign token succeeded")
+			}
+
+			if err := b.DeleteWithToken(ctx, "blue:v2", "other"); !errors.Is(err, ErrTokenMismatch) {
+				t.Errorf("DeleteWithToken mismatch: err = %v, want ErrTokenMismatch", err)
+			}
+			if routes, err := b.ListByOwner(ctx, "team-a"); err != nil || len(routes) != 1 {
+				t.Errorf("ListByOwner = %v, %v, want the blue route", routes, err)
+			}
+		})
+	}
+}
+
+func TestSQLBackendDeleteExpired(t *testing.T) {
+	for _, dialect := range []string{DialectPostgres, DialectMySQL} {
+		t.Run(dialect, func(t *testing.T) {
+			ctx := context.Background()
+			b, f := newFakeSQLBackend(t, dialect)
+
+			if err := b.Register(ctx, &Route{Color: "blue", Address: "http://10.0.0.1:8080", Token: "t"}, time.Millisecond); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.Register(ctx, &Route{Color: "green", Address: "http://10.0.0.2:8080", Token: "t"}, time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			routes, err := b.DeleteExpired(ctx)
+			if err != nil {
+				t.Fatalf("DeleteExpired: %v", err)
+			}
+			if len(routes) != 1 || routes[0].Color != "green" {
+				t.Errorf("DeleteExpired returned %+v, want only green", routes)
+			}
+			f.mu.Lock()
+			_, kept := f.rows["blue"]
+			f.mu.Unlock()
+			if kept {
+				t.Error("expired row still stored")
+			}
+			if err := b.Heartbeat(ctx, "blue", "http://10.0.0.1:8080", "t", time.Minute); !errors.Is(err, ErrRouteNotFound) {
+				t.Errorf("Heartbeat after expiry: err = %v, want ErrRouteNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package strategy
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CanaryStrategy 金丝雀分流：忽略请求的 color，按百分比在 stable / canary 两个路由间随机选择
+// 选中 canary 但 canary 不存在（或没有可用地址）时回退到 stable；
+// stable 没有可用地址时返回 stable 的错误，不会把全部流量转给 canary
+// 开启 SetExpiryDecay 后 canary 的流量比例随其路由的剩余 TTL 衰减，stable 的比例不衰减
+type CanaryStrategy struct {
+	expiryDecay
+	inner   Strategy
+	stable  string
+	canary  string
+	percent atomic.Int64
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewCanaryStrategy 创建金丝雀分流策略，percent 为分到 canary 的流量百分比（0-100）
+// stable / canary 路由本身由 inner 解析，因此仍可使用轮询、加权等策略选择地址
+func NewCanaryStrategy(inner Strategy, stable, canary string, percent int) *CanaryStrategy {
+	s := &CanaryStrategy{
+		inner:  inner,
+		stable: stable,
+		canary: canary,
+		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	s.SetPercent(percent)
+	return s
+}
+
+// SetPercent 运行时调整分到 canary 的流量百分比，超出 0-100 时截断（如自动回滚时设为 0）
+func (s *CanaryStrategy) SetPercent(percent int) {
+	s.percent.Store(int64(min(max(percent, 0), 100)))
+}
+
+// Percent 返回当前分到 canary 的流量百分比
+func (s *CanaryStrategy) Percent() int {
+	return int(s.percent.Load())
+}
+
+// SetHealthChecker 透传给内部策略
+func (s *CanaryStrategy) SetHealthChecker(h HealthChecker) {
+	if ha, ok := s.inner.(HealthAware); ok {
+		ha.SetHealthChecker(h)
+	}
+}
+
+func (s *CanaryStrategy) Select(ctx context.Context, req RoutingRequest) (string, error) {
+	if s.pickCanary(ctx) {
+		if target, err := s.inner.Select(ctx, req.WithColor(s.canary)); err == nil {
+			return target, nil
+		}
+		// canary 未注册，回退到 stable
+		return s.inner.Select(ctx, req.WithColor(s.stable))
+	}
+
+	return s.inner.Select(ctx, req.WithColor(s.stable))
+}
+
+func (s *CanaryStrategy) pickCanary(ctx context.Context) bool {
+	base := s.percent.Load()
+	if base == 0 {
+		return false
+	}
+	percent := float64(base) * s.factor(ctx, s.canary, time.Now())
+	if percent >= 100 {
+		return true
+	}
+	s.mu.Lock()
+	n := s.rnd.Float64() * 100
+	s.mu.Unlock()
+	return n < percent
+}
@@ -0,0 +1,53 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// staticStrategy 按 color 返回固定目标，未配置的 color 返回 ErrRouteNotFound
+type staticStrategy map[string]string
+
+func (s staticStrategy) Select(_ context.Context, req RoutingRequest) (string, error) {
+	if target, ok := s[req.Color]; ok {
+		return target, nil
+	}
+	return "", backend.ErrRouteNotFound
+}
+
+func TestCanaryFallsBackToStable(t *testing.T) {
+	s := NewCanaryStrategy(staticStrategy{"stable": "http://stable"}, "stable", "canary", 100)
+	target, err := s.Select(context.Background(), ForColor("any"))
+	if err != nil || target != "http://stable" {
+		t.Errorf("Select = %q, %v, want stable when canary is missing", target, err)
+	}
+}
+
+func TestCanaryNotPromotedWhenStableMissing(t *testing.T) {
+	s := NewCanaryStrategy(staticStrategy{"canary": "http://canary"}, "stable", "canary", 0)
+	if target, err := s.Select(context.Background(), ForColor("any")); !errors.Is(err, backend.ErrRouteNotFound) {
+		t.Errorf("Select = %q, %v, want ErrRouteNotFound instead of promoting the canary", target, err)
+	}
+}
+
+func TestCanarySplit(t *testing.T) {
+	s := NewCanaryStrategy(staticStrategy{"stable": "http://stable", "canary": "http://canary"}, "stable", "canary", 10)
+	const n = 10000
+	canary := 0
+	for i := 0; i < n; i++ {
+		target, err := s.Select(context.Background(), ForColor("any"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target == "http://canary" {
+			canary++
+		}
+	}
+	// 期望 1000，标准差约 30
+	if canary < 850 || canary > 1150 {
+		t.Errorf("canary share = %d/%d, want about 10%%", canary, n)
+	}
+}
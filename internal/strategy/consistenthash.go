@@ -0,0 +1,134 @@
+package strategy
+
+import (
+	"context"
+	"hash/crc32"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// hashReplicas 每个地址在哈希环上的虚拟节点数，越大分布越均匀
+const hashReplicas = 160
+
+// HashKeyFunc 从请求中提取一致性哈希的 key（例如 session cookie 或 X-Session-Id header）
+type HashKeyFunc func(r *http.Request) string
+
+// ConsistentHashStrategy 一致性哈希策略：同一个 key 总是落在 color 的同一个地址上（会话保持）
+// key 由 keyFunc 从请求中提取，为空时使用客户端 IP；地址增减时只有少部分 key 会重新映射
+//
+// 权重为 0 的地址处于排空状态，不参与哈希环，新的 key 不会落在该地址上；
+// 只有 keyFunc 提取到的 key 恰好是该地址（如记录了上游地址的 sticky cookie）时，请求才显式指向排空地址
+type ConsistentHashStrategy struct {
+	healthFilter
+	backend backend.Backend
+	keyFunc HashKeyFunc
+
+	mu    sync.Mutex
+	rings map[string]*hashRing
+}
+
+// hashRing 一个 color 的哈希环，signature 为构建时的地址集合，地址变化时重建
+type hashRing struct {
+	signature string
+	hashes    []uint32
+	owners    map[uint32]string
+}
+
+func NewConsistentHashStrategy(backend backend.Backend, keyFunc HashKeyFunc) *ConsistentHashStrategy {
+	return &ConsistentHashStrategy{
+		backend: backend,
+		keyFunc: keyFunc,
+		rings:   make(map[string]*hashRing),
+	}
+}
+
+func (s *ConsistentHashStrategy) Select(ctx context.Context, req RoutingRequest) (string, error) {
+	route, err := s.backend.Get(ctx, req.Color)
+	if err != nil {
+		return "", err
+	}
+
+	key, sticky := s.key(req)
+	if sticky {
+		// key 显式指定了 color 的某个地址（包括排空中的地址）时直接使用
+		for _, ep := range s.members(req.Color, route.EndpointList()) {
+			if ep.Address == key {
+				return ep.Address, nil
+			}
+		}
+	}
+
+	endpoints := s.routable(req.Color, route.EndpointList())
+	if len(endpoints) == 0 {
+		return "", ErrNoEndpoint
+	}
+
+	ring := s.ring(req.Color, endpoints)
+	return ring.lookup(key), nil
+}
+
+// key 提取哈希 key：keyFunc 只作用于 HTTP 请求，其余情况使用客户端 IP；都没有时为空，所有调用落在同一地址
+// sticky 表示 key 来自 keyFunc（显式的会话 key）
+func (s *ConsistentHashStrategy) key(req RoutingRequest) (key string, sticky bool) {
+	if s.keyFunc != nil && req.HTTP != nil {
+		if key := s.keyFunc(req.HTTP); key != "" {
+			return key, true
+		}
+	}
+	return req.ClientIP(), false
+}
+
+// ring 返回 color 当前可路由地址集合对应的哈希环，地址集合未变化时复用
+func (s *ConsistentHashStrategy) ring(color string, endpoints []backend.Endpoint) *hashRing {
+	addrs := make([]string, len(endpoints))
+	for i, ep := range endpoints {
+		addrs[i] = ep.Address
+	}
+	sort.Strings(addrs)
+	signature := strings.Join(addrs, ",")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ring, ok := s.rings[color]; ok && ring.signature == signature {
+		return ring
+	}
+	ring := newHashRing(signature, addrs)
+	s.rings[color] = ring
+	return ring
+}
+
+func newHashRing(signature string, addrs []string) *hashRing {
+	ring := &hashRing{
+		signature: signature,
+		hashes:    make([]uint32, 0, len(addrs)*hashReplicas),
+		owners:    make(map[uint32]string, len(addrs)*hashReplicas),
+	}
+	for _, addr := range addrs {
+		for i := 0; i < hashReplicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(addr + "#" + strconv.Itoa(i)))
+			// 极少数的哈希冲突保留先写入的地址，addrs 已排序，结果是确定的
+			if _, ok := ring.owners[h]; ok {
+				continue
+			}
+			ring.owners[h] = addr
+			ring.hashes = append(ring.hashes, h)
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool { return ring.hashes[i] < ring.hashes[j] })
+	return ring
+}
+
+// lookup 顺时针找到第一个不小于 key 哈希值的虚拟节点
+func (r *hashRing) lookup(key string) string {
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[r.hashes[i]]
+}
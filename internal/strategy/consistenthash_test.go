@@ -0,0 +1,89 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+func sessionCookie(r *http.Request) string {
+	if c, err := r.Cookie("session"); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+func registerEndpoints(t *testing.T, b backend.Backend, color string, endpoints ...backend.Endpoint) {
+	t.Helper()
+	if err := b.Register(context.Background(), &backend.Route{Color: color, Token: "t", Endpoints: endpoints}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func selectWithSession(t *testing.T, s Strategy, session, remoteAddr string) string {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	if session != "" {
+		r.AddCookie(&http.Cookie{Name: "session", Value: session})
+	}
+	target, err := s.Select(context.Background(), FromHTTP("blue", r))
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	return target
+}
+
+func TestConsistentHashDrainKeepsStickySessions(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	defer b.Close()
+	s := NewConsistentHashStrategy(b, sessionCookie)
+
+	registerEndpoints(t, b, "blue",
+		backend.Endpoint{Address: "http://a", Weight: 1},
+		backend.Endpoint{Address: "http://b", Weight: 1},
+		backend.Endpoint{Address: "http://c", Weight: 0},
+	)
+
+	// sticky cookie 记录了排空中的地址：仍落在 c 上
+	if target := selectWithSession(t, s, "http://c", "10.0.0.1:1234"); target != "http://c" {
+		t.Errorf("sticky session pinned to c moved to %s after drain", target)
+	}
+
+	// 新的会话 key 与没有会话 cookie 的请求都不会落在 c 上
+	for i := 0; i < 1000; i++ {
+		if target := selectWithSession(t, s, fmt.Sprintf("session-%d", i), "10.0.0.1:1234"); target == "http://c" {
+			t.Fatalf("new session %d hashed to the draining endpoint", i)
+		}
+	}
+	for i := 0; i < 500; i++ {
+		if target := selectWithSession(t, s, "", fmt.Sprintf("10.0.%d.%d:1234", i/250, i%250)); target == "http://c" {
+			t.Fatalf("fresh request from client %d routed to the draining endpoint", i)
+		}
+	}
+}
+
+func TestConsistentHashAllDrained(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	defer b.Close()
+	s := NewConsistentHashStrategy(b, sessionCookie)
+	registerEndpoints(t, b, "blue", backend.Endpoint{Address: "http://a", Weight: 0})
+
+	if target := selectWithSession(t, s, "http://a", "10.0.0.1:1234"); target != "http://a" {
+		t.Errorf("sticky session resolved to %q, want the draining endpoint", target)
+	}
+	for _, session := range []string{"session-1", ""} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if session != "" {
+			r.AddCookie(&http.Cookie{Name: "session", Value: session})
+		}
+		if _, err := s.Select(context.Background(), FromHTTP("blue", r)); err != ErrNoEndpoint {
+			t.Errorf("request with session %q: err = %v, want ErrNoEndpoint", session, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package strategy
+
+import (
+	"context"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// expiryDecay 路由临近过期时按剩余 TTL 衰减其选择权重：剩余 TTL 低于 threshold 时，
+// 有效权重为 权重 * 剩余 TTL / threshold，停止心跳的路由在过期前逐步失去流量，而不是在 TTL 到期时突然消失
+type expiryDecay struct {
+	backend   backend.Backend
+	threshold time.Duration
+}
+
+// SetExpiryDecay 开启按剩余 TTL 衰减，b 用于读取候选路由的过期时间；threshold <= 0 表示不衰减
+func (d *expiryDecay) SetExpiryDecay(b backend.Backend, threshold time.Duration) {
+	d.backend, d.threshold = b, threshold
+}
+
+// factor 返回路由 key 的权重系数（0~1）；未开启衰减时为 1，路由不存在时为 0
+func (d *expiryDecay) factor(ctx context.Context, key string, now time.Time) float64 {
+	if d.backend == nil || d.threshold <= 0 {
+		return 1
+	}
+	route, err := d.backend.Get(ctx, key)
+	if err != nil {
+		return 0
+	}
+	return decayFactor(route.ExpiresAt, now, d.threshold)
+}
+
+func decayFactor(expiresAt, now time.Time, threshold time.Duration) float64 {
+	if expiresAt.IsZero() {
+		return 1
+	}
+	remaining := expiresAt.Sub(now)
+	switch {
+	case remaining >= threshold:
+		return 1
+	case remaining <= 0:
+		return 0
+	}
+	return float64(remaining) / float64(threshold)
+}
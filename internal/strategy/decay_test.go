@@ -0,0 +1,97 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+func TestDecayFactor(t *testing.T) {
+	now := time.Now()
+	threshold := 10 * time.Second
+	cases := []struct {
+		remaining time.Duration
+		want      float64
+	}{
+		{time.Minute, 1},
+		{threshold, 1},
+		{5 * time.Second, 0.5},
+		{time.Second, 0.1},
+		{-time.Second, 0},
+	}
+	for _, c := range cases {
+		if got := decayFactor(now.Add(c.remaining), now, threshold); got < c.want-1e-9 || got > c.want+1e-9 {
+			t.Errorf("remaining %v: factor = %v, want %v", c.remaining, got, c.want)
+		}
+	}
+	if got := decayFactor(time.Time{}, now, threshold); got != 1 {
+		t.Errorf("no expiry: factor = %v, want 1", got)
+	}
+}
+
+func TestVariantSplitDecaysExpiringRoutes(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	defer b.Close()
+	ctx := context.Background()
+	// 权重相同，剩余 TTL 分别为 1 分钟、15 秒（低于阈值，系数 0.5）与 3 秒（系数 0.1）
+	for _, r := range []struct {
+		variant string
+		ttl     time.Duration
+	}{{"a", time.Minute}, {"b", 15 * time.Second}, {"c", 3 * time.Second}} {
+		route := &backend.Route{Color: "blue" + VariantSeparator + r.variant, Address: "http://" + r.variant, Token: "t", TTL: r.ttl}
+		if err := b.Register(ctx, route, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewVariantSplitStrategy(NewSimpleStrategy(b), map[string]map[string]int{"blue": {"a": 1, "b": 1, "c": 1}})
+	s.SetExpiryDecay(b, 30*time.Second)
+
+	const n = 16000
+	counts := map[string]int{}
+	for i := 0; i < n; i++ {
+		target, err := s.Select(ctx, ForColor("blue"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[target]++
+	}
+
+	// 有效权重 1 : 0.5 : 0.1
+	want := map[string]float64{"http://a": 1 / 1.6, "http://b": 0.5 / 1.6, "http://c": 0.1 / 1.6}
+	for target, share := range want {
+		got := float64(counts[target]) / n
+		if got < share-0.03 || got > share+0.03 {
+			t.Errorf("%s share = %.3f, want about %.3f", target, got, share)
+		}
+	}
+}
+
+func TestCanaryDecayOnlyShiftsToStable(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	defer b.Close()
+	ctx := context.Background()
+	b.Register(ctx, &backend.Route{Color: "stable", Address: "http://stable", Token: "t", TTL: time.Second}, time.Minute)
+	b.Register(ctx, &backend.Route{Color: "canary", Address: "http://canary", Token: "t", TTL: time.Second}, time.Minute)
+
+	// 两者都临近过期：canary 的比例衰减，stable 不衰减
+	s := NewCanaryStrategy(NewSimpleStrategy(b), "stable", "canary", 50)
+	s.SetExpiryDecay(b, time.Minute)
+
+	canary := 0
+	const n = 2000
+	for i := 0; i < n; i++ {
+		target, err := s.Select(ctx, ForColor("any"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target == "http://canary" {
+			canary++
+		}
+	}
+	if canary > n/10 {
+		t.Errorf("canary share = %d/%d, want it to decay towards 0", canary, n)
+	}
+}
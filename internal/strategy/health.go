@@ -0,0 +1,48 @@
+package strategy
+
+import "github.com/asam264/color/internal/backend"
+
+// HealthChecker 端点健康状态查询
+type HealthChecker interface {
+	// Healthy 返回 color 下的 address 是否健康，未探测过的地址视为健康
+	Healthy(color, address string) bool
+}
+
+// HealthAware 可接入健康检查的策略，选择时跳过不健康的地址
+// 内置策略都实现了该接口；SetHealthChecker 需在开始 Select 之前调用
+type HealthAware interface {
+	SetHealthChecker(h HealthChecker)
+}
+
+// healthFilter 内置策略共用的可路由地址过滤
+type healthFilter struct {
+	health HealthChecker
+}
+
+func (f *healthFilter) SetHealthChecker(h HealthChecker) {
+	f.health = h
+}
+
+// routable 过滤出可分配新流量（权重大于 0 且健康）的地址
+func (f *healthFilter) routable(color string, endpoints []backend.Endpoint) []backend.Endpoint {
+	return f.filter(color, endpoints, false)
+}
+
+// members 过滤出健康的地址，包括权重为 0（排空中）的地址，供会话保持等显式指向已有地址的查找使用
+func (f *healthFilter) members(color string, endpoints []backend.Endpoint) []backend.Endpoint {
+	return f.filter(color, endpoints, true)
+}
+
+func (f *healthFilter) filter(color string, endpoints []backend.Endpoint, draining bool) []backend.Endpoint {
+	out := make([]backend.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if ep.Weight < 0 || (ep.Weight == 0 && !draining) {
+			continue
+		}
+		if f.health != nil && !f.health.Healthy(color, ep.Address) {
+			continue
+		}
+		out = append(out, ep)
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package strategy
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PatternRule 把请求 color 映射到已注册 color 的规则，Prefix 与 Regexp 二选一
+// Regexp 规则的 Target 可以引用分组（如 "$1"、"${team}"），按 Regexp.Expand 展开
+type PatternRule struct {
+	Prefix string
+	Regexp *regexp.Regexp
+	Target string
+}
+
+// PatternStrategy 按前缀或正则把请求 color 解析为已注册的 color，再交给内部策略选择地址
+// 1. 前缀规则优先，多个前缀匹配时最长的前缀胜出
+// 2. 没有前缀匹配时按配置顺序尝试正则规则，第一个匹配的胜出
+// 3. 没有规则匹配，或规则解析出的 color 没有可用路由时，按请求 color 精确匹配
+type PatternStrategy struct {
+	inner    Strategy
+	prefixes []PatternRule
+	regexps  []PatternRule
+}
+
+// NewPatternStrategy 创建模式匹配策略；Target 为空、或 Prefix 与 Regexp 都未设置的规则被忽略
+func NewPatternStrategy(inner Strategy, rules []PatternRule) *PatternStrategy {
+	s := &PatternStrategy{inner: inner}
+	for _, rule := range rules {
+		switch {
+		case rule.Target == "":
+		case rule.Prefix != "":
+			s.prefixes = append(s.prefixes, rule)
+		case rule.Regexp != nil:
+			s.regexps = append(s.regexps, rule)
+		}
+	}
+	// 最长前缀在前；长度相同时保持配置顺序
+	sort.SliceStable(s.prefixes, func(i, j int) bool {
+		return len(s.prefixes[i].Prefix) > len(s.prefixes[j].Prefix)
+	})
+	return s
+}
+
+// SetHealthChecker 透传给内部策略
+func (s *PatternStrategy) SetHealthChecker(h HealthChecker) {
+	if ha, ok := s.inner.(HealthAware); ok {
+		ha.SetHealthChecker(h)
+	}
+}
+
+func (s *PatternStrategy) Select(ctx context.Context, req RoutingRequest) (string, error) {
+	color, ok := s.Resolve(req.Color)
+	if !ok || color == req.Color {
+		return s.inner.Select(ctx, req)
+	}
+	if target, err := s.inner.Select(ctx, req.WithColor(color)); err == nil {
+		return target, nil
+	}
+
+	// 解析出的 color 未注册，回退到精确匹配
+	return s.inner.Select(ctx, req)
+}
+
+// Resolve 返回 color 匹配的规则解析出的 color，没有规则匹配时返回 false
+func (s *PatternStrategy) Resolve(color string) (string, bool) {
+	for _, rule := range s.prefixes {
+		if strings.HasPrefix(color, rule.Prefix) {
+			return rule.Target, true
+		}
+	}
+	for _, rule := range s.regexps {
+		if m := rule.Regexp.FindStringSubmatchIndex(color); m != nil {
+			return string(rule.Regexp.ExpandString(nil, rule.Target, color, m)), true
+		}
+	}
+	return "", false
+}
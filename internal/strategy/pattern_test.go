@@ -0,0 +1,73 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+func TestPatternLongestPrefixWins(t *testing.T) {
+	s := NewPatternStrategy(staticStrategy{
+		"team-a":        "http://team-a",
+		"team-a-canary": "http://team-a-canary",
+	}, []PatternRule{
+		{Prefix: "team-a-", Target: "team-a"},
+		{Prefix: "team-a-canary-", Target: "team-a-canary"},
+	})
+
+	for color, want := range map[string]string{
+		"team-a-canary-v3": "http://team-a-canary",
+		"team-a-stable-v1": "http://team-a",
+	} {
+		if target, err := s.Select(context.Background(), ForColor(color)); err != nil || target != want {
+			t.Errorf("Select(%s) = %q, %v, want %s", color, target, err, want)
+		}
+	}
+}
+
+func TestPatternPrefixBeforeRegexp(t *testing.T) {
+	s := NewPatternStrategy(staticStrategy{
+		"team-a":    "http://team-a",
+		"by-regexp": "http://by-regexp",
+		"team-b":    "http://team-b",
+	}, []PatternRule{
+		// 正则规则配置在前，仍在前缀规则之后尝试
+		{Regexp: regexp.MustCompile(`^team-a-.*$`), Target: "by-regexp"},
+		{Prefix: "team-a-", Target: "team-a"},
+		{Regexp: regexp.MustCompile(`^(?P<team>team-[a-z])-`), Target: "${team}"},
+	})
+
+	for color, want := range map[string]string{
+		"team-a-canary-v3": "http://team-a",
+		"team-b-canary-v3": "http://team-b",
+	} {
+		if target, err := s.Select(context.Background(), ForColor(color)); err != nil || target != want {
+			t.Errorf("Select(%s) = %q, %v, want %s", color, target, err, want)
+		}
+	}
+}
+
+func TestPatternFallsBackToExactMatch(t *testing.T) {
+	s := NewPatternStrategy(staticStrategy{
+		"team-a-canary-v3": "http://exact",
+		"plain":            "http://plain",
+	}, []PatternRule{
+		// 解析出的 color 未注册
+		{Prefix: "team-a-", Target: "team-a"},
+	})
+
+	for color, want := range map[string]string{
+		"team-a-canary-v3": "http://exact",
+		"plain":            "http://plain",
+	} {
+		if target, err := s.Select(context.Background(), ForColor(color)); err != nil || target != want {
+			t.Errorf("Select(%s) = %q, %v, want exact match %s", color, target, err, want)
+		}
+	}
+	if _, err := s.Select(context.Background(), ForColor("team-a-unknown")); !errors.Is(err, backend.ErrRouteNotFound) {
+		t.Errorf("Select(team-a-unknown) err = %v, want ErrRouteNotFound", err)
+	}
+}
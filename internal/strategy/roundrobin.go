@@ -0,0 +1,63 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// maxRoundRobinCounters 计数器数量上限，超过后清空重新计数（只影响轮转的起点）
+const maxRoundRobinCounters = 4096
+
+// RoundRobinStrategy 轮询策略：按 color 维护计数器，依次轮转可路由（权重大于 0 且健康）的地址
+// 路由不存在时删除其计数器，计数器总数不超过 maxRoundRobinCounters
+type RoundRobinStrategy struct {
+	healthFilter
+	backend backend.Backend
+
+	mu       sync.Mutex
+	counters map[string]uint64
+}
+
+func NewRoundRobinStrategy(backend backend.Backend) *RoundRobinStrategy {
+	return &RoundRobinStrategy{
+		backend:  backend,
+		counters: make(map[string]uint64),
+	}
+}
+
+func (s *RoundRobinStrategy) Select(ctx context.Context, req RoutingRequest) (string, error) {
+	color := req.Color
+	route, err := s.backend.Get(ctx, color)
+	if errors.Is(err, backend.ErrRouteNotFound) {
+		s.forget(color)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	endpoints := s.routable(color, route.EndpointList())
+	if len(endpoints) == 0 {
+		return "", ErrNoEndpoint
+	}
+
+	s.mu.Lock()
+	n, ok := s.counters[color]
+	if !ok && len(s.counters) >= maxRoundRobinCounters {
+		clear(s.counters)
+	}
+	s.counters[color] = n + 1
+	s.mu.Unlock()
+
+	// 每次按当前地址数取模，地址数量变化时不会越界
+	return endpoints[n%uint64(len(endpoints))].Address, nil
+}
+
+// forget 删除已不存在的路由的计数器
+func (s *RoundRobinStrategy) forget(color string) {
+	s.mu.Lock()
+	delete(s.counters, color)
+	s.mu.Unlock()
+}
@@ -0,0 +1,57 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+func TestRoundRobinRotates(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	defer b.Close()
+	b.Register(context.Background(), &backend.Route{Color: "blue", Token: "t", Endpoints: []backend.Endpoint{
+		{Address: "http://a", Weight: 1}, {Address: "http://b", Weight: 1},
+	}}, time.Minute)
+
+	s := NewRoundRobinStrategy(b)
+	var got []string
+	for i := 0; i < 4; i++ {
+		target, err := s.Select(context.Background(), ForColor("blue"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, target)
+	}
+	if got[0] == got[1] || got[0] != got[2] || got[1] != got[3] {
+		t.Errorf("targets = %v, want alternating", got)
+	}
+}
+
+func TestRoundRobinEvictsCounters(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	defer b.Close()
+	s := NewRoundRobinStrategy(b)
+	ctx := context.Background()
+
+	b.Register(ctx, &backend.Route{Color: "blue", Address: "http://a", Token: "t"}, time.Minute)
+	s.Select(ctx, ForColor("blue"))
+	b.Delete(ctx, "blue")
+	if _, err := s.Select(ctx, ForColor("blue")); err == nil {
+		t.Fatal("Select succeeded for a deleted route")
+	}
+	if _, ok := s.counters["blue"]; ok {
+		t.Error("counter kept after the route was deleted")
+	}
+
+	for i := 0; i < maxRoundRobinCounters+10; i++ {
+		color := fmt.Sprintf("c%d", i)
+		b.Register(ctx, &backend.Route{Color: color, Address: "http://a", Token: "t"}, time.Minute)
+		s.Select(ctx, ForColor(color))
+	}
+	if n := len(s.counters); n > maxRoundRobinCounters {
+		t.Errorf("counters = %d, want at most %d", n, maxRoundRobinCounters)
+	}
+}
@@ -6,8 +6,9 @@ import (
 	"github.com/asam264/color/internal/backend"
 )
 
-// SimpleStrategy 简单策略：直接返回匹配的地址
+// SimpleStrategy 简单策略：返回第一个可路由（权重大于 0 且健康）的地址
 type SimpleStrategy struct {
+	healthFilter
 	backend backend.Backend
 }
 
@@ -15,10 +16,14 @@ func NewSimpleStrategy(backend backend.Backend) *SimpleStrategy {
 	return &SimpleStrategy{backend: backend}
 }
 
-func (s *SimpleStrategy) Select(ctx context.Context, color string) (string, error) {
-	route, err := s.backend.Get(ctx, color)
+func (s *SimpleStrategy) Select(ctx context.Context, req RoutingRequest) (string, error) {
+	route, err := s.backend.Get(ctx, req.Color)
 	if err != nil {
 		return "", err
 	}
-	return route.Address, nil
+	endpoints := s.routable(req.Color, route.EndpointList())
+	if len(endpoints) == 0 {
+		return "", ErrNoEndpoint
+	}
+	return endpoints[0].Address, nil
 }
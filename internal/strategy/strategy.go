@@ -2,10 +2,62 @@ package strategy
 
 import (
 	"context"
+	"errors"
+	"net"
+	"net/http"
 )
 
+// ErrNoEndpoint 路由存在但没有可用（权重大于 0 且健康）的地址
+var ErrNoEndpoint = errors.New("no routable endpoint")
+
 // Strategy 路由策略接口
+//
+// 迁移说明：旧版签名为 Select(ctx, color string)，实现方把 color 参数换成 req.Color 即可；
+// 调用方只有 color 时使用 ForColor(color) 构造请求。
 type Strategy interface {
-	// Select 根据 color 选择目标地址
-	Select(ctx context.Context, color string) (string, error)
+	// Select 根据路由请求选择目标地址
+	Select(ctx context.Context, req RoutingRequest) (string, error)
+}
+
+// RoutingRequest 路由请求：除 color 外携带请求的上下文信息，HTTP 与 gRPC 共用
+type RoutingRequest struct {
+	// Color 请求解析出的 color
+	Color string
+
+	// Version 请求指定的路由版本（可选），由代理在选择策略前解析
+	Version string
+
+	// Header HTTP 请求头，gRPC 调用时为 incoming metadata（key 转换为规范形式）
+	Header http.Header
+
+	// RemoteAddr 客户端地址（host:port），未知时为空
+	RemoteAddr string
+
+	// HTTP 原始 HTTP 请求，gRPC 调用或仅按 color 解析时为 nil
+	HTTP *http.Request
+}
+
+// ForColor 仅按 color 构造路由请求
+func ForColor(color string) RoutingRequest {
+	return RoutingRequest{Color: color}
+}
+
+// FromHTTP 从 HTTP 请求构造路由请求
+func FromHTTP(color string, r *http.Request) RoutingRequest {
+	return RoutingRequest{Color: color, Header: r.Header, RemoteAddr: r.RemoteAddr, HTTP: r}
+}
+
+// WithColor 返回替换了 color 的副本，组合策略（变体、金丝雀）解析其他 color 时使用
+func (r RoutingRequest) WithColor(color string) RoutingRequest {
+	r.Color = color
+	return r
+}
+
+// ClientIP 返回 RemoteAddr 中的 IP，解析失败时原样返回
+func (r RoutingRequest) ClientIP() string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
@@ -0,0 +1,107 @@
+package strategy
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// VariantSeparator 变体路由的命名分隔符：color "blue" 的变体 "stable" 注册为 "blue#stable"
+const VariantSeparator = "#"
+
+// VariantSplitStrategy color 内按比例分流到变体路由
+// 对配置了变体的 color，按权重随机选择一个变体，再交给内部策略解析 "<color>#<variant>"；
+// 选中的变体没有路由时回退到普通的 "<color>" 路由。未配置变体的 color 直接交给内部策略。
+// 开启 SetExpiryDecay 后变体的权重随其路由的剩余 TTL 衰减，所有变体都衰减到 0 时使用普通路由。
+type VariantSplitStrategy struct {
+	expiryDecay
+	inner    Strategy
+	variants map[string][]variantWeight
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+type variantWeight struct {
+	name   string
+	weight int
+}
+
+// NewVariantSplitStrategy 创建变体分流策略，splits 为 color -> (变体名 -> 权重)
+// 权重按总和归一化，例如 {"experimental": 10, "stable": 90} 即 10%/90%；权重 <= 0 的变体不参与分流
+func NewVariantSplitStrategy(inner Strategy, splits map[string]map[string]int) *VariantSplitStrategy {
+	s := &VariantSplitStrategy{
+		inner:    inner,
+		variants: make(map[string][]variantWeight, len(splits)),
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	for color, variants := range splits {
+		list := make([]variantWeight, 0, len(variants))
+		for name, weight := range variants {
+			if weight > 0 {
+				list = append(list, variantWeight{name: name, weight: weight})
+			}
+		}
+		// 固定顺序，保证同一随机数落在同一个变体上
+		sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+		if len(list) > 0 {
+			s.variants[color] = list
+		}
+	}
+
+	return s
+}
+
+// SetHealthChecker 透传给内部策略
+func (s *VariantSplitStrategy) SetHealthChecker(h HealthChecker) {
+	if ha, ok := s.inner.(HealthAware); ok {
+		ha.SetHealthChecker(h)
+	}
+}
+
+func (s *VariantSplitStrategy) Select(ctx context.Context, req RoutingRequest) (string, error) {
+	variants, ok := s.variants[req.Color]
+	if !ok {
+		return s.inner.Select(ctx, req)
+	}
+
+	variant, ok := s.pick(ctx, req.Color, variants)
+	if !ok {
+		return s.inner.Select(ctx, req)
+	}
+	if target, err := s.inner.Select(ctx, req.WithColor(req.Color+VariantSeparator+variant)); err == nil {
+		return target, nil
+	}
+
+	// 变体未注册，回退到普通 color 路由
+	return s.inner.Select(ctx, req)
+}
+
+// pick 按（衰减后的）权重随机选择变体，所有变体的有效权重都为 0 时返回 false
+func (s *VariantSplitStrategy) pick(ctx context.Context, color string, variants []variantWeight) (string, bool) {
+	now := time.Now()
+	weights := make([]float64, len(variants))
+	total := 0.0
+	for i, v := range variants {
+		weights[i] = float64(v.weight) * s.factor(ctx, color+VariantSeparator+v.name, now)
+		total += weights[i]
+	}
+	if total <= 0 {
+		return "", false
+	}
+
+	s.mu.Lock()
+	n := s.rnd.Float64() * total
+	s.mu.Unlock()
+
+	for i, v := range variants {
+		if n < weights[i] {
+			return v.name, true
+		}
+		n -= weights[i]
+	}
+	return variants[len(variants)-1].name, true
+}
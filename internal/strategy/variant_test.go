@@ -0,0 +1,54 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+)
+
+func TestVariantSplitProportions(t *testing.T) {
+	s := NewVariantSplitStrategy(staticStrategy{
+		"blue#experimental": "http://experimental",
+		"blue#stable":       "http://stable",
+		"blue":              "http://plain",
+	}, map[string]map[string]int{"blue": {"experimental": 10, "stable": 90}})
+
+	const n = 10000
+	counts := map[string]int{}
+	for i := 0; i < n; i++ {
+		target, err := s.Select(context.Background(), ForColor("blue"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[target]++
+	}
+
+	// 期望 1000 / 9000，标准差约 30
+	if got := counts["http://experimental"]; got < 850 || got > 1150 {
+		t.Errorf("experimental = %d/%d, want about 10%%", got, n)
+	}
+	if got := counts["http://stable"]; got < 8850 || got > 9150 {
+		t.Errorf("stable = %d/%d, want about 90%%", got, n)
+	}
+	if got := counts["http://plain"]; got != 0 {
+		t.Errorf("plain blue selected %d times, want only variants while both are registered", got)
+	}
+}
+
+func TestVariantSplitPlainColorWithoutVariants(t *testing.T) {
+	routes := staticStrategy{"blue": "http://plain", "green": "http://green"}
+	for name, splits := range map[string]map[string]map[string]int{
+		"no splits":            nil,
+		"other color split":    {"green": {"canary": 1}},
+		"only zero weights":    {"blue": {"experimental": 0}},
+		"variant unregistered": {"blue": {"experimental": 1}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := NewVariantSplitStrategy(routes, splits)
+			for i := 0; i < 20; i++ {
+				if target, err := s.Select(context.Background(), ForColor("blue")); err != nil || target != "http://plain" {
+					t.Fatalf("Select(blue) = %q, %v, want the plain blue route", target, err)
+				}
+			}
+		})
+	}
+}
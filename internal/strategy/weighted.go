@@ -0,0 +1,56 @@
+package strategy
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// WeightedRandomStrategy 加权随机策略：按地址权重成比例随机选择
+// 每次选择都是新流量：权重为 0（排空中）的地址永远不会被选中；所有地址权重都为 0 时返回 ErrNoEndpoint
+type WeightedRandomStrategy struct {
+	healthFilter
+	backend backend.Backend
+
+	// 每个实例独立的随机源，避免多个代理实例争用全局随机源
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func NewWeightedRandomStrategy(backend backend.Backend) *WeightedRandomStrategy {
+	return &WeightedRandomStrategy{
+		backend: backend,
+		rnd:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (s *WeightedRandomStrategy) Select(ctx context.Context, req RoutingRequest) (string, error) {
+	route, err := s.backend.Get(ctx, req.Color)
+	if err != nil {
+		return "", err
+	}
+
+	endpoints := s.routable(req.Color, route.EndpointList())
+	total := 0
+	for _, ep := range endpoints {
+		total += ep.Weight
+	}
+	if total == 0 {
+		return "", ErrNoEndpoint
+	}
+
+	s.mu.Lock()
+	n := s.rnd.Intn(total)
+	s.mu.Unlock()
+
+	for _, ep := range endpoints {
+		if n < ep.Weight {
+			return ep.Address, nil
+		}
+		n -= ep.Weight
+	}
+	return endpoints[len(endpoints)-1].Address, nil
+}
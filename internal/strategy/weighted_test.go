@@ -0,0 +1,69 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+func TestWeightedRandomDistribution(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	defer b.Close()
+	registerEndpoints(t, b, "blue",
+		backend.Endpoint{Address: "http://a", Weight: 1},
+		backend.Endpoint{Address: "http://b", Weight: 3},
+		backend.Endpoint{Address: "http://c", Weight: 6},
+	)
+	s := NewWeightedRandomStrategy(b)
+
+	const n = 10000
+	counts := map[string]int{}
+	for i := 0; i < n; i++ {
+		target, err := s.Select(context.Background(), ForColor("blue"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[target]++
+	}
+
+	// 二项分布标准差最大约 0.5%，容差取 2%
+	for target, share := range map[string]float64{"http://a": 0.1, "http://b": 0.3, "http://c": 0.6} {
+		got := float64(counts[target]) / n
+		if got < share-0.02 || got > share+0.02 {
+			t.Errorf("%s share = %.3f, want %.2f±0.02", target, got, share)
+		}
+	}
+}
+
+func TestWeightedRandomSkipsDrained(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	defer b.Close()
+	registerEndpoints(t, b, "blue",
+		backend.Endpoint{Address: "http://a", Weight: 1},
+		backend.Endpoint{Address: "http://b", Weight: 0},
+	)
+	s := NewWeightedRandomStrategy(b)
+	for i := 0; i < 1000; i++ {
+		target, err := s.Select(context.Background(), ForColor("blue"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target == "http://b" {
+			t.Fatal("weighted random selected a draining endpoint")
+		}
+	}
+}
+
+func TestWeightedRandomAllDrained(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	defer b.Close()
+	registerEndpoints(t, b, "blue",
+		backend.Endpoint{Address: "http://a", Weight: 0},
+		backend.Endpoint{Address: "http://b", Weight: 0},
+	)
+	if _, err := NewWeightedRandomStrategy(b).Select(context.Background(), ForColor("blue")); !errors.Is(err, ErrNoEndpoint) {
+		t.Errorf("err = %v, want ErrNoEndpoint", err)
+	}
+}
@@ -0,0 +1,115 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+)
+
+// bodyMemoryLimit 缓冲的 body 不超过该大小时保存在内存中，更大的写入临时文件
+const bodyMemoryLimit = 1 << 20
+
+// WithMaxBufferedBody 缓冲不超过 n 字节的请求 body，使其可以重放（GetBody），
+// 重试与流量镜像因此可以作用于 POST/PUT 等带 body 的请求。
+// 1MiB 以内的 body 保存在内存中，更大的写入临时文件，请求结束后删除；
+// 超过 n 的 body（含未声明长度、读取后才超出的）按原样流式转发，不可重放。n <= 0 表示关闭
+func WithMaxBufferedBody(n int64) HTTPOption {
+	return func(t *HTTPTransport) {
+		t.maxBufferedBody = n
+	}
+}
+
+// bufferedBody 已缓冲的请求 body，数据在内存或临时文件中
+type bufferedBody struct {
+	data []byte
+	file *os.File
+	size int64
+}
+
+// reader 返回从头读取的新 reader，每次重放各自独立
+func (b *bufferedBody) reader() io.ReadCloser {
+	if b.file != nil {
+		return io.NopCloser(io.NewSectionReader(b.file, 0, b.size))
+	}
+	return io.NopCloser(bytes.NewReader(b.data))
+}
+
+// close 删除临时文件
+func (b *bufferedBody) close() {
+	if b.file != nil {
+		b.file.Close()
+		os.Remove(b.file.Name())
+	}
+}
+
+// bufferBody 按 WithMaxBufferedBody 的上限缓冲 req 的 body
+func (t *HTTPTransport) bufferBody(req *http.Request) (func(), error) {
+	return BufferBody(req, t.maxBufferedBody)
+}
+
+// BufferBody 缓冲 req 中不超过 limit 字节的 body 并设置 GetBody，返回不再需要重放时的清理函数
+// body 为空或已可重放时不做处理，超过上限时 GetBody 保持为 nil；读取 body 失败时返回错误
+func BufferBody(req *http.Request, limit int64) (func(), error) {
+	noop := func() {}
+	if limit <= 0 || req.Body == nil || req.Body == http.NoBody || req.GetBody != nil || req.ContentLength > limit {
+		return noop, nil
+	}
+
+	// 先在内存中读取，超过内存上限后改写临时文件；多读 1 字节用于判断是否超出上限
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(req.Body, min(limit, bodyMemoryLimit)+1))
+	if err != nil {
+		return noop, err
+	}
+	if n <= min(limit, bodyMemoryLimit) {
+		b := &bufferedBody{data: buf.Bytes(), size: n}
+		setBufferedBody(req, b)
+		return noop, nil
+	}
+
+	if n > limit {
+		// 上限本身不超过内存上限且已超出：把已读部分接回原 body，按流式转发
+		req.Body = readCloser{io.MultiReader(bytes.NewReader(buf.Bytes()), req.Body), req.Body}
+		return noop, nil
+	}
+
+	file, err := os.CreateTemp("", "colorproxy-body-*")
+	if err != nil {
+		return noop, err
+	}
+	b := &bufferedBody{file: file}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		b.close()
+		return noop, err
+	}
+	rest, err := io.Copy(file, io.LimitReader(req.Body, limit+1-n))
+	if err != nil {
+		b.close()
+		return noop, err
+	}
+	b.size = n + rest
+
+	if b.size > limit {
+		// 读取后才发现超出上限：已读部分从临时文件读出，其余继续读原 body，不可重放
+		req.Body = readCloser{io.MultiReader(io.NewSectionReader(file, 0, b.size), req.Body), req.Body}
+		return b.close, nil
+	}
+
+	setBufferedBody(req, b)
+	return b.close, nil
+}
+
+// setBufferedBody 用缓冲的数据替换 body，并提供 GetBody 供重放
+func setBufferedBody(req *http.Request, b *bufferedBody) {
+	req.Body = b.reader()
+	req.GetBody = func() (io.ReadCloser, error) {
+		return b.reader(), nil
+	}
+}
+
+// readCloser 组合 Reader 与原 body 的 Close
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,192 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen target 的熔断器处于打开状态，请求未发往后端
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerTransition 一次熔断器状态变化
+type BreakerTransition struct {
+	Target   string
+	From, To BreakerState
+	// Failures 变化时的连续失败次数；探测成功关闭时为关闭前的次数
+	Failures int
+}
+
+// BreakerObserver 熔断器状态变化回调，req 为触发变化的请求；在熔断器的锁内同步调用，不应阻塞
+type BreakerObserver func(req *http.Request, t BreakerTransition)
+
+// BreakerStatus 单个 target 熔断器的当前状态
+type BreakerStatus struct {
+	State    BreakerState
+	Failures int       // 当前的连续失败次数
+	OpenedAt time.Time // 最近一次打开的时间，从未打开时为零
+}
+
+// breakerConfig 熔断配置
+type breakerConfig struct {
+	threshold int
+	cooldown  time.Duration
+	observer  BreakerObserver
+}
+
+// WithCircuitBreaker 按 target 熔断：连续 failureThreshold 次失败（连接错误或 502/503/504）后打开，
+// 打开期间直接返回 503 不再拨号；cooldown 过后进入半开状态，只放行一个探测请求，
+// 探测成功则关闭，失败则重新打开。
+func WithCircuitBreaker(failureThreshold int, cooldown time.Duration) HTTPOption {
+	return func(t *HTTPTransport) {
+		if failureThreshold <= 0 {
+			t.breaker = nil
+			return
+		}
+		var observer BreakerObserver
+		if t.breaker != nil {
+			observer = t.breaker.observer
+		}
+		t.breaker = &breakerConfig{threshold: failureThreshold, cooldown: cooldown, observer: observer}
+	}
+}
+
+// WithBreakerObserver 熔断器状态变化时回调 fn，需与 WithCircuitBreaker 一起使用（顺序不限）
+func WithBreakerObserver(fn BreakerObserver) HTTPOption {
+	return func(t *HTTPTransport) {
+		if t.breaker == nil {
+			// 先于 WithCircuitBreaker 调用：暂存回调，threshold 为 0 时不生效
+			t.breaker = &breakerConfig{observer: fn}
+			return
+		}
+		t.breaker.observer = fn
+	}
+}
+
+// circuitBreaker 单个 target 的熔断器
+type circuitBreaker struct {
+	target string
+	cfg    *breakerConfig
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow 判断请求能否发往后端；半开状态下只放行一个探测请求
+func (b *circuitBreaker) allow(req *http.Request) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cfg.cooldown {
+			return false
+		}
+		b.transition(req, BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record 记录请求结果
+func (b *circuitBreaker) record(req *http.Request, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+		if success {
+			b.transition(req, BreakerClosed)
+			b.failures = 0
+		} else {
+			b.failures++
+			b.openedAt = time.Now()
+			b.transition(req, BreakerOpen)
+		}
+		return
+	}
+
+	if success {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == BreakerClosed && b.failures >= b.cfg.threshold {
+		b.openedAt = time.Now()
+		b.transition(req, BreakerOpen)
+	}
+}
+
+// transition 切换状态（调用方持有锁）
+func (b *circuitBreaker) transition(req *http.Request, to BreakerState) {
+	from := b.state
+	b.state = to
+	if b.cfg.observer != nil && from != to {
+		b.cfg.observer(req, BreakerTransition{Target: b.target, From: from, To: to, Failures: b.failures})
+	}
+}
+
+func (b *circuitBreaker) status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BreakerStatus{State: b.state, Failures: b.failures, OpenedAt: b.openedAt}
+}
+
+// breakerFailure 计入熔断的响应状态码（与重试条件一致）
+func breakerFailure(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// getBreaker 获取（或创建）target 的熔断器，未启用熔断时返回 nil
+func (t *HTTPTransport) getBreaker(target string) *circuitBreaker {
+	if t.breaker == nil || t.breaker.threshold <= 0 {
+		return nil
+	}
+	if b, ok := t.breakers.Load(target); ok {
+		return b.(*circuitBreaker)
+	}
+	b, _ := t.breakers.LoadOrStore(target, &circuitBreaker{target: target, cfg: t.breaker})
+	return b.(*circuitBreaker)
+}
+
+// BreakerStates 返回各 target 当前的熔断状态
+func (t *HTTPTransport) BreakerStates() map[string]BreakerStatus {
+	states := make(map[string]BreakerStatus)
+	t.breakers.Range(func(key, value interface{}) bool {
+		states[key.(string)] = value.(*circuitBreaker).status()
+		return true
+	})
+	return states
+}
@@ -0,0 +1,196 @@
+package transport
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// WithCompression 客户端接受 gzip / deflate 且后端响应未编码、不小于 minSize 字节时，在返回给客户端时压缩响应。
+// 已编码（Content-Encoding 非 identity）、范围响应、Cache-Control: no-transform 以及图片/音视频/压缩包等
+// 本身已压缩的内容类型不处理；未声明长度的响应按流式压缩，每读到一段数据即输出，不缓冲整个 body
+func WithCompression(minSize int) HTTPOption {
+	return func(t *HTTPTransport) {
+		t.compression = true
+		t.compressMinSize = int64(max(minSize, 0))
+	}
+}
+
+// compressChunkSize 每次从后端读取的数据量
+const compressChunkSize = 32 << 10
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(io.Discard) },
+}
+
+// compressResponse 按协商结果压缩响应，在 ModifyResponse 的最后执行
+func (t *HTTPTransport) compressResponse(res *http.Response) {
+	if res.Request == nil || !compressible(res, t.compressMinSize) {
+		return
+	}
+	encoding := negotiateEncoding(res.Request.Header.Values("Accept-Encoding"))
+	if encoding == "" {
+		return
+	}
+
+	cr := &compressReader{
+		src:   res.Body,
+		chunk: make([]byte, compressChunkSize),
+		// 未声明长度的响应可能是流式的（如 SSE），每段数据都立即输出
+		flush: res.ContentLength < 0,
+	}
+	if encoding == "gzip" {
+		gw := gzipWriterPool.Get().(*gzip.Writer)
+		gw.Reset(&cr.buf)
+		cr.w = gw
+		cr.release = func() { gzipWriterPool.Put(gw) }
+	} else {
+		fw, _ := flate.NewWriter(&cr.buf, flate.DefaultCompression)
+		cr.w = fw
+	}
+	res.Body = cr
+
+	res.Header.Set("Content-Encoding", encoding)
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Header.Add("Vary", "Accept-Encoding")
+	// 压缩后的表示与原始表示字节不同，强 ETag 降级为弱 ETag
+	if etag := res.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		res.Header.Set("ETag", "W/"+etag)
+	}
+}
+
+// compressible 判断响应是否适合压缩
+func compressible(res *http.Response, minSize int64) bool {
+	if res.Request.Method == http.MethodHead || res.Body == nil || res.Body == http.NoBody {
+		return false
+	}
+	switch {
+	case res.StatusCode < 200, res.StatusCode == http.StatusNoContent,
+		res.StatusCode == http.StatusNotModified, res.StatusCode == http.StatusPartialContent:
+		return false
+	}
+	if ce := res.Header.Get("Content-Encoding"); ce != "" && !strings.EqualFold(ce, "identity") {
+		return false
+	}
+	if res.Header.Get("Content-Range") != "" || strings.Contains(strings.ToLower(res.Header.Get("Cache-Control")), "no-transform") {
+		return false
+	}
+	if res.ContentLength >= 0 && res.ContentLength < minSize {
+		return false
+	}
+	return !precompressedType(res.Header.Get("Content-Type"))
+}
+
+// precompressedType 本身已压缩、再压缩收益很小的内容类型
+func precompressedType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "image/svg+xml":
+		return false
+	case strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "video/"), strings.HasPrefix(mediaType, "audio/"):
+		return true
+	}
+	switch mediaType {
+	case "application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+		"application/x-7z-compressed", "application/x-rar-compressed", "font/woff", "font/woff2":
+		return true
+	}
+	return false
+}
+
+// negotiateEncoding 按 Accept-Encoding 选择编码：优先 gzip，其次 deflate；q=0 表示拒绝
+func negotiateEncoding(values []string) string {
+	accepted := make(map[string]bool)
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			ok := true
+			for _, param := range strings.Split(params, ";") {
+				if k, q, found := strings.Cut(strings.TrimSpace(param), "="); found && strings.EqualFold(k, "q") {
+					if f, err := strconv.ParseFloat(q, 64); err == nil && f == 0 {
+						ok = false
+					}
+				}
+			}
+			if name != "" {
+				accepted[name] = ok
+			}
+		}
+	}
+	for _, enc := range []string{"gzip", "deflate"} {
+		if ok, found := accepted[enc]; found {
+			if ok {
+				return enc
+			}
+			continue
+		}
+		if accepted["*"] {
+			return enc
+		}
+	}
+	return ""
+}
+
+// compressWriter gzip.Writer 与 flate.Writer 的公共接口
+type compressWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressReader 边读取后端 body 边压缩，不缓冲整个 body
+type compressReader struct {
+	src     io.ReadCloser
+	w       compressWriter
+	release func()
+	buf     bytes.Buffer
+	chunk   []byte
+	flush   bool
+	done    bool
+}
+
+func (c *compressReader) Read(p []byte) (int, error) {
+	for c.buf.Len() == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		n, err := c.src.Read(c.chunk)
+		if n > 0 {
+			if _, werr := c.w.Write(c.chunk[:n]); werr != nil {
+				return 0, werr
+			}
+			if c.flush {
+				if ferr := c.w.Flush(); ferr != nil {
+					return 0, ferr
+				}
+			}
+		}
+		if err == io.EOF {
+			if cerr := c.w.Close(); cerr != nil {
+				return 0, cerr
+			}
+			c.done = true
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	return c.buf.Read(p)
+}
+
+func (c *compressReader) Close() error {
+	if c.release != nil {
+		c.release()
+		c.release = nil
+	}
+	return c.src.Close()
+}
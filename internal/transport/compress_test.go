@@ -0,0 +1,174 @@
+package transport
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var compressiblePayload = strings.Repeat("colorproxy compresses text responses. ", 64)
+
+// staticBackend 返回带给定 header 的固定 body
+func staticBackend(t *testing.T, header http.Header, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, values := range header {
+			w.Header()[name] = values
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func proxyWithEncoding(t *testing.T, tr *HTTPTransport, target, acceptEncoding string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(context.Background(), target, req, rec); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestCompressionNegotiatesEncoding(t *testing.T) {
+	srv := staticBackend(t, http.Header{
+		"Content-Type": {"text/plain; charset=utf-8"},
+		"Etag":         {`"v1"`},
+	}, compressiblePayload)
+	tr := NewHTTPTransport(5*time.Second, WithCompression(256), WithHTTPLogging(false))
+	defer tr.Close()
+
+	for _, tc := range []struct {
+		accept   string
+		encoding string
+		decode   func(io.Reader) (io.Reader, error)
+	}{
+		{"gzip, deflate", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"deflate, gzip;q=0", "deflate", func(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil }},
+	} {
+		t.Run(tc.encoding, func(t *testing.T) {
+			rec := proxyWithEncoding(t, tr, srv.URL, tc.accept)
+			if got := rec.Header().Get("Content-Encoding"); got != tc.encoding {
+				t.Fatalf("Content-Encoding = %q, want %s", got, tc.encoding)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			if got := rec.Header().Get("ETag"); got != `W/"v1"` {
+				t.Errorf("ETag = %q, want it weakened to W/\"v1\"", got)
+			}
+			if got := rec.Header().Get("Content-Length"); got != "" {
+				t.Errorf("Content-Length = %s, want it removed", got)
+			}
+			r, err := tc.decode(rec.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(r)
+			if err != nil || string(body) != compressiblePayload {
+				t.Errorf("decoded body mismatch (%d bytes, err %v)", len(body), err)
+			}
+		})
+	}
+}
+
+func TestCompressionSkipsSmallAndUnacceptedResponses(t *testing.T) {
+	srv := staticBackend(t, http.Header{"Content-Type": {"text/plain"}}, "short")
+	tr := NewHTTPTransport(5*time.Second, WithCompression(256), WithHTTPLogging(false))
+	defer tr.Close()
+
+	if rec := proxyWithEncoding(t, tr, srv.URL, "gzip"); rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != "short" {
+		t.Errorf("response below minSize encoded as %q", rec.Header().Get("Content-Encoding"))
+	}
+
+	big := staticBackend(t, http.Header{"Content-Type": {"text/plain"}}, compressiblePayload)
+	if rec := proxyWithEncoding(t, tr, big.URL, "br"); rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != compressiblePayload {
+		t.Errorf("response for a client without gzip/deflate encoded as %q", rec.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestCompressionPassesThroughEncodedResponses(t *testing.T) {
+	tr := NewHTTPTransport(5*time.Second, WithCompression(0), WithHTTPLogging(false))
+	defer tr.Close()
+
+	for name, header := range map[string]http.Header{
+		"already encoded": {"Content-Type": {"text/plain"}, "Content-Encoding": {"br"}},
+		"precompressed":   {"Content-Type": {"image/png"}},
+		"archive":         {"Content-Type": {"application/zip"}},
+		"no-transform":    {"Content-Type": {"text/plain"}, "Cache-Control": {"no-transform"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := staticBackend(t, header, compressiblePayload)
+			rec := proxyWithEncoding(t, tr, srv.URL, "gzip")
+			if got, want := rec.Header().Get("Content-Encoding"), header.Get("Content-Encoding"); got != want {
+				t.Errorf("Content-Encoding = %q, want %q unchanged", got, want)
+			}
+			if rec.Header().Get("Vary") != "" || rec.Body.String() != compressiblePayload {
+				t.Errorf("Vary = %q, body changed = %v, want the response passed through",
+					rec.Header().Get("Vary"), rec.Body.String() != compressiblePayload)
+			}
+		})
+	}
+}
+
+func TestCompressionStreamsChunks(t *testing.T) {
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("data: second\n\n"))
+	}))
+	defer backend.Close()
+	defer close(release)
+
+	tr := NewHTTPTransport(5*time.Second, WithCompression(0), WithHTTPLogging(false))
+	defer tr.Close()
+	front := proxyFront(t, tr, backend.URL)
+
+	req, _ := http.NewRequest(http.MethodGet, front.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	defer client.CloseIdleConnections()
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", res.Header.Get("Content-Encoding"))
+	}
+
+	// 后端仍阻塞在第二段之前，第一段必须已经压缩并输出
+	first := make(chan string, 1)
+	go func() {
+		gz, err := gzip.NewReader(res.Body)
+		if err != nil {
+			first <- err.Error()
+			return
+		}
+		buf := make([]byte, len("data: first\n\n"))
+		if _, err := io.ReadFull(gz, buf); err != nil {
+			first <- err.Error()
+			return
+		}
+		first <- string(buf)
+	}()
+	select {
+	case got := <-first:
+		if got != "data: first\n\n" {
+			t.Errorf("first chunk = %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("first chunk not flushed before the stream finished")
+	}
+}
@@ -0,0 +1,159 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+)
+
+// ConnStat 单个 host 的连接池状态
+type ConnStat struct {
+	Idle  int `json:"idle"`
+	InUse int `json:"in_use"`
+}
+
+// WithConnStats 统计连接池中各 host 的空闲与使用中连接数，见 HTTPTransport.ConnStats
+// http.Transport 不暴露连接池状态，因此在拨号与请求两侧计数：
+// 拨号成功 open+1、连接关闭 open-1；请求拿到连接（httptrace GotConn）in_use+1、响应体关闭或转发失败 in_use-1，空闲数为两者之差。
+// HTTP/2 多个请求复用同一连接，in_use 为进行中的流数，可能大于连接数（此时空闲数为 0）；
+// 经 HTTP_PROXY 转发时连接按代理地址计数。
+func WithConnStats(enabled bool) HTTPOption {
+	return func(t *HTTPTransport) {
+		if enabled {
+			t.conns = &connStats{}
+		}
+	}
+}
+
+// connStats 按 host（host:port）计数的连接池状态
+type connStats struct {
+	hosts sync.Map // host -> *hostConns
+}
+
+type hostConns struct {
+	open  atomic.Int64
+	inUse atomic.Int64
+}
+
+func (s *connStats) host(addr string) *hostConns {
+	if v, ok := s.hosts.Load(addr); ok {
+		return v.(*hostConns)
+	}
+	v, _ := s.hosts.LoadOrStore(addr, new(hostConns))
+	return v.(*hostConns)
+}
+
+// snapshot 返回有连接或进行中请求的 host
+func (s *connStats) snapshot() map[string]ConnStat {
+	stats := make(map[string]ConnStat)
+	s.hosts.Range(func(key, value interface{}) bool {
+		h := value.(*hostConns)
+		open, inUse := h.open.Load(), h.inUse.Load()
+		if open == 0 && inUse == 0 {
+			return true
+		}
+		stats[key.(string)] = ConnStat{Idle: int(max(open-inUse, 0)), InUse: int(inUse)}
+		return true
+	})
+	return stats
+}
+
+// dial 包装拨号：连接建立时计数，关闭时释放
+func (s *connStats) dial(next func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		h := s.host(addr)
+		h.open.Add(1)
+		return &countedConn{Conn: conn, host: h}, nil
+	}
+}
+
+// countedConn 关闭时减少所属 host 的连接数（只计一次）
+type countedConn struct {
+	net.Conn
+	host   *hostConns
+	closed atomic.Bool
+}
+
+func (c *countedConn) Close() error {
+	if c.closed.CompareAndSwap(false, true) {
+		c.host.open.Add(-1)
+	}
+	return c.Conn.Close()
+}
+
+// connTracker 记录请求占用连接的区间：拿到连接到响应体关闭
+type connTracker struct {
+	next  http.RoundTripper
+	stats *connStats
+}
+
+func (t *connTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	h := t.stats.host(canonicalAddr(req))
+	var held atomic.Bool
+	release := func() {
+		if held.CompareAndSwap(true, false) {
+			h.inUse.Add(-1)
+		}
+	}
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			if held.CompareAndSwap(false, true) {
+				h.inUse.Add(1)
+			}
+		},
+	}
+	res, err := t.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	res.Body = &releaseBody{ReadCloser: res.Body, release: release}
+	return res, nil
+}
+
+// releaseBody 响应体关闭时释放连接占用；协议升级的 body 同时实现 io.Writer，需要保留
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+func (b *releaseBody) Write(p []byte) (int, error) {
+	if w, ok := b.ReadCloser.(io.Writer); ok {
+		return w.Write(p)
+	}
+	return 0, http.ErrNotSupported
+}
+
+// canonicalAddr 请求的拨号地址（host:port），与 DialContext 收到的 addr 一致
+func canonicalAddr(req *http.Request) string {
+	if port := req.URL.Port(); port != "" {
+		return req.URL.Host
+	}
+	port := "80"
+	if req.URL.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(req.URL.Hostname(), port)
+}
+
+// ConnStats 返回各 host（host:port）的连接池状态，未启用 WithConnStats 时返回 nil
+func (t *HTTPTransport) ConnStats() map[string]ConnStat {
+	if t.conns == nil {
+		return nil
+	}
+	return t.conns.snapshot()
+}
@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// waitConnStat 等待 host 的连接池状态变为 want
+func waitConnStat(t *testing.T, tr *HTTPTransport, host string, want ConnStat) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got, ok := tr.ConnStats()[host]
+		if got == want && (ok || want == ConnStat{}) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ConnStats()[%s] = %+v, want %+v", host, got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestConnStatsTracksInUseAndIdle(t *testing.T) {
+	release := make(chan struct{})
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("ok"))
+	}))
+	defer up.Close()
+	host := mustHost(t, up.URL)
+
+	tr := NewHTTPTransport(5*time.Second, WithConnStats(true), WithHTTPLogging(false))
+	defer tr.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tr.Proxy(context.Background(), up.URL, httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	}()
+	waitConnStat(t, tr, host, ConnStat{Idle: 0, InUse: 1})
+
+	close(release)
+	<-done
+	waitConnStat(t, tr, host, ConnStat{Idle: 1, InUse: 0})
+
+	tr.getTransport().CloseIdleConnections()
+	waitConnStat(t, tr, host, ConnStat{})
+	if _, ok := tr.ConnStats()[host]; ok {
+		t.Errorf("host without connections still reported: %v", tr.ConnStats())
+	}
+}
+
+func TestConnStatsFailedRequestReleasesConn(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 不返回响应直接断开连接，转发失败
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer up.Close()
+	host := mustHost(t, up.URL)
+
+	tr := NewHTTPTransport(5*time.Second, WithConnStats(true), WithHTTPLogging(false))
+	defer tr.Close()
+
+	rec := httptest.NewRecorder()
+	tr.Proxy(context.Background(), up.URL, httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", rec.Code)
+	}
+	waitConnStat(t, tr, host, ConnStat{})
+}
+
+func TestConnStatsDisabled(t *testing.T) {
+	tr := NewHTTPTransport(time.Second, WithHTTPLogging(false))
+	defer tr.Close()
+	if stats := tr.ConnStats(); stats != nil {
+		t.Errorf("ConnStats() = %v, want nil when disabled", stats)
+	}
+}
+
+func mustHost(t *testing.T, raw string) string {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Host
+}
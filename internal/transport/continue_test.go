@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingReader 记录请求体是否被读取，用于确认客户端是否真的上传了 body
+type countingReader struct {
+	r     io.Reader
+	reads atomic.Int32
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	c.reads.Add(1)
+	return c.r.Read(p)
+}
+
+// proxyFront 在真实的 http.Server 前置 HTTPTransport，使 100 Continue 走完整的入站链路
+func proxyFront(t *testing.T, tr *HTTPTransport, target string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tr.Proxy(r.Context(), target, r, w)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// uploadBackend 在读取 body 前检查 Content-Length，超过 limit 直接返回 413，否则读完 body 返回 201
+func uploadBackend(t *testing.T, limit int64) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// expectContinueUpload 以 Expect: 100-continue 上传 body，返回响应与 body 被读取的次数
+func expectContinueUpload(t *testing.T, url, body string) (*http.Response, string, int32) {
+	t.Helper()
+	reader := &countingReader{r: strings.NewReader(body)}
+	req, err := http.NewRequest(http.MethodPost, url, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Expect", "100-continue")
+
+	// 超时远大于测试时长：客户端只有收到 100 Continue 才会发送 body
+	client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: 10 * time.Second}}
+	defer client.CloseIdleConnections()
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	got, _ := io.ReadAll(res.Body)
+	return res, string(got), reader.reads.Load()
+}
+
+func TestExpectContinueRejectedBeforeBody(t *testing.T) {
+	backend := uploadBackend(t, 4)
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false))
+	defer tr.Close()
+	front := proxyFront(t, tr, backend.URL)
+
+	res, _, reads := expectContinueUpload(t, front.URL, "too large upload")
+	if res.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want the backend's 413", res.StatusCode)
+	}
+	if reads != 0 {
+		t.Errorf("client body read %d times, want no upload after the backend rejected it", reads)
+	}
+}
+
+func TestExpectContinueAccepted(t *testing.T) {
+	backend := uploadBackend(t, 1<<10)
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false))
+	defer tr.Close()
+	front := proxyFront(t, tr, backend.URL)
+
+	res, body, reads := expectContinueUpload(t, front.URL, "small upload")
+	if res.StatusCode != http.StatusCreated || body != "small upload" {
+		t.Errorf("status = %d body = %q, want 201 echoing the upload", res.StatusCode, body)
+	}
+	if reads == 0 {
+		t.Error("client never sent the body after 100 Continue")
+	}
+}
+
+func TestInformationalResponseKeepsFinalStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		informational int
+		final         int
+	}{
+		{"continue", http.StatusContinue, http.StatusCreated},
+		{"early hints", http.StatusEarlyHints, http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.informational)
+				w.WriteHeader(tc.final)
+			}))
+			defer backend.Close()
+			tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false))
+			defer tr.Close()
+			front := proxyFront(t, tr, backend.URL)
+
+			res, err := http.Get(front.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != tc.final {
+				t.Errorf("status = %d after a %d, want the final %d", res.StatusCode, tc.informational, tc.final)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// hostResolver 解析 hostname，*net.Resolver 实现了该接口
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// dnsCache 带定期刷新的 DNS 解析缓存
+// 拨号时使用缓存的 IP 列表；后台定期重新解析，IP 集合变化时通知调用方淘汰旧连接
+type dnsCache struct {
+	resolver hostResolver
+	dialer   *net.Dialer
+	logf     LogFunc
+
+	mu    sync.RWMutex
+	hosts map[string][]string
+}
+
+func newDNSCache(dialer *net.Dialer, logf LogFunc) *dnsCache {
+	return &dnsCache{
+		resolver: net.DefaultResolver,
+		dialer:   dialer,
+		logf:     logf,
+		hosts:    make(map[string][]string),
+	}
+}
+
+// DialContext 使用缓存的解析结果拨号，依次尝试每个 IP
+func (c *dnsCache) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return c.dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := c.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no addresses resolved for " + host)
+	}
+	return nil, lastErr
+}
+
+// lookup 优先读缓存，未命中时解析并写入缓存
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	c.mu.RLock()
+	ips, ok := c.hosts[host]
+	c.mu.RUnlock()
+	if ok {
+		return ips, nil
+	}
+
+	ips, err := c.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ips)
+
+	c.mu.Lock()
+	c.hosts[host] = ips
+	c.mu.Unlock()
+	return ips, nil
+}
+
+// forget 删除 host 的缓存，之后不再定期刷新；再次拨号时重新解析
+func (c *dnsCache) forget(host string) {
+	c.mu.Lock()
+	delete(c.hosts, host)
+	c.mu.Unlock()
+}
+
+// refresh 重新解析所有已缓存的 host，返回 IP 集合发生变化的 host
+// 解析失败时保留旧结果，避免 DNS 抖动导致目标不可用
+func (c *dnsCache) refresh() []string {
+	c.mu.RLock()
+	hosts := make([]string, 0, len(c.hosts))
+	for host := range c.hosts {
+		hosts = append(hosts, host)
+	}
+	c.mu.RUnlock()
+
+	var changed []string
+	for _, host := range hosts {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ips, err := c.resolver.LookupHost(ctx, host)
+		cancel()
+		if err != nil {
+			c.logf("[HTTPTransport] DNS refresh failed for %s: %v", host, err)
+			continue
+		}
+		sort.Strings(ips)
+
+		c.mu.Lock()
+		if !equalStrings(c.hosts[host], ips) {
+			c.hosts[host] = ips
+			changed = append(changed, host)
+		}
+		c.mu.Unlock()
+	}
+	return changed
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
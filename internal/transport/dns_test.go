@@ -0,0 +1,135 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubResolver 可在测试中修改解析结果的 hostResolver
+type stubResolver struct {
+	mu    sync.Mutex
+	hosts map[string][]string
+}
+
+func (r *stubResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ips, ok := r.hosts[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return ips, nil
+}
+
+func (r *stubResolver) set(host string, ips ...string) {
+	r.mu.Lock()
+	r.hosts[host] = ips
+	r.mu.Unlock()
+}
+
+// loopbackPair 在 127.0.0.1 与 127.0.0.2 的同一端口上启动后端，响应 body 为后端接受连接的本地 IP
+func loopbackPair(t *testing.T) string {
+	t.Helper()
+	first, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := strconv.Itoa(first.Addr().(*net.TCPAddr).Port)
+	second, err := net.Listen("tcp", net.JoinHostPort("127.0.0.2", port))
+	if err != nil {
+		first.Close()
+		t.Skipf("127.0.0.2 is not available: %v", err)
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		local := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+		host, _, _ := net.SplitHostPort(local.String())
+		io.WriteString(w, host)
+	})}
+	go srv.Serve(first)
+	go srv.Serve(second)
+	t.Cleanup(func() { srv.Close() })
+	return port
+}
+
+// newDNSTransport 启用 DNS 缓存的 HTTPTransport；刷新间隔足够长，由测试手动调用 refresh
+func newDNSTransport(t *testing.T, resolver hostResolver) *HTTPTransport {
+	t.Helper()
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false), WithDNSRefresh(time.Hour))
+	tr.dns.resolver = resolver
+	t.Cleanup(func() { tr.Close() })
+	return tr
+}
+
+func proxiedBody(t *testing.T, tr *HTTPTransport, target string) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(context.Background(), target, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("proxy to %s: status = %d, err = %v", target, rec.Code, err)
+	}
+	return rec.Body.String()
+}
+
+// cachedIPs 读取 DNS 缓存中 host 的解析结果
+func cachedIPs(c *dnsCache, host string) ([]string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ips, ok := c.hosts[host]
+	return ips, ok
+}
+
+func TestDNSChangeEvictsCachedProxy(t *testing.T) {
+	port := loopbackPair(t)
+	resolver := &stubResolver{hosts: make(map[string][]string)}
+	resolver.set("svc.test", "127.0.0.1")
+	tr := newDNSTransport(t, resolver)
+	target := "http://svc.test:" + port
+
+	if got := proxiedBody(t, tr, target); got != "127.0.0.1" {
+		t.Fatalf("first request reached %s, want 127.0.0.1", got)
+	}
+	if changed := tr.dns.refresh(); len(changed) != 0 {
+		t.Fatalf("refresh reported %v without a DNS change", changed)
+	}
+
+	resolver.set("svc.test", "127.0.0.2")
+	changed := tr.dns.refresh()
+	if len(changed) != 1 || changed[0] != "svc.test" {
+		t.Fatalf("refresh reported %v, want svc.test", changed)
+	}
+	tr.evictHost("svc.test")
+	if _, ok := tr.proxyCache.Load(target); ok {
+		t.Error("cached proxy kept after the IP change")
+	}
+
+	if got := proxiedBody(t, tr, target); got != "127.0.0.2" {
+		t.Errorf("request after the IP change reached %s, want 127.0.0.2", got)
+	}
+}
+
+func TestRemoveTargetForgetsUnusedHost(t *testing.T) {
+	port := loopbackPair(t)
+	resolver := &stubResolver{hosts: make(map[string][]string)}
+	resolver.set("svc.test", "127.0.0.1")
+	tr := newDNSTransport(t, resolver)
+	api, web := "http://svc.test:"+port+"/api", "http://svc.test:"+port+"/web"
+
+	proxiedBody(t, tr, api)
+	proxiedBody(t, tr, web)
+
+	tr.RemoveTarget(api)
+	if _, ok := cachedIPs(tr.dns, "svc.test"); !ok {
+		t.Fatal("host forgotten while another target still uses it")
+	}
+	tr.RemoveTarget(web)
+	if ips, ok := cachedIPs(tr.dns, "svc.test"); ok {
+		t.Errorf("host still cached as %v after its last target was removed", ips)
+	}
+}
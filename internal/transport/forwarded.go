@@ -0,0 +1,91 @@
+package transport
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// WithTrustedProxies 只信任来自 nets 的上游代理设置的转发 header：
+// 来自受信任上游的 X-Forwarded-For / Forwarded 链会被保留并追加，X-Forwarded-Proto / X-Forwarded-Host 原样保留；
+// 其他来源的这些 header 会被丢弃并按本次连接重新设置，避免客户端伪造 IP。
+// 未配置时不信任任何上游：客户端带来的转发 header 一律丢弃，代理部署在负载均衡等上游之后时需要配置
+func WithTrustedProxies(nets []*net.IPNet) HTTPOption {
+	return func(t *HTTPTransport) {
+		t.trustedProxies = nets
+	}
+}
+
+// trustedUpstream 判断直接连接的上游是否受信任，未配置 WithTrustedProxies 时始终为 false
+func (t *HTTPTransport) trustedUpstream(r *http.Request) bool {
+	ip := net.ParseIP(remoteIP(r))
+	if ip == nil {
+		return false
+	}
+	for _, n := range t.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// setForwardedHeaders 在 Director 中为发往后端的请求设置转发 header，host 为客户端请求的原始 Host
+// X-Forwarded-For 由 ReverseProxy 在 Director 之后追加客户端 IP，这里只决定是否保留已有的链
+func (t *HTTPTransport) setForwardedHeaders(r *http.Request, host string) {
+	proto := "http"
+	if r.TLS != nil {
+		proto = "https"
+	}
+
+	if !t.trustedUpstream(r) {
+		r.Header.Del("X-Forwarded-For")
+		r.Header.Del("X-Forwarded-Proto")
+		r.Header.Del("X-Forwarded-Host")
+		r.Header.Del("Forwarded")
+	}
+	if r.Header.Get("X-Forwarded-Proto") == "" {
+		r.Header.Set("X-Forwarded-Proto", proto)
+	}
+	if r.Header.Get("X-Forwarded-Host") == "" && host != "" {
+		r.Header.Set("X-Forwarded-Host", host)
+	}
+
+	// RFC 7239：追加本跳的 for/host/proto
+	element := "for=" + forwardedNode(remoteIP(r))
+	if host != "" {
+		element += ";host=" + forwardedValue(host)
+	}
+	element += ";proto=" + proto
+	if prior := strings.Join(r.Header.Values("Forwarded"), ", "); prior != "" {
+		element = prior + ", " + element
+	}
+	r.Header.Set("Forwarded", element)
+}
+
+// remoteIP 返回 RemoteAddr 中的 IP，无法解析时原样返回
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// forwardedNode RFC 7239 的节点标识：IPv6 需要加方括号并加引号，未知时为 unknown
+func forwardedNode(ip string) string {
+	if ip == "" {
+		return "unknown"
+	}
+	if strings.Contains(ip, ":") {
+		return `"[` + ip + `]"`
+	}
+	return ip
+}
+
+// forwardedValue 含有 token 以外字符（如 host 中的 :）的值需要加引号
+func forwardedValue(v string) string {
+	if strings.ContainsAny(v, `:[]"`) {
+		return `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+	}
+	return v
+}
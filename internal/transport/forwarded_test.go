@@ -0,0 +1,105 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// forwardThrough 以 remoteAddr 作为直接连接的上游转发请求，返回后端收到的 header
+func forwardThrough(t *testing.T, tr *HTTPTransport, remoteAddr string, header http.Header) http.Header {
+	t.Helper()
+	srv, seen := capturingBackend(t)
+	req := httptest.NewRequest(http.MethodGet, "http://public.example.com/", nil)
+	req.RemoteAddr = remoteAddr
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if err := tr.Proxy(context.Background(), srv.URL, req, httptest.NewRecorder()); err != nil {
+		t.Fatal(err)
+	}
+	return <-seen
+}
+
+func trustedNets(t *testing.T, cidrs ...string) []*net.IPNet {
+	t.Helper()
+	var nets []*net.IPNet
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// forgedHeaders 上游（或客户端）带来的转发 header
+func forgedHeaders() http.Header {
+	return http.Header{
+		"X-Forwarded-For":   {"203.0.113.7"},
+		"X-Forwarded-Proto": {"https"},
+		"X-Forwarded-Host":  {"spoofed.example.com"},
+		"Forwarded":         {"for=203.0.113.7;proto=https"},
+	}
+}
+
+func assertForwarded(t *testing.T, got http.Header, want map[string]string) {
+	t.Helper()
+	for name, value := range want {
+		if v := got.Get(name); v != value {
+			t.Errorf("%s = %q, want %q", name, v, value)
+		}
+	}
+}
+
+func TestForwardedHeadersDirectClient(t *testing.T) {
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false))
+	defer tr.Close()
+
+	got := forwardThrough(t, tr, "198.51.100.9:4321", nil)
+	assertForwarded(t, got, map[string]string{
+		"X-Forwarded-For":   "198.51.100.9",
+		"X-Forwarded-Proto": "http",
+		"X-Forwarded-Host":  "public.example.com",
+		"Forwarded":         "for=198.51.100.9;host=public.example.com;proto=http",
+	})
+}
+
+func TestForwardedHeadersTrustedUpstream(t *testing.T) {
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false), WithTrustedProxies(trustedNets(t, "10.0.0.0/8")))
+	defer tr.Close()
+
+	got := forwardThrough(t, tr, "10.1.2.3:4321", forgedHeaders())
+	assertForwarded(t, got, map[string]string{
+		"X-Forwarded-For":   "203.0.113.7, 10.1.2.3",
+		"X-Forwarded-Proto": "https",
+		"X-Forwarded-Host":  "spoofed.example.com",
+		"Forwarded":         "for=203.0.113.7;proto=https, for=10.1.2.3;host=public.example.com;proto=http",
+	})
+}
+
+func TestForwardedHeadersUntrustedUpstream(t *testing.T) {
+	direct := map[string]string{
+		"X-Forwarded-For":   "198.51.100.9",
+		"X-Forwarded-Proto": "http",
+		"X-Forwarded-Host":  "public.example.com",
+		"Forwarded":         "for=198.51.100.9;host=public.example.com;proto=http",
+	}
+	for name, opts := range map[string][]HTTPOption{
+		"outside trusted nets": {WithTrustedProxies(trustedNets(t, "10.0.0.0/8"))},
+		// 未配置 WithTrustedProxies 时不信任任何上游
+		"no trusted proxies": nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			tr := NewHTTPTransport(5*time.Second, append(opts, WithHTTPLogging(false))...)
+			defer tr.Close()
+
+			got := forwardThrough(t, tr, "198.51.100.9:4321", forgedHeaders())
+			assertForwarded(t, got, direct)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package transport
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
@@ -9,9 +11,13 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // GRPCTransport gRPC 传输层实现
@@ -19,6 +25,14 @@ type GRPCTransport struct {
 	timeout   time.Duration
 	enableLog bool
 
+	// 连接凭证：creds 为默认凭证，credsFor 非空时按 target 选择，返回 nil 则使用 creds
+	creds    credentials.TransportCredentials
+	credsFor func(target string) credentials.TransportCredentials
+
+	// 连接建立方式，见 WithGRPCWaitForReady 与 WithGRPCBlockingDial
+	waitForReady bool
+	dialTimeout  time.Duration
+
 	// gRPC 客户端连接池：缓存到不同 target 的连接
 	connPool sync.Map // map[string]*grpcConn
 
@@ -36,8 +50,58 @@ type grpcConn struct {
 	lastUse time.Time
 }
 
+// GRPCOption GRPCTransport 配置选项
+type GRPCOption func(*GRPCTransport)
+
+// WithGRPCTransportCredentials 使用 creds 连接所有 target
+func WithGRPCTransportCredentials(creds credentials.TransportCredentials) GRPCOption {
+	return func(t *GRPCTransport) {
+		t.creds = creds
+	}
+}
+
+// WithGRPCTLSConfig 使用 cfg 以 TLS 连接所有 target
+func WithGRPCTLSConfig(cfg *tls.Config) GRPCOption {
+	return WithGRPCTransportCredentials(credentials.NewTLS(cfg))
+}
+
+// WithGRPCInsecure 以明文连接所有 target，仅用于本地开发
+func WithGRPCInsecure() GRPCOption {
+	return WithGRPCTransportCredentials(insecure.NewCredentials())
+}
+
+// WithGRPCCredentialsFunc 按 target（host:port）选择连接凭证，用于不同后端使用不同证书的场景
+// fn 返回 nil 时使用默认凭证；连接按 target 缓存，fn 对同一 target 应返回一致的结果
+func WithGRPCCredentialsFunc(fn func(target string) credentials.TransportCredentials) GRPCOption {
+	return func(t *GRPCTransport) {
+		t.credsFor = fn
+	}
+}
+
+// WithGRPCWaitForReady 设置 RPC 在连接未就绪时的行为：
+//   - false（默认，fail-fast）：连接处于失败状态时 RPC 立即返回 Unavailable，后端不可达时调用方能马上感知并降级；
+//     连接仍在建立中（首次调用）时 RPC 会等待连接结果
+//   - true（queue-until-ready）：RPC 排队等待连接就绪，直到自身的超时，适合后端短暂重启的场景；
+//     后端长时间不可达时每个请求都会占用到超时为止
+func WithGRPCWaitForReady(enabled bool) GRPCOption {
+	return func(t *GRPCTransport) {
+		t.waitForReady = enabled
+	}
+}
+
+// WithGRPCBlockingDial 新建到 target 的连接时阻塞等待连接就绪，最长 timeout：
+// 超时的 target 立即返回 Unavailable 且不缓存连接，下次调用重新建立。
+// 首次调用即可确认 target 是否可达，代价是每个新 target 的首次调用多出建连耗时；
+// 默认（timeout <= 0）连接在后台建立，不阻塞创建过程
+func WithGRPCBlockingDial(timeout time.Duration) GRPCOption {
+	return func(t *GRPCTransport) {
+		t.dialTimeout = timeout
+	}
+}
+
 // NewGRPCTransport 创建 gRPC 传输层
-func NewGRPCTransport(timeout time.Duration) *GRPCTransport {
+// 默认以 TLS（系统根证书）连接后端，明文连接需通过 WithGRPCInsecure 显式开启
+func NewGRPCTransport(timeout time.Duration, opts ...GRPCOption) *GRPCTransport {
 	if timeout == 0 {
 		timeout = 30 * time.Second
 	}
@@ -47,6 +111,12 @@ func NewGRPCTransport(timeout time.Duration) *GRPCTransport {
 		enableLog: true,
 		done:      make(chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.creds == nil {
+		t.creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
 
 	// 启动连接清理协程（清理超过 5 分钟未使用的连接）
 	t.cleanupTicker = time.NewTicker(1 * time.Minute)
@@ -55,7 +125,7 @@ func NewGRPCTransport(timeout time.Duration) *GRPCTransport {
 	return t
 }
 
-// getOrCreateConn 获取或创建到 target 的 gRPC 连接
+// getOrCreateConn 获取或创建到 target 的 gRPC 连接，失败时返回 codes.Unavailable
 func (t *GRPCTransport) getOrCreateConn(target string) (*grpc.ClientConn, error) {
 	// 解析 target（支持 "host:port" 或 "grpc://host:port" 格式）
 	addr := target
@@ -64,7 +134,7 @@ func (t *GRPCTransport) getOrCreateConn(target string) (*grpc.ClientConn, error)
 	}
 
 	if addr == "" {
-		return nil, fmt.Errorf("empty target address")
+		return nil, status.Error(codes.Unavailable, "empty target address")
 	}
 
 	// 从缓存获取
@@ -76,18 +146,14 @@ func (t *GRPCTransport) getOrCreateConn(target string) (*grpc.ClientConn, error)
 		return gc.conn, nil
 	}
 
-	// 创建新连接
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	// 连接选项
 	opts := []grpc.DialOption{
-		// 本地开发使用，生产环境应使用 TLS
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(t.credentials(addr)),
 		// 消息大小限制（根据实际需求调整）
 		grpc.WithDefaultCallOptions(
 			grpc.MaxCallRecvMsgSize(100*1024*1024), // 100MB
 			grpc.MaxCallSendMsgSize(100*1024*1024), // 100MB
+			grpc.WaitForReady(t.waitForReady),
 		),
 		// Keepalive 配置：保持长连接
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
@@ -97,9 +163,16 @@ func (t *GRPCTransport) getOrCreateConn(target string) (*grpc.ClientConn, error)
 		}),
 	}
 
-	conn, err := grpc.DialContext(ctx, addr, opts...)
+	// 非阻塞：只创建连接对象，实际连接在后台建立
+	conn, err := grpc.DialContext(context.Background(), addr, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to dial %s: %w", addr, err)
+		return nil, status.Errorf(codes.Unavailable, "failed to dial %s: %v", addr, err)
+	}
+	if t.dialTimeout > 0 {
+		if err := waitReady(conn, t.dialTimeout); err != nil {
+			conn.Close()
+			return nil, status.Errorf(codes.Unavailable, "failed to connect %s: %v", addr, err)
+		}
 	}
 
 	// 缓存连接
@@ -117,6 +190,38 @@ func (t *GRPCTransport) getOrCreateConn(target string) (*grpc.ClientConn, error)
 	return conn, nil
 }
 
+// waitReady 主动建立连接并等待其就绪，超时或连接被关闭时返回错误
+func waitReady(conn *grpc.ClientConn, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Shutdown:
+			return errors.New("connection closed")
+		case connectivity.Idle:
+			conn.Connect()
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("not ready within %v (state %s)", timeout, state)
+		}
+	}
+}
+
+// credentials 返回连接 addr 使用的凭证
+func (t *GRPCTransport) credentials(addr string) credentials.TransportCredentials {
+	if t.credsFor != nil {
+		if creds := t.credsFor(addr); creds != nil {
+			return creds
+		}
+	}
+	return t.creds
+}
+
 // ProxyUnary 执行 gRPC Unary RPC 代理转发
 func (t *GRPCTransport) Proxy(
 	ctx context.Context,
@@ -128,7 +233,7 @@ func (t *GRPCTransport) Proxy(
 ) error {
 	conn, err := t.getOrCreateConn(target)
 	if err != nil {
-		return fmt.Errorf("failed to get connection: %w", err)
+		return err
 	}
 
 	// 创建带超时的 context
@@ -155,7 +260,8 @@ func (t *GRPCTransport) Proxy(
 			log.Printf("[GRPCTransport] RPC call failed: method=%s, target=%s, error=%v",
 				method, target, err)
 		}
-		return fmt.Errorf("rpc call failed: %w", err)
+		// 原样返回上游的 status，调用方据此拿到原始的 code 与 message
+		return err
 	}
 
 	if t.enableLog {
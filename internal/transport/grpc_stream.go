@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ProxyStream 以原始字节双向转发 gRPC 流（客户端流、服务端流、双向流，也兼容 Unary）
+// 1. 上游流的 context 继承自 ctx：调用方断开或取消时上游流随之关闭
+// 2. 流式调用通常是长连接，不套用 t.timeout，只保留调用方自身的 deadline
+// 3. 上游的 header 在首条消息前转发，trailer 与最终 status 在结束时转发
+func (t *GRPCTransport) ProxyStream(ctx context.Context, target, method string, desc *grpc.StreamDesc, downstream grpc.ServerStream) error {
+	conn, err := t.getOrCreateConn(target)
+	if err != nil {
+		return err
+	}
+
+	upCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if _, ok := metadata.FromOutgoingContext(upCtx); !ok {
+		if md, ok := metadata.FromIncomingContext(upCtx); ok {
+			upCtx = metadata.NewOutgoingContext(upCtx, md)
+		}
+	}
+
+	upstream, err := grpc.NewClientStream(upCtx, desc, conn, method, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		return err
+	}
+
+	c2s := make(chan error, 1)
+	s2c := make(chan error, 1)
+	go func() { c2s <- forwardClientToServer(downstream, upstream) }()
+	go func() { s2c <- forwardServerToClient(upstream, downstream) }()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-c2s:
+			if err == io.EOF {
+				// 调用方发送完毕，通知上游；继续等待上游的响应与 status
+				upstream.CloseSend()
+				continue
+			}
+			// 读取调用方消息失败（通常是断开），终止上游流
+			cancel()
+			if t.enableLog {
+				log.Printf("[GRPCTransport] Stream aborted: method=%s, target=%s, error=%v", method, target, err)
+			}
+			return status.Errorf(codes.Canceled, "client stream failed: %v", err)
+		case err := <-s2c:
+			downstream.SetTrailer(upstream.Trailer())
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// forwardClientToServer 调用方 -> 上游
+func forwardClientToServer(src grpc.ServerStream, dst grpc.ClientStream) error {
+	for {
+		var frame []byte
+		if err := src.RecvMsg(&frame); err != nil {
+			return err
+		}
+		if err := dst.SendMsg(&frame); err != nil {
+			// 上游已结束（io.EOF），最终 status 由 forwardServerToClient 取得
+			return io.EOF
+		}
+	}
+}
+
+// forwardServerToClient 上游 -> 调用方，首条消息前先转发 header
+func forwardServerToClient(src grpc.ClientStream, dst grpc.ServerStream) error {
+	for first := true; ; first = false {
+		var frame []byte
+		if err := src.RecvMsg(&frame); err != nil {
+			if first {
+				// 没有任何消息时也要把 header 带回去
+				if md, herr := src.Header(); herr == nil && len(md) > 0 {
+					dst.SetHeader(md)
+				}
+			}
+			return err
+		}
+		if first {
+			md, err := src.Header()
+			if err != nil {
+				return err
+			}
+			if err := dst.SendHeader(md); err != nil {
+				return err
+			}
+		}
+		if err := dst.SendMsg(&frame); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var bidiDesc = &grpc.StreamDesc{StreamName: "Chat", ServerStreams: true, ClientStreams: true}
+
+// echoService 后端流式服务：Chat 逐条回显消息，Count 按请求中的字节数返回对应条数的消息
+type echoService struct {
+	canceled chan struct{}
+}
+
+func (s *echoService) chat(srv interface{}, stream grpc.ServerStream) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	stream.SetHeader(metadata.Pairs("x-up", "header"))
+	stream.SetTrailer(metadata.Pairs("x-trailer", "done"))
+	for {
+		var msg []byte
+		if err := stream.RecvMsg(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		switch string(msg) {
+		case "fail":
+			return status.Error(codes.ResourceExhausted, "quota exceeded")
+		case "hang":
+			<-stream.Context().Done()
+			close(s.canceled)
+			return stream.Context().Err()
+		}
+		reply := append([]byte(md.Get("x-client")[0]+":"), msg...)
+		if err := stream.SendMsg(&reply); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *echoService) count(srv interface{}, stream grpc.ServerStream) error {
+	var msg []byte
+	if err := stream.RecvMsg(&msg); err != nil {
+		return err
+	}
+	for i := 0; i < len(msg); i++ {
+		reply := []byte{byte('a' + i)}
+		if err := stream.SendMsg(&reply); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listenGRPC 创建使用透传编解码器的服务与本地监听，注册服务后调用 Serve
+func listenGRPC(t *testing.T, opts ...grpc.ServerOption) (*grpc.Server, net.Listener) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer(append([]grpc.ServerOption{grpc.ForceServerCodec(RawCodec())}, opts...)...)
+	t.Cleanup(s.Stop)
+	return s, lis
+}
+
+// newStreamProxy 启动后端与经 ProxyStream 转发的代理，返回连到代理的客户端连接
+func newStreamProxy(t *testing.T) (*grpc.ClientConn, *echoService) {
+	t.Helper()
+	svc := &echoService{canceled: make(chan struct{})}
+	backend, backendLis := listenGRPC(t)
+	backend.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Echo",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{
+			{StreamName: "Chat", Handler: svc.chat, ServerStreams: true, ClientStreams: true},
+			{StreamName: "Count", Handler: svc.count, ServerStreams: true},
+		},
+	}, svc)
+	go backend.Serve(backendLis)
+	backendAddr := backendLis.Addr().String()
+
+	tr := NewGRPCTransport(5*time.Second, WithGRPCInsecure())
+	t.Cleanup(func() { tr.Close() })
+	proxy, proxyLis := listenGRPC(t, grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+		return tr.ProxyStream(stream.Context(), "grpc://"+backendAddr, method, bidiDesc, stream)
+	}))
+	go proxy.Serve(proxyLis)
+
+	conn, err := grpc.NewClient(proxyLis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(RawCodec())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, svc
+}
+
+func TestProxyStreamBidi(t *testing.T) {
+	conn, _ := newStreamProxy(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-client", "c1")
+	stream, err := conn.NewStream(ctx, bidiDesc, "/test.Echo/Chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"one", "two", "three"} {
+		out := []byte(msg)
+		if err := stream.SendMsg(&out); err != nil {
+			t.Fatal(err)
+		}
+		var in []byte
+		if err := stream.RecvMsg(&in); err != nil {
+			t.Fatal(err)
+		}
+		if string(in) != "c1:"+msg {
+			t.Errorf("reply = %q, want %q", in, "c1:"+msg)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	var in []byte
+	if err := stream.RecvMsg(&in); err != io.EOF {
+		t.Fatalf("final RecvMsg = %v, want io.EOF", err)
+	}
+
+	if md, _ := stream.Header(); len(md.Get("x-up")) != 1 {
+		t.Errorf("header = %v, want x-up forwarded", md)
+	}
+	if md := stream.Trailer(); len(md.Get("x-trailer")) != 1 {
+		t.Errorf("trailer = %v, want x-trailer forwarded", md)
+	}
+}
+
+func TestProxyStreamServerStreaming(t *testing.T) {
+	conn, _ := newStreamProxy(t)
+	stream, err := conn.NewStream(context.Background(), &grpc.StreamDesc{ServerStreams: true}, "/test.Echo/Count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := []byte("xxxx")
+	if err := stream.SendMsg(&req); err != nil {
+		t.Fatal(err)
+	}
+	stream.CloseSend()
+
+	var got string
+	for {
+		var in []byte
+		err := stream.RecvMsg(&in)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got += string(in)
+	}
+	if got != "abcd" {
+		t.Errorf("messages = %q, want abcd", got)
+	}
+}
+
+func TestProxyStreamForwardsStatus(t *testing.T) {
+	conn, _ := newStreamProxy(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-client", "c1")
+	stream, err := conn.NewStream(ctx, bidiDesc, "/test.Echo/Chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("fail")
+	if err := stream.SendMsg(&msg); err != nil {
+		t.Fatal(err)
+	}
+	var in []byte
+	err = stream.RecvMsg(&in)
+	if st, _ := status.FromError(err); st.Code() != codes.ResourceExhausted || st.Message() != "quota exceeded" {
+		t.Errorf("status = %v, want the backend's ResourceExhausted", err)
+	}
+	if md := stream.Trailer(); len(md.Get("x-trailer")) != 1 {
+		t.Errorf("trailer = %v, want x-trailer forwarded with the error", md)
+	}
+}
+
+func TestProxyStreamClientCancelTearsDownUpstream(t *testing.T) {
+	conn, svc := newStreamProxy(t)
+	ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(context.Background(), "x-client", "c1"))
+	stream, err := conn.NewStream(ctx, bidiDesc, "/test.Echo/Chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hang")
+	if err := stream.SendMsg(&msg); err != nil {
+		t.Fatal(err)
+	}
+	// 等待消息到达后端再取消
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-svc.canceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream stream not canceled after the client went away")
+	}
+}
@@ -0,0 +1,143 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// unaryService 后端 Unary 服务：Echo 原样返回请求，Deny 返回 PermissionDenied
+var unaryService = &grpc.ServiceDesc{
+	ServiceName: "test.Unary",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Echo", Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			var msg []byte
+			if err := dec(&msg); err != nil {
+				return nil, err
+			}
+			return &msg, nil
+		}},
+		{MethodName: "Deny", Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			var msg []byte
+			if err := dec(&msg); err != nil {
+				return nil, err
+			}
+			return nil, status.Error(codes.PermissionDenied, "caller may not access this resource")
+		}},
+	},
+}
+
+// startUnaryBackend 启动 Unary 后端，返回其地址
+func startUnaryBackend(t *testing.T, opts ...grpc.ServerOption) string {
+	t.Helper()
+	s, lis := listenGRPC(t, opts...)
+	s.RegisterService(unaryService, struct{}{})
+	go s.Serve(lis)
+	return lis.Addr().String()
+}
+
+// unreachableAddr 返回一个已关闭的本地端口，连接会被拒绝
+func unreachableAddr(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
+}
+
+// callUnary 经 GRPCTransport.Proxy 调用 method，返回后端的回复
+func callUnary(tr *GRPCTransport, target, method string) ([]byte, error) {
+	req, reply := []byte("ping"), []byte(nil)
+	err := tr.Proxy(context.Background(), target, method, &req, &reply, grpc.ForceCodec(RawCodec()))
+	return reply, err
+}
+
+func TestProxyPreservesUpstreamStatus(t *testing.T) {
+	addr := startUnaryBackend(t)
+	tr := NewGRPCTransport(5*time.Second, WithGRPCInsecure())
+	defer tr.Close()
+
+	_, err := callUnary(tr, "grpc://"+addr, "/test.Unary/Deny")
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.PermissionDenied || st.Message() != "caller may not access this resource" {
+		t.Errorf("error = %v, want the backend's PermissionDenied unchanged", err)
+	}
+
+	if reply, err := callUnary(tr, "grpc://"+addr, "/test.Unary/Echo"); err != nil || string(reply) != "ping" {
+		t.Errorf("Echo = %q, %v, want ping", reply, err)
+	}
+}
+
+func TestProxyDialFailureUnavailable(t *testing.T) {
+	tr := NewGRPCTransport(5*time.Second, WithGRPCInsecure())
+	defer tr.Close()
+
+	for _, target := range []string{"", "grpc://" + unreachableAddr(t)} {
+		if _, err := callUnary(tr, target, "/test.Unary/Echo"); status.Code(err) != codes.Unavailable {
+			t.Errorf("target %q: error = %v, want Unavailable", target, err)
+		}
+	}
+}
+
+func TestUnreachableTargetFailFast(t *testing.T) {
+	tr := NewGRPCTransport(2*time.Second, WithGRPCInsecure())
+	defer tr.Close()
+
+	start := time.Now()
+	_, err := callUnary(tr, "grpc://"+unreachableAddr(t), "/test.Unary/Echo")
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("error = %v, want Unavailable", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, want the RPC to fail without waiting for the timeout", elapsed)
+	}
+}
+
+func TestUnreachableTargetWaitForReady(t *testing.T) {
+	tr := NewGRPCTransport(300*time.Millisecond, WithGRPCInsecure(), WithGRPCWaitForReady(true))
+	defer tr.Close()
+
+	// RPC 排队等待连接就绪，直到自身的超时
+	start := time.Now()
+	_, err := callUnary(tr, "grpc://"+unreachableAddr(t), "/test.Unary/Echo")
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("error = %v, want DeadlineExceeded after queueing", err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("returned after %v, want the RPC to wait for its timeout", elapsed)
+	}
+}
+
+func TestUnreachableTargetBlockingDial(t *testing.T) {
+	tr := NewGRPCTransport(5*time.Second, WithGRPCInsecure(), WithGRPCBlockingDial(200*time.Millisecond))
+	defer tr.Close()
+
+	start := time.Now()
+	_, err := callUnary(tr, "grpc://"+unreachableAddr(t), "/test.Unary/Echo")
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("error = %v, want Unavailable", err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("returned after %v, want the dial to block for its timeout", elapsed)
+	}
+	cached := 0
+	tr.connPool.Range(func(any, any) bool { cached++; return true })
+	if cached != 0 {
+		t.Errorf("%d connections cached, want the failed dial discarded", cached)
+	}
+
+	// 可达的 target 在阻塞拨号后正常调用
+	addr := startUnaryBackend(t)
+	if reply, err := callUnary(tr, "grpc://"+addr, "/test.Unary/Echo"); err != nil || string(reply) != "ping" {
+		t.Errorf("Echo after blocking dial = %q, %v, want ping", reply, err)
+	}
+}
@@ -0,0 +1,115 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// selfSignedCert 生成签发给 127.0.0.1 的自签名证书，返回服务端证书与信任它的证书池
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "grpc-backend"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+// startTLSUnaryBackend 启动使用自签名证书的 Unary 后端，返回其地址与信任该证书的证书池
+func startTLSUnaryBackend(t *testing.T) (string, *x509.CertPool) {
+	t.Helper()
+	cert, pool := selfSignedCert(t)
+	addr := startUnaryBackend(t, grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
+	return addr, pool
+}
+
+func TestGRPCProxyTLSBackend(t *testing.T) {
+	addr, pool := startTLSUnaryBackend(t)
+
+	tr := NewGRPCTransport(5*time.Second, WithGRPCTLSConfig(&tls.Config{RootCAs: pool}))
+	defer tr.Close()
+	if reply, err := callUnary(tr, "grpc://"+addr, "/test.Unary/Echo"); err != nil || string(reply) != "ping" {
+		t.Errorf("Echo over TLS = %q, %v, want ping", reply, err)
+	}
+
+	// 默认使用系统根证书，不信任自签名证书
+	untrusted := NewGRPCTransport(5 * time.Second)
+	defer untrusted.Close()
+	if _, err := callUnary(untrusted, "grpc://"+addr, "/test.Unary/Echo"); status.Code(err) != codes.Unavailable {
+		t.Errorf("untrusted certificate: error = %v, want Unavailable", err)
+	}
+
+	// 明文连接无法与 TLS 后端握手
+	plaintext := NewGRPCTransport(5*time.Second, WithGRPCInsecure())
+	defer plaintext.Close()
+	if _, err := callUnary(plaintext, "grpc://"+addr, "/test.Unary/Echo"); status.Code(err) != codes.Unavailable {
+		t.Errorf("plaintext to TLS backend: error = %v, want Unavailable", err)
+	}
+}
+
+func TestGRPCCredentialsPerTarget(t *testing.T) {
+	tlsAddr, pool := startTLSUnaryBackend(t)
+	plainAddr := startUnaryBackend(t)
+
+	var mu sync.Mutex
+	var asked []string
+	tlsCreds := credentials.NewTLS(&tls.Config{RootCAs: pool})
+	tr := NewGRPCTransport(5*time.Second, WithGRPCInsecure(),
+		WithGRPCCredentialsFunc(func(target string) credentials.TransportCredentials {
+			mu.Lock()
+			asked = append(asked, target)
+			mu.Unlock()
+			if target == tlsAddr {
+				return tlsCreds
+			}
+			return nil // 其余 target 使用默认的明文凭证
+		}))
+	defer tr.Close()
+
+	for _, addr := range []string{tlsAddr, plainAddr} {
+		if reply, err := callUnary(tr, "grpc://"+addr, "/test.Unary/Echo"); err != nil || string(reply) != "ping" {
+			t.Errorf("Echo via %s = %q, %v, want ping", addr, reply, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(asked) != 2 || asked[0] != tlsAddr || asked[1] != plainAddr {
+		t.Errorf("credentials requested for %v, want one lookup per target by host:port", asked)
+	}
+}
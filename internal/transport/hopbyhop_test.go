@@ -0,0 +1,91 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// capturingBackend 记录后端收到的请求 header
+func capturingBackend(t *testing.T) (*httptest.Server, <-chan http.Header) {
+	t.Helper()
+	seen := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen <- r.Header.Clone()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, seen
+}
+
+func TestConnectionListedHeaderDropped(t *testing.T) {
+	srv, seen := capturingBackend(t)
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false))
+	defer tr.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Connection", "X-Custom")
+	req.Header.Set("X-Custom", "connection-scoped")
+	req.Header.Set("Authorization", "Bearer user-token")
+	if err := tr.Proxy(context.Background(), srv.URL, req, httptest.NewRecorder()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-seen
+	if v := got.Get("X-Custom"); v != "" {
+		t.Errorf("X-Custom = %q at the backend, want it dropped", v)
+	}
+	if v := got.Get("Authorization"); v != "Bearer user-token" {
+		t.Errorf("Authorization = %q at the backend, want it forwarded", v)
+	}
+}
+
+func TestRemoveHopByHopHeadersKeepsEndToEnd(t *testing.T) {
+	h := http.Header{}
+	h.Set("Connection", "X-Custom, Keep-Alive")
+	h.Set("X-Custom", "connection-scoped")
+	h.Set("Keep-Alive", "timeout=5")
+	h.Set("Proxy-Authenticate", "Basic")
+	h.Set("Te", "trailers")
+	h.Set("Authorization", "Bearer user-token")
+
+	RemoveHopByHopHeaders(h)
+	for _, name := range []string{"Connection", "X-Custom", "Keep-Alive", "Proxy-Authenticate", "Te"} {
+		if v := h.Get(name); v != "" {
+			t.Errorf("%s = %q, want it removed", name, v)
+		}
+	}
+	if v := h.Get("Authorization"); v != "Bearer user-token" {
+		t.Errorf("Authorization = %q, want it kept", v)
+	}
+}
+
+func TestHopByHopHeadersNotForwarded(t *testing.T) {
+	srv, seen := capturingBackend(t)
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false))
+	defer tr.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Connection", "keep-alive, X-Session-Hint")
+	req.Header.Set("X-Session-Hint", "sticky")
+	req.Header.Set("Keep-Alive", "timeout=5")
+	req.Header.Set("Te", "gzip")
+	req.Header.Set("Trailer", "X-Checksum")
+	req.Header.Set("Upgrade", "h2c")
+	req.Header.Set("Proxy-Authorization", "Basic cHJveHk6c2VjcmV0")
+	req.Header.Set("X-End-To-End", "kept")
+	if err := tr.Proxy(context.Background(), srv.URL, req, httptest.NewRecorder()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-seen
+	for _, name := range []string{"X-Session-Hint", "Keep-Alive", "Te", "Trailer", "Upgrade", "Proxy-Authorization"} {
+		if v := got.Get(name); v != "" {
+			t.Errorf("%s = %q at the backend, want it stripped", name, v)
+		}
+	}
+	if v := got.Get("X-End-To-End"); v != "kept" {
+		t.Errorf("X-End-To-End = %q at the backend, want it forwarded", v)
+	}
+}
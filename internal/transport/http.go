@@ -2,11 +2,17 @@ package transport
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"net/http/httputil"
+	"net/textproto"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 )
@@ -24,8 +30,65 @@ type HTTPTransport struct {
 	// 缓存每个 target 的 ReverseProxy 实例，避免重复创建
 	proxyCache sync.Map // map[string]*cachedProxy
 
-	// 日志开关（可选，未来可扩展为接口）
+	// 日志开关，输出目标见 logf
 	enableLog bool
+
+	// 连接池隔离：每个 target 使用独立的 http.Transport
+	isolatedPools bool
+	closeOnce     sync.Once
+	done          chan struct{}
+
+	// DNS 定期刷新（可选）：hostname 形式的 target 解析结果变化时淘汰旧连接
+	dnsRefresh time.Duration
+	dns        *dnsCache
+
+	// 错误响应使用 RFC 7807 problem+json
+	problemJSON bool
+
+	// 转发失败回调（可选，用于指标采集）
+	errorObserver ErrorObserver
+
+	// 转发重试（可选）
+	retry *retryPolicy
+
+	// 单个请求的总超时（可选），0 表示使用 timeout
+	requestTimeout time.Duration
+
+	// 请求 body 缓冲上限（可选），0 表示不缓冲
+	maxBufferedBody int64
+
+	// 按 target 熔断（可选），熔断器独立于 proxyCache，不随空闲淘汰重置
+	breaker  *breakerConfig
+	breakers sync.Map // map[string]*circuitBreaker
+
+	// 请求 ID header（可选），为空表示不处理请求 ID
+	requestIDHeader string
+
+	// 日志输出，默认 log.Printf
+	logf LogFunc
+
+	// 连接 https 后端的 TLS 配置（可选）
+	tlsConfig *tls.Config
+
+	// 对 https 后端通过 ALPN 协商 HTTP/2（可选）
+	http2 bool
+
+	// 受信任的上游代理（可选），为空表示不信任任何上游的转发 header
+	trustedProxies []*net.IPNet
+
+	// 请求/响应改写钩子（可选），按添加顺序执行
+	requestModifiers  []func(*http.Request)
+	responseModifiers []func(*http.Response) error
+
+	// 响应压缩（可选），见 WithCompression
+	compression     bool
+	compressMinSize int64
+
+	// 连接池统计（可选），见 WithConnStats
+	conns *connStats
+
+	// 响应 body 上限（可选），见 WithMaxResponseBody
+	maxResponseBody int64
 }
 
 type cachedProxy struct {
@@ -33,52 +96,244 @@ type cachedProxy struct {
 	target  *url.URL
 	mu      sync.RWMutex
 	lastUse time.Time
+
+	// 隔离模式下该 target 独占的 Transport，共享模式下为 nil
+	transport *http.Transport
+
+	// 排空状态：target 被移除后等待进行中的请求完成再关闭空闲连接
+	inflight int
+	draining bool
+}
+
+// HTTPOption HTTPTransport 配置选项
+type HTTPOption func(*HTTPTransport)
+
+// WithIsolatedPools 为每个 target 创建独立的 http.Transport（独立连接池）
+// 避免某个后端的突发流量占满连接池影响其他后端。
+// 代价：每个 target 各自维护空闲连接（最多 MaxIdleConnsPerHost 个），
+// 内存占用随 target 数量线性增长；超过 5 分钟未使用的 target 会被淘汰并关闭其连接池。
+func WithIsolatedPools(enabled bool) HTTPOption {
+	return func(t *HTTPTransport) {
+		t.isolatedPools = enabled
+	}
+}
+
+// WithDNSRefresh 启用 DNS 解析缓存，并每隔 interval 重新解析 target 的 hostname
+// IP 集合变化时淘汰该 target 的 ReverseProxy 缓存并关闭空闲连接，
+// 使后续请求连接到新的 IP（适用于 headless service 等滚动更新场景）。
+// 共享连接池模式下无法只关闭单个 host 的连接，会关闭全部空闲连接。
+// target 被移除或因空闲被淘汰、且没有其他 target 使用同一 hostname 时，该 hostname 不再刷新。
+func WithDNSRefresh(interval time.Duration) HTTPOption {
+	return func(t *HTTPTransport) {
+		t.dnsRefresh = interval
+	}
+}
+
+// WithProblemJSON 转发失败时以 application/problem+json 返回错误
+func WithProblemJSON(enabled bool) HTTPOption {
+	return func(t *HTTPTransport) {
+		t.problemJSON = enabled
+	}
+}
+
+// ErrorObserver 转发失败回调：req 为发往后端的请求，status 为写回客户端的状态码
+type ErrorObserver func(req *http.Request, status int, err error)
+
+// WithErrorObserver 转发失败（连接错误、超时等）时回调 fn
+func WithErrorObserver(fn ErrorObserver) HTTPOption {
+	return func(t *HTTPTransport) {
+		t.errorObserver = fn
+	}
+}
+
+// WithTLSConfig 连接 https 后端时使用的 TLS 配置（如私有 CA 的 RootCAs、客户端证书）
+// ServerName 留空时按 target 的 host 做 SNI 与证书校验；配置会被复制，之后修改 cfg 不影响传输层
+func WithTLSConfig(cfg *tls.Config) HTTPOption {
+	return func(t *HTTPTransport) {
+		if cfg != nil {
+			t.tlsConfig = cfg.Clone()
+		}
+	}
 }
 
-func NewHTTPTransport(timeout time.Duration) *HTTPTransport {
+// WithInsecureSkipVerify 跳过后端证书校验，仅用于开发环境
+func WithInsecureSkipVerify() HTTPOption {
+	return func(t *HTTPTransport) {
+		if t.tlsConfig == nil {
+			t.tlsConfig = &tls.Config{}
+		}
+		t.tlsConfig.InsecureSkipVerify = true
+	}
+}
+
+// WithHTTP2 对 https 后端通过 ALPN 协商 HTTP/2（默认仅使用 HTTP/1.1）
+// 明文 http 后端仍使用 HTTP/1.1；协议升级请求（如 WebSocket）始终走 HTTP/1.1 连接。
+// HTTP/2 下同一后端的请求复用少量多路复用连接，MaxIdleConnsPerHost 不再限制并发；
+// 空闲淘汰、target 排空等关闭空闲连接的逻辑同样适用于 HTTP/2 连接。
+func WithHTTP2(enabled bool) HTTPOption {
+	return func(t *HTTPTransport) {
+		t.http2 = enabled
+	}
+}
+
+// WithRequestModifier 改写发往后端的请求，每个请求在默认 Director 之后执行，
+// 因此可以覆盖默认设置为 target 的 Host（r.Host）、注入内部认证 header 或删除 header。
+// 多次调用按顺序执行；ReverseProxy 在其后仍会移除 hop-by-hop header，无法通过它添加
+func WithRequestModifier(fn func(*http.Request)) HTTPOption {
+	return func(t *HTTPTransport) {
+		if fn != nil {
+			t.requestModifiers = append(t.requestModifiers, fn)
+		}
+	}
+}
+
+// WithResponseModifier 改写后端返回的响应（ReverseProxy.ModifyResponse），多次调用按顺序执行；
+// 返回错误时不再执行后续钩子，按转发失败处理（502）
+func WithResponseModifier(fn func(*http.Response) error) HTTPOption {
+	return func(t *HTTPTransport) {
+		if fn != nil {
+			t.responseModifiers = append(t.responseModifiers, fn)
+		}
+	}
+}
+
+// WithRequestTimeout 单个转发请求的总时长上限（含读取响应 body），与拨号、等待响应头的超时相互独立；
+// 未设置时使用构造时传入的 timeout。调用方的 ctx 带有更早的截止时间时以较早者为准，超时返回 504
+func WithRequestTimeout(d time.Duration) HTTPOption {
+	return func(t *HTTPTransport) {
+		t.requestTimeout = d
+	}
+}
+
+// WithHTTPLogging 开关传输层日志（默认开启），关闭后转发错误、连接淘汰等日志都不再输出
+func WithHTTPLogging(enabled bool) HTTPOption {
+	return func(t *HTTPTransport) {
+		t.enableLog = enabled
+	}
+}
+
+// LogFunc 传输层日志输出函数
+type LogFunc func(format string, args ...interface{})
+
+// WithLogFunc 自定义传输层日志输出（默认 log.Printf），例如接入结构化日志
+func WithLogFunc(fn LogFunc) HTTPOption {
+	return func(t *HTTPTransport) {
+		if fn != nil {
+			t.logf = fn
+		}
+	}
+}
+
+// logIfEnabled 日志开启时输出，供不持有 HTTPTransport 的组件（如 DNS 缓存）使用
+func (t *HTTPTransport) logIfEnabled(format string, args ...interface{}) {
+	if t.enableLog {
+		t.logf(format, args...)
+	}
+}
+
+func NewHTTPTransport(timeout time.Duration, opts ...HTTPOption) *HTTPTransport {
 	if timeout == 0 {
 		timeout = 30 * time.Second
 	}
-	return &HTTPTransport{
+	t := &HTTPTransport{
 		timeout:   timeout,
 		enableLog: true, // 默认启用日志
+		done:      make(chan struct{}),
+		logf:      log.Printf,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	// 隔离模式下需要定期淘汰空闲 target，释放其独立连接池
+	if t.isolatedPools {
+		go t.cleanupIdleProxies()
+	}
+
+	if t.dnsRefresh > 0 {
+		t.dns = newDNSCache(newDialer(), t.logIfEnabled)
+		go t.refreshDNS()
 	}
+
+	return t
 }
 
 // getTransport 获取共享的 http.Transport 实例，配置连接池参数
 // 使用单例模式确保全局只有一个 Transport 实例，所有连接共享连接池
 func (t *HTTPTransport) getTransport() *http.Transport {
 	t.once.Do(func() {
-		dialer := &net.Dialer{
-			Timeout:   10 * time.Second,
-			KeepAlive: 30 * time.Second,
-			// 不指定 LocalAddr，让系统自动分配端口，避免端口占用冲突
-			// 系统会自动选择可用端口，避免 "connectex" 错误
-		}
-
-		t.transport = &http.Transport{
-			Proxy:       http.ProxyFromEnvironment,
-			DialContext: dialer.DialContext,
-			// 连接池配置：支持大量并发连接
-			MaxIdleConns:          1000,             // 最大空闲连接数
-			MaxIdleConnsPerHost:   100,              // 每个 host 的最大空闲连接数（降低以避免端口耗尽）
-			MaxConnsPerHost:       0,                // 0 表示不限制每个 host 的总连接数
-			IdleConnTimeout:       90 * time.Second, // 空闲连接超时（增加以支持长连接复用）
-			TLSHandshakeTimeout:   10 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-			ResponseHeaderTimeout: t.timeout,
-			// 关键配置：启用连接复用
-			DisableKeepAlives:  false, // 必须为 false，启用 Keep-Alive
-			ForceAttemptHTTP2:  false, // 当前仅支持 HTTP/1.1，未来可扩展 HTTP/2
-			DisableCompression: false,
-		}
+		t.transport = t.newTransport()
 	})
 	return t.transport
 }
 
+// RoundTripper 返回共享的 http.Transport（含 TLS、DNS 刷新与连接池配置），
+// 供代理转发以外的出站请求（如流量镜像）复用
+func (t *HTTPTransport) RoundTripper() http.RoundTripper {
+	return t.trackConns(t.getTransport())
+}
+
+// trackConns 启用连接池统计时记录请求占用连接的区间
+func (t *HTTPTransport) trackConns(rt http.RoundTripper) http.RoundTripper {
+	if t.conns == nil {
+		return rt
+	}
+	return &connTracker{next: rt, stats: t.conns}
+}
+
+// newTransport 按统一的连接池参数创建 http.Transport
+func (t *HTTPTransport) newTransport() *http.Transport {
+	dialContext := newDialer().DialContext
+	if t.dns != nil {
+		dialContext = t.dns.DialContext
+	}
+	if t.conns != nil {
+		dialContext = t.conns.dial(dialContext)
+	}
+
+	// 每个 Transport 使用独立的副本，http.Transport 会修改 TLSClientConfig（如 NextProtos）
+	var tlsConfig *tls.Config
+	if t.tlsConfig != nil {
+		tlsConfig = t.tlsConfig.Clone()
+	}
+
+	// Expect: 100-continue：ExpectContinueTimeout 内等后端返回 100 后才读取入站请求体，
+	// 入站 http.Server 在请求体首次被读取时才向客户端发送 100 Continue；
+	// 后端在读 body 前直接拒绝（如 413）时，客户端收到该最终响应且无需上传 body
+	return &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		DialContext:     dialContext,
+		TLSClientConfig: tlsConfig,
+		// 连接池配置：支持大量并发连接
+		MaxIdleConns:          1000,             // 最大空闲连接数
+		MaxIdleConnsPerHost:   100,              // 每个 host 的最大空闲连接数（降低以避免端口耗尽）
+		MaxConnsPerHost:       0,                // 0 表示不限制每个 host 的总连接数
+		IdleConnTimeout:       90 * time.Second, // 空闲连接超时（增加以支持长连接复用）
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ResponseHeaderTimeout: t.timeout,
+		// 关键配置：启用连接复用
+		DisableKeepAlives: false, // 必须为 false，启用 Keep-Alive
+		// 自定义了 DialContext / TLSClientConfig 时需要显式开启才会协商 HTTP/2；
+		// 开启后 Transport 会在 TLS 握手的 ALPN 中加入 h2，升级请求单独使用仅 HTTP/1.1 的连接
+		ForceAttemptHTTP2:  t.http2,
+		DisableCompression: false,
+	}
+}
+
+// newDialer 创建拨号器
+func newDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		// 不指定 LocalAddr，让系统自动分配端口，避免端口占用冲突
+		// 系统会自动选择可用端口，避免 "connectex" 错误
+	}
+}
+
 // getOrCreateProxy 获取或创建指定 target 的 ReverseProxy 实例
 // 使用缓存避免重复创建，确保连接管理的稳定性
-func (t *HTTPTransport) getOrCreateProxy(targetURL *url.URL) *httputil.ReverseProxy {
+func (t *HTTPTransport) getOrCreateProxy(targetURL *url.URL) *cachedProxy {
 	// 使用 target 的完整 URL（scheme + host + path）作为 key
 	targetKey := targetURL.String()
 
@@ -88,7 +343,7 @@ func (t *HTTPTransport) getOrCreateProxy(targetURL *url.URL) *httputil.ReversePr
 		cp.mu.Lock()
 		cp.lastUse = time.Now()
 		cp.mu.Unlock()
-		return cp.proxy
+		return cp
 	}
 
 	// 缓存未命中，创建新的 ReverseProxy
@@ -99,6 +354,9 @@ func (t *HTTPTransport) getOrCreateProxy(targetURL *url.URL) *httputil.ReversePr
 
 	// 自定义 Director：正确设置请求信息并保留所有 headers
 	proxy.Director = func(r *http.Request) {
+		// 客户端请求的原始 Host，用于 X-Forwarded-Host / Forwarded
+		origHost := r.Host
+
 		// 先调用原始 Director（这会设置基本的 URL 和 headers）
 		origDirector(r)
 
@@ -116,6 +374,9 @@ func (t *HTTPTransport) getOrCreateProxy(targetURL *url.URL) *httputil.ReversePr
 		// 确保连接复用
 		r.Close = false
 
+		// 转发 header：X-Forwarded-Proto / Host 与 Forwarded，按上游是否受信任决定保留或重置
+		t.setForwardedHeaders(r, origHost)
+
 		// 对于有 body 的请求，确保 Content-Length 正确设置
 		// ReverseProxy 会自动处理，但我们需要确保 body 没有被提前消费
 		if r.Body != nil {
@@ -123,63 +384,277 @@ func (t *HTTPTransport) getOrCreateProxy(targetURL *url.URL) *httputil.ReversePr
 			// 我们只需要确保 body 存在即可
 		}
 
-		// 移除可能干扰的 headers（如果需要）
-		// r.Header.Del("Connection") // 不删除，让 Transport 管理
+		// hop-by-hop headers（RFC 7230 6.1）无需在此处理：
+		// ReverseProxy 在 Director 之后会移除 Connection、Keep-Alive、Proxy-Authenticate、Proxy-Authorization、
+		// Te（保留 "trailers"）、Trailer、Transfer-Encoding、Upgrade 以及 Connection 中列出的 header，响应方向同样处理；
+		// Authorization、Cookie 等端到端 header 原样转发。
+		// 协议升级（如 WebSocket）时会在移除后重新设置 Connection/Upgrade。
+		// 因此这里不要提前删除 Connection/Upgrade，否则 ReverseProxy 无法识别升级请求。
+
+		// 用户钩子在默认设置之后执行，可以覆盖 Host 等字段
+		for _, modify := range t.requestModifiers {
+			modify(r)
+		}
 	}
 
-	// 使用共享的 Transport，支持连接复用
-	proxy.Transport = t.getTransport()
+	// 使用共享的 Transport，支持连接复用；隔离模式下使用独立的 Transport
+	var ownTransport *http.Transport
+	if t.isolatedPools {
+		ownTransport = t.newTransport()
+		proxy.Transport = ownTransport
+	} else {
+		proxy.Transport = t.getTransport()
+	}
+	proxy.Transport = t.trackConns(proxy.Transport)
+	// 未配置 WithRetry 时同样包装，请求可以通过 RequestPolicy 单独开启重试
+	proxy.Transport = &retryTransport{next: proxy.Transport, policy: t.retry}
+
+	if t.requestIDHeader != "" || len(t.responseModifiers) > 0 || t.compression || t.maxResponseBody > 0 {
+		proxy.ModifyResponse = func(res *http.Response) error {
+			// 响应已带上请求 ID，丢弃后端回显的同名 header，避免重复
+			if t.requestIDHeader != "" {
+				res.Header.Del(t.requestIDHeader)
+			}
+			for _, modify := range t.responseModifiers {
+				if err := modify(res); err != nil {
+					return err
+				}
+			}
+			// 压缩在用户钩子之后进行，钩子看到的是未压缩的响应
+			if t.compression {
+				t.compressResponse(res)
+			}
+			// 按最终写给客户端的长度判断，压缩后不再声明长度的响应改为转发时计数
+			return t.limitResponse(res)
+		}
+	}
 
 	// 自定义错误处理
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, e error) {
 		// 如果响应头还没写，才写错误响应
 		if w.Header().Get("Content-Type") == "" {
 			if t.enableLog {
-				log.Printf("[HTTPTransport] Proxy error for %s -> %s: %v",
-					r.URL.Path, targetURL.String(), e)
+				t.logf("[HTTPTransport] Proxy error for %s -> %s (request_id=%s): %v",
+					r.URL.Path, targetURL.String(), t.requestID(r), e)
+			}
+			status := t.writeError(w, e)
+			if t.errorObserver != nil {
+				t.errorObserver(r, status, e)
 			}
-			w.WriteHeader(http.StatusBadGateway)
-			w.Write([]byte("proxy error: " + e.Error()))
 		}
 	}
 
 	// 缓存新的 proxy 实例
 	cp := &cachedProxy{
-		proxy:   proxy,
-		target:  targetURL,
-		lastUse: time.Now(),
+		proxy:     proxy,
+		target:    targetURL,
+		lastUse:   time.Now(),
+		transport: ownTransport,
+	}
+	if actual, loaded := t.proxyCache.LoadOrStore(targetKey, cp); loaded {
+		// 并发创建时以先写入的为准，释放本次多余的 Transport
+		if ownTransport != nil {
+			ownTransport.CloseIdleConnections()
+		}
+		return actual.(*cachedProxy)
 	}
-	t.proxyCache.Store(targetKey, cp)
 
-	return proxy
+	return cp
+}
+
+// writeError 输出转发错误：超时返回 504，其他错误返回 502，返回写出的状态码
+func (t *HTTPTransport) writeError(w http.ResponseWriter, e error) int {
+	status := http.StatusBadGateway
+	var netErr net.Error
+	if errors.Is(e, context.DeadlineExceeded) || (errors.As(e, &netErr) && netErr.Timeout()) {
+		status = http.StatusGatewayTimeout
+	}
+	t.writeErrorStatus(w, status, e)
+	return status
+}
+
+// writeErrorStatus 以指定状态码输出转发错误
+func (t *HTTPTransport) writeErrorStatus(w http.ResponseWriter, status int, e error) {
+	if t.problemJSON {
+		body, _ := json.Marshal(map[string]interface{}{
+			"type":   "about:blank",
+			"title":  "proxy error",
+			"status": status,
+			"detail": e.Error(),
+		})
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(status)
+		w.Write(body)
+		return
+	}
+
+	w.WriteHeader(status)
+	w.Write([]byte("proxy error: " + e.Error()))
+}
+
+// cleanupIdleProxies 定期淘汰超过 5 分钟未使用的 target（仅隔离模式）
+func (t *HTTPTransport) cleanupIdleProxies() {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			t.proxyCache.Range(func(key, value interface{}) bool {
+				cp := value.(*cachedProxy)
+				cp.mu.RLock()
+				idle := now.Sub(cp.lastUse)
+				cp.mu.RUnlock()
+
+				if idle > 5*time.Minute {
+					t.proxyCache.Delete(key)
+					if cp.transport != nil {
+						cp.transport.CloseIdleConnections()
+					}
+					t.forgetHost(cp.target.Hostname())
+					if t.enableLog {
+						t.logf("[HTTPTransport] Evicted idle target %s", cp.target.String())
+					}
+				}
+				return true
+			})
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// refreshDNS 定期刷新 DNS 缓存，解析结果变化时淘汰对应 target
+func (t *HTTPTransport) refreshDNS() {
+	ticker := time.NewTicker(t.dnsRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, host := range t.dns.refresh() {
+				t.evictHost(host)
+			}
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// evictHost 淘汰指向 host 的所有 ReverseProxy 缓存，并关闭相关空闲连接
+// 正在进行中的请求不受影响，会在旧连接上完成
+func (t *HTTPTransport) evictHost(host string) {
+	closeShared := false
+	t.proxyCache.Range(func(key, value interface{}) bool {
+		cp := value.(*cachedProxy)
+		if cp.target.Hostname() != host {
+			return true
+		}
+		t.proxyCache.Delete(key)
+		if cp.transport != nil {
+			cp.transport.CloseIdleConnections()
+		} else {
+			closeShared = true
+		}
+		return true
+	})
+
+	if closeShared && t.transport != nil {
+		t.transport.CloseIdleConnections()
+	}
+	t.forgetHost(host)
+
+	if t.enableLog {
+		t.logf("[HTTPTransport] DNS changed for %s, evicted cached connections", host)
+	}
+}
+
+// forgetHost 已没有缓存的 ReverseProxy 指向 host 时，从 DNS 缓存中删除 host，停止定期解析
+func (t *HTTPTransport) forgetHost(host string) {
+	if t.dns == nil {
+		return
+	}
+	inUse := false
+	t.proxyCache.Range(func(_, value interface{}) bool {
+		inUse = value.(*cachedProxy).target.Hostname() == host
+		return !inUse
+	})
+	if !inUse {
+		t.dns.forget(host)
+	}
 }
 
 // Proxy 执行代理转发
 // 核心方法：根据 target 地址转发请求到后端服务
 func (t *HTTPTransport) Proxy(ctx context.Context, target string, req *http.Request, w http.ResponseWriter) error {
+	// 请求 ID 在所有分支之前确定，错误响应同样携带
+	requestID := t.ensureRequestID(req, w)
+
 	// 解析 target URL
 	targetURL, err := url.Parse(target)
 	if err != nil {
 		if t.enableLog {
-			log.Printf("[HTTPTransport] Invalid target URL: %s, error: %v", target, err)
+			t.logf("[HTTPTransport] Invalid target URL: %s (request_id=%s), error: %v", target, requestID, err)
 		}
 		return err
 	}
 
 	// 关键修复：创建一个新的 context，使用独立的超时控制
 	// 这样可以避免 Gin 的 context 被提前取消导致 "context canceled" 错误
-	// 使用 timeout 作为超时时间，确保请求有足够时间完成
-	// 注意：我们不继承原 context 的取消信号，因为 Gin 的 context 可能在请求完成前被取消
-	proxyCtx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	// 使用 requestTimeout（未设置时为 timeout）作为超时时间，确保请求有足够时间完成
+	// 注意：我们不继承原 context 的取消信号，因为 Gin 的 context 可能在请求完成前被取消；
+	// 但调用方设置的更早的截止时间会被沿用
+	proxyCtx, cancel := context.WithDeadline(context.Background(), t.deadline(ctx))
+	if isUpgradeRequest(req) {
+		// 协议升级后连接会一直保持，ReverseProxy 在 context 结束时关闭升级后的连接，
+		// 因此不能套用请求超时；连接由任一端关闭时结束。
+		// 升级后的连接由 ReverseProxy 接管，不会回到 Transport 的空闲连接池，不影响连接复用。
+		cancel()
+		proxyCtx, cancel = context.WithCancel(context.Background())
+	}
 	defer cancel()
+	// 调用方的转发参数（如按 color 的策略）随新的 context 传给重试层
+	if policy, ok := ctx.Value(requestPolicyKey{}).(RequestPolicy); ok {
+		proxyCtx = WithRequestPolicy(proxyCtx, policy)
+	}
+	// 响应 body 上限的计数状态，由 ModifyResponse 包装 body 时取出
+	var limit *responseLimit
+	if t.maxResponseBody > 0 {
+		limit = &responseLimit{max: t.maxResponseBody}
+		proxyCtx = context.WithValue(proxyCtx, responseLimitKey{}, limit)
+	}
 
-	// 获取或创建 ReverseProxy 实例
-	proxy := t.getOrCreateProxy(targetURL)
+	// 熔断打开：直接返回 503，不再拨号
+	breaker := t.getBreaker(targetURL.String())
+	if breaker != nil && !breaker.allow(req) {
+		t.writeErrorStatus(w, http.StatusServiceUnavailable, ErrCircuitOpen)
+		if t.errorObserver != nil {
+			t.errorObserver(req, http.StatusServiceUnavailable, ErrCircuitOpen)
+		}
+		return nil
+	}
+
+	// 获取或创建 ReverseProxy 实例，并登记为进行中的请求
+	cp := t.getOrCreateProxy(targetURL)
+	cp.mu.Lock()
+	cp.inflight++
+	cp.mu.Unlock()
+	defer t.release(cp)
+
+	// 缓冲请求 body，使重试与镜像可以重放 body
+	cleanupBody, err := t.bufferBody(req)
+	if err != nil {
+		t.writeErrorStatus(w, http.StatusBadRequest, fmt.Errorf("read request body: %w", err))
+		return nil
+	}
+	defer cleanupBody()
 
 	// 创建响应包装器以记录状态码
 	responseWriter := &responseWriterWrapper{
 		ResponseWriter: w,
 		statusCode:     http.StatusOK, // 默认状态码
+		head:           req.Method == http.MethodHead,
 	}
 
 	// 关键修复：对于 POST/PUT/PATCH 等有 body 的请求，确保 body 可以被读取
@@ -188,19 +663,103 @@ func (t *HTTPTransport) Proxy(ctx context.Context, target string, req *http.Requ
 	proxyReq := req.WithContext(proxyCtx)
 
 	// 执行代理转发
-	proxy.ServeHTTP(responseWriter, proxyReq)
+	if breaker != nil {
+		// 确保半开探测请求一定会记录结果（含 panic），否则熔断器会停留在半开状态
+		success := false
+		defer func() { breaker.record(req, success) }()
+		cp.proxy.ServeHTTP(responseWriter, proxyReq)
+		success = !breakerFailure(responseWriter.statusCode)
+		return limit.err()
+	}
+	cp.proxy.ServeHTTP(responseWriter, proxyReq)
 
-	return nil
+	return limit.err()
+}
+
+// deadline 本次转发的截止时间：RequestPolicy.Timeout（未设置时为 requestTimeout，再未设置时为 timeout）与 ctx 截止时间中较早者
+func (t *HTTPTransport) deadline(ctx context.Context) time.Time {
+	timeout := requestPolicyFrom(ctx).Timeout
+	if timeout <= 0 {
+		timeout = t.requestTimeout
+	}
+	if timeout <= 0 {
+		timeout = t.timeout
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	return deadline
+}
+
+// release 请求结束；若 target 正在排空且已无进行中的请求，关闭其空闲连接
+func (t *HTTPTransport) release(cp *cachedProxy) {
+	cp.mu.Lock()
+	cp.inflight--
+	drained := cp.draining && cp.inflight == 0
+	cp.mu.Unlock()
+
+	if drained {
+		t.closeIdle(cp)
+	}
+}
+
+// closeIdle 关闭 target 独立连接池中的空闲连接
+// 共享连接池无法只关闭单个 host 的连接，不做处理，该 host 的空闲连接由 IdleConnTimeout 回收，
+// 避免移除一个 target 时断开其他后端的 keep-alive 连接
+func (t *HTTPTransport) closeIdle(cp *cachedProxy) {
+	if cp.transport != nil {
+		cp.transport.CloseIdleConnections()
+	}
+}
+
+// RemoveTarget 移除 target：从缓存中删除其 ReverseProxy 并标记为排空，
+// 进行中的请求照常完成，全部完成后关闭其空闲连接（仅限按 target 独立的连接池）
+func (t *HTTPTransport) RemoveTarget(target string) {
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return
+	}
+
+	t.breakers.Delete(targetURL.String())
+
+	value, ok := t.proxyCache.LoadAndDelete(targetURL.String())
+	if !ok {
+		return
+	}
+	cp := value.(*cachedProxy)
+	t.forgetHost(targetURL.Hostname())
+
+	cp.mu.Lock()
+	cp.draining = true
+	idle := cp.inflight == 0
+	cp.mu.Unlock()
+
+	if idle {
+		t.closeIdle(cp)
+	}
+
+	if t.enableLog {
+		t.logf("[HTTPTransport] Removed target %s (draining)", targetURL.String())
+	}
 }
 
 // responseWriterWrapper 包装 http.ResponseWriter 以记录状态码
+// HEAD 请求的响应不写出 body（后端误返回 body 或 Gin 等框架的 ResponseWriter 不会自动丢弃时）
 type responseWriterWrapper struct {
 	http.ResponseWriter
 	statusCode  int
 	wroteHeader bool
+	head        bool
 }
 
 func (w *responseWriterWrapper) WriteHeader(code int) {
+	// ReverseProxy 会转发后端的 1xx 信息响应（如 100 Continue、103 Early Hints），
+	// 之后还有最终状态码，不能记为响应状态；101 是最终响应
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
 	if !w.wroteHeader {
 		w.statusCode = code
 		w.wroteHeader = true
@@ -212,18 +771,35 @@ func (w *responseWriterWrapper) Write(b []byte) (int, error) {
 	if !w.wroteHeader {
 		w.WriteHeader(http.StatusOK)
 	}
+	if w.head {
+		return len(b), nil
+	}
 	return w.ResponseWriter.Write(b)
 }
 
+// Unwrap 暴露底层 ResponseWriter：ReverseProxy 通过 http.ResponseController 进行
+// Hijack（协议升级，如 WebSocket）和 Flush（流式响应），包装层缺少 Unwrap 时升级会失败
+func (w *responseWriterWrapper) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // Close 关闭 Transport 并清理所有空闲连接和缓存
 func (t *HTTPTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.done)
+	})
+
 	// 关闭 Transport 的所有空闲连接
 	if t.transport != nil {
 		t.transport.CloseIdleConnections()
 	}
 
 	// 清理缓存（可选，通常不需要，因为程序退出时自动清理）
+	// 隔离模式下同时关闭每个 target 独立的连接池
 	t.proxyCache.Range(func(key, value interface{}) bool {
+		if cp := value.(*cachedProxy); cp.transport != nil {
+			cp.transport.CloseIdleConnections()
+		}
 		t.proxyCache.Delete(key)
 		return true
 	})
@@ -231,6 +807,49 @@ func (t *HTTPTransport) Close() error {
 	return nil
 }
 
+// isUpgradeRequest 判断是否为协议升级请求（Connection: Upgrade + Upgrade header）
+func isUpgradeRequest(req *http.Request) bool {
+	if req.Header.Get("Upgrade") == "" {
+		return false
+	}
+	for _, v := range req.Header.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hopByHopHeaders RFC 7230 6.1 定义的逐跳 header，不应转发给下一跳
+var hopByHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// RemoveHopByHopHeaders 删除逐跳 header 以及 Connection 中列出的 header，Authorization 等端到端 header 保持不变
+// ReverseProxy 转发时会自行处理；不经过 ReverseProxy 自行构造的转发请求（如流量镜像）需调用它
+func RemoveHopByHopHeaders(h http.Header) {
+	for _, v := range h.Values("Connection") {
+		for _, name := range strings.Split(v, ",") {
+			if name = textproto.TrimString(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		h.Del(name)
+	}
+}
+
 // singleJoiningSlash 合并两个路径，正确处理斜杠
 func singleJoiningSlash(a, b string) string {
 	aslash := len(a) > 0 && a[len(a)-1] == '/'
@@ -0,0 +1,82 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrResponseTooLarge 后端响应 body 超过 WithMaxResponseBody 的上限
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// WithMaxResponseBody 限制转发给客户端的响应 body 不超过 n 字节，0 表示不限制。
+// 声明了 Content-Length 且超过上限的响应直接返回 502；未声明长度（chunked 等流式响应）时边转发边计数，
+// 达到上限后停止读取后端，Proxy 返回 ErrResponseTooLarge（此时响应头与上限以内的 body 已写出），
+// 由调用方中断与客户端的连接，避免客户端把截断的 body 当作完整响应。
+// 启用 WithCompression 时按压缩后的字节数计数；HEAD 请求与协议升级的连接不受限制。
+func WithMaxResponseBody(n int64) HTTPOption {
+	return func(t *HTTPTransport) {
+		t.maxResponseBody = max(n, 0)
+	}
+}
+
+type responseLimitKey struct{}
+
+// responseLimit 单次转发的响应 body 上限与是否已截断
+type responseLimit struct {
+	max      int64
+	exceeded bool
+}
+
+// err 响应 body 被截断时返回 ErrResponseTooLarge，未启用上限（nil）时返回 nil
+func (l *responseLimit) err() error {
+	if l == nil || !l.exceeded {
+		return nil
+	}
+	return fmt.Errorf("%w: exceeds %d bytes", ErrResponseTooLarge, l.max)
+}
+
+// limitResponse 在 ModifyResponse 的最后执行：声明的长度已超过上限时返回错误，交给 ErrorHandler 返回 502；
+// 否则包装 body 按读取的字节数计数
+func (t *HTTPTransport) limitResponse(res *http.Response) error {
+	if res.Request == nil || res.Request.Method == http.MethodHead || res.StatusCode == http.StatusSwitchingProtocols {
+		return nil
+	}
+	limit, ok := res.Request.Context().Value(responseLimitKey{}).(*responseLimit)
+	if !ok {
+		return nil
+	}
+	if res.ContentLength > limit.max {
+		return fmt.Errorf("%w: content-length %d exceeds %d", ErrResponseTooLarge, res.ContentLength, limit.max)
+	}
+	res.Body = &limitedBody{ReadCloser: res.Body, remaining: limit.max, limit: limit}
+	return nil
+}
+
+// limitedBody 读到上限后返回 io.EOF 并标记截断：ReverseProxy 按正常结束处理，不再继续读取后端，
+// 是否截断由 Proxy 在转发结束后通过 responseLimit 判断
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	limit     *responseLimit
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.limit.exceeded {
+		return 0, io.EOF
+	}
+	// 多读一个字节，区分恰好等于上限与超过上限
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		b.limit.exceeded = true
+		return n, io.EOF
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,134 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// headerBackend 把收到的 Host 与部分请求 header 回写到响应 header，并附带一个内部 header
+func headerBackend(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Seen-Host", r.Host)
+		w.Header().Set("X-Seen-Auth", r.Header.Get("X-Internal-Auth"))
+		w.Header().Set("X-Seen-Secret", r.Header.Get("X-Client-Secret"))
+		w.Header().Set("X-Backend-Internal", "leak")
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRequestModifierAddsAndStripsHeaders(t *testing.T) {
+	srv := headerBackend(t)
+	var calls atomic.Int32
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false), WithRequestModifier(func(r *http.Request) {
+		calls.Add(1)
+		r.Host = "backend.internal"
+		r.Header.Set("X-Internal-Auth", "service-token")
+		r.Header.Del("X-Client-Secret")
+	}))
+	defer tr.Close()
+
+	// 第二次请求复用缓存的 ReverseProxy，钩子仍按请求执行
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Client-Secret", "do-not-forward")
+		rec := httptest.NewRecorder()
+		if err := tr.Proxy(context.Background(), srv.URL, req, rec); err != nil {
+			t.Fatal(err)
+		}
+		if got := rec.Header().Get("X-Seen-Host"); got != "backend.internal" {
+			t.Errorf("backend Host = %q, want the modifier to override the target host", got)
+		}
+		if got := rec.Header().Get("X-Seen-Auth"); got != "service-token" {
+			t.Errorf("backend X-Internal-Auth = %q, want service-token", got)
+		}
+		if got := rec.Header().Get("X-Seen-Secret"); got != "" {
+			t.Errorf("backend X-Client-Secret = %q, want it stripped", got)
+		}
+		if req.Header.Get("X-Client-Secret") == "" {
+			t.Error("modifier changed the caller's request")
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("request modifier ran %d times, want once per request", calls.Load())
+	}
+}
+
+func TestRequestModifierDefaultHost(t *testing.T) {
+	srv := headerBackend(t)
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false), WithRequestModifier(func(r *http.Request) {}))
+	defer tr.Close()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "http://public.example.com/", nil)
+	if err := tr.Proxy(context.Background(), srv.URL, req, rec); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.Header().Get("X-Seen-Host"), srv.Listener.Addr().String(); got != want {
+		t.Errorf("backend Host = %q, want the target host %q", got, want)
+	}
+}
+
+func TestResponseModifierAddsAndStripsHeaders(t *testing.T) {
+	srv := headerBackend(t)
+	var order []string
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false),
+		WithResponseModifier(func(res *http.Response) error {
+			order = append(order, "first")
+			res.Header.Del("X-Backend-Internal")
+			return nil
+		}),
+		WithResponseModifier(func(res *http.Response) error {
+			order = append(order, "second")
+			res.Header.Set("X-Proxy", "color")
+			return nil
+		}))
+	defer tr.Close()
+
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(context.Background(), srv.URL, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("X-Backend-Internal"); got != "" {
+		t.Errorf("X-Backend-Internal = %q, want it stripped", got)
+	}
+	if got := rec.Header().Get("X-Proxy"); got != "color" {
+		t.Errorf("X-Proxy = %q, want color", got)
+	}
+	if rec.Body.String() != "ok" {
+		t.Errorf("body = %q, want ok", rec.Body.String())
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("modifier order = %v, want [first second]", order)
+	}
+}
+
+func TestResponseModifierErrorReturns502(t *testing.T) {
+	srv := headerBackend(t)
+	var later atomic.Bool
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false),
+		WithResponseModifier(func(*http.Response) error { return errors.New("rejected") }),
+		WithResponseModifier(func(*http.Response) error { later.Store(true); return nil }))
+	defer tr.Close()
+
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(context.Background(), srv.URL, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want 502", rec.Code)
+	}
+	if later.Load() {
+		t.Error("modifier after the failing one still ran")
+	}
+	if rec.Header().Get("X-Backend-Internal") != "" {
+		t.Error("backend headers leaked into the error response")
+	}
+}
@@ -0,0 +1,26 @@
+package transport
+
+import (
+	"context"
+	"time"
+)
+
+// RequestPolicy 单个请求覆盖的转发参数，零值字段使用传输层自身的配置
+type RequestPolicy struct {
+	// Timeout 请求总超时，替代 WithRequestTimeout（等待响应头仍受 HTTP 超时限制）
+	Timeout time.Duration
+	// MaxAttempts 最大尝试次数（含首次），1 表示不重试；未配置 WithRetry 时使用默认的方法与退避
+	MaxAttempts int
+}
+
+type requestPolicyKey struct{}
+
+// WithRequestPolicy 返回携带转发参数的 context，传给 HTTPTransport.Proxy 后生效
+func WithRequestPolicy(ctx context.Context, p RequestPolicy) context.Context {
+	return context.WithValue(ctx, requestPolicyKey{}, p)
+}
+
+func requestPolicyFrom(ctx context.Context) RequestPolicy {
+	p, _ := ctx.Value(requestPolicyKey{}).(RequestPolicy)
+	return p
+}
@@ -0,0 +1,64 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// rawCodec 透传编解码器：消息以已编码的 []byte 形式收发，不做任何序列化
+// Name 返回 "proto"，使 content-type 保持为 application/grpc+proto，后端无需感知
+// 其他 protobuf 消息按标准 proto 编解码，因此也可以作为服务端 codec 与普通服务共存
+type rawCodec struct{}
+
+// RawCodec 返回透传编解码器，用于 grpc.ForceServerCodec
+func RawCodec() encoding.Codec {
+	return rawCodec{}
+}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	switch m := v.(type) {
+	case []byte:
+		return m, nil
+	case *[]byte:
+		return *m, nil
+	case proto.Message:
+		return proto.Marshal(m)
+	case protoadapt.MessageV1:
+		return proto.Marshal(protoadapt.MessageV2Of(m))
+	default:
+		return nil, fmt.Errorf("raw codec: unsupported message type %T", v)
+	}
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	switch m := v.(type) {
+	case *[]byte:
+		*m = append((*m)[:0], data...)
+		return nil
+	case proto.Message:
+		return proto.Unmarshal(data, m)
+	case protoadapt.MessageV1:
+		return proto.Unmarshal(data, protoadapt.MessageV2Of(m))
+	default:
+		return fmt.Errorf("raw codec: unsupported message type %T", v)
+	}
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// ProxyRaw 以原始字节转发 gRPC Unary RPC，无需知道请求/响应的消息类型
+// in 为已编码的请求消息，返回已编码的响应消息
+func (t *GRPCTransport) ProxyRaw(ctx context.Context, target, method string, in []byte) ([]byte, error) {
+	var out []byte
+	if err := t.Proxy(ctx, target, method, &in, &out, grpc.ForceCodec(rawCodec{})); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,54 @@
+package transport
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// connCountingBackend 统计后端接受的新连接数，用于判断 keep-alive 连接是否被复用
+func connCountingBackend(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv, &conns
+}
+
+func proxyOK(t *testing.T, tr *HTTPTransport, target string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(t.Context(), target, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("proxy to %s: status = %d, err = %v", target, rec.Code, err)
+	}
+}
+
+func TestRemoveTargetKeepsOtherHostsConnections(t *testing.T) {
+	removed, _ := connCountingBackend(t)
+	kept, keptConns := connCountingBackend(t)
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false))
+	defer tr.Close()
+
+	proxyOK(t, tr, removed.URL)
+	proxyOK(t, tr, kept.URL)
+	tr.RemoveTarget(removed.URL)
+	proxyOK(t, tr, kept.URL)
+
+	if n := keptConns.Load(); n != 1 {
+		t.Errorf("kept backend saw %d connections, want its keep-alive connection reused after removing another target", n)
+	}
+	if _, ok := tr.proxyCache.Load(removed.URL); ok {
+		t.Error("removed target still cached")
+	}
+}
@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// DefaultRequestIDHeader 默认的请求 ID header
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// 没有配置的 header 时依次尝试的常见请求 ID header
+var requestIDFallbackHeaders = []string{"X-Request-Id", "X-Trace-Id"}
+
+// WithRequestID 为每个转发的请求携带请求 ID：沿用传入的 header（或 X-Request-Id / X-Trace-Id），
+// 缺失时生成 UUID；ID 写入转发给后端的请求和返回给客户端的响应，并出现在传输层日志中
+// header 为空时使用 X-Request-Id
+func WithRequestID(header string) HTTPOption {
+	return func(t *HTTPTransport) {
+		if header == "" {
+			header = DefaultRequestIDHeader
+		}
+		t.requestIDHeader = header
+	}
+}
+
+// ensureRequestID 确保请求携带请求 ID 并回写到响应 header，未启用时返回空字符串
+func (t *HTTPTransport) ensureRequestID(req *http.Request, w http.ResponseWriter) string {
+	if t.requestIDHeader == "" {
+		return ""
+	}
+
+	id := req.Header.Get(t.requestIDHeader)
+	for _, h := range requestIDFallbackHeaders {
+		if id != "" {
+			break
+		}
+		id = req.Header.Get(h)
+	}
+	if id == "" {
+		id = newUUID()
+	}
+
+	req.Header.Set(t.requestIDHeader, id)
+	w.Header().Set(t.requestIDHeader, id)
+	return id
+}
+
+// requestID 读取已设置的请求 ID，用于日志
+func (t *HTTPTransport) requestID(req *http.Request) string {
+	if t.requestIDHeader == "" {
+		return "-"
+	}
+	return req.Header.Get(t.requestIDHeader)
+}
+
+// newUUID 生成随机（v4）UUID
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand 在受支持的平台上不会失败
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
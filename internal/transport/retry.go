@@ -0,0 +1,182 @@
+package transport
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// defaultRetryMethods 默认只重试幂等方法
+var defaultRetryMethods = []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete}
+
+// defaultRetryBackoff 未配置 WithRetry、只由 RequestPolicy 开启重试时的初始退避
+const defaultRetryBackoff = 100 * time.Millisecond
+
+// retryPolicy 转发重试策略
+type retryPolicy struct {
+	maxAttempts int
+	backoff     time.Duration
+	methods     map[string]bool
+}
+
+// WithRetry 对幂等请求在连接错误或 502/503/504 时重试，最多 maxAttempts 次（含首次），
+// 间隔从 backoff 开始指数增长并加随机抖动；响应带 Retry-After 时改为等待其给出的时长，
+// 等待会超过请求截止时间时不再重试，直接把该响应（含 Retry-After）返回给客户端。仅在请求无 body 或 body 可重放（GetBody）时重试，
+// 带 body 的请求需配合 WithMaxBufferedBody 使用。
+func WithRetry(maxAttempts int, backoff time.Duration) HTTPOption {
+	return func(t *HTTPTransport) {
+		if maxAttempts <= 1 {
+			t.retry = nil
+			return
+		}
+		methods := map[string]bool{}
+		if t.retry != nil {
+			methods = t.retry.methods
+		} else {
+			for _, m := range defaultRetryMethods {
+				methods[m] = true
+			}
+		}
+		t.retry = &retryPolicy{maxAttempts: maxAttempts, backoff: backoff, methods: methods}
+	}
+}
+
+// WithRetryMethods 自定义可重试的 HTTP 方法，需与 WithRetry 一起使用（顺序不限）
+func WithRetryMethods(methods ...string) HTTPOption {
+	return func(t *HTTPTransport) {
+		set := make(map[string]bool, len(methods))
+		for _, m := range methods {
+			set[m] = true
+		}
+		if t.retry == nil {
+			// 先于 WithRetry 调用：暂存方法集，maxAttempts 为 0 时不生效
+			t.retry = &retryPolicy{methods: set}
+			return
+		}
+		t.retry.methods = set
+	}
+}
+
+// retryTransport 在 RoundTripper 层重试，最终的错误仍交给 ReverseProxy.ErrorHandler 处理
+// policy 为 nil 表示未配置 WithRetry，此时只有 RequestPolicy.MaxAttempts 大于 1 的请求会重试
+type retryTransport struct {
+	next   http.RoundTripper
+	policy *retryPolicy
+}
+
+func (rt *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	maxAttempts := rt.maxAttempts(req)
+	if !rt.retryable(req, maxAttempts) {
+		return rt.next.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := rt.next.RoundTrip(req)
+		if attempt >= maxAttempts || !retryableResult(resp, err) {
+			return resp, err
+		}
+
+		// 后端给出 Retry-After 时按其等待，代替固定的退避间隔
+		wait := rt.delay(attempt)
+		if resp != nil {
+			if ra, ok := retryAfter(resp); ok {
+				wait = ra
+			}
+		}
+
+		// 等待会超过请求截止时间时不再重试，直接返回本次结果
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) <= wait {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, berr := req.GetBody()
+			if berr != nil {
+				return nil, berr
+			}
+			req.Body = body
+		}
+	}
+}
+
+// maxAttempts 请求的最大尝试次数：RequestPolicy 优先，其次为 WithRetry
+func (rt *retryTransport) maxAttempts(req *http.Request) int {
+	if n := requestPolicyFrom(req.Context()).MaxAttempts; n > 0 {
+		return n
+	}
+	if rt.policy == nil {
+		return 1
+	}
+	return rt.policy.maxAttempts
+}
+
+// retryable 方法在白名单内，且 body 为空或可重放
+func (rt *retryTransport) retryable(req *http.Request, maxAttempts int) bool {
+	if maxAttempts <= 1 {
+		return false
+	}
+	if rt.policy != nil && len(rt.policy.methods) > 0 {
+		if !rt.policy.methods[req.Method] {
+			return false
+		}
+	} else if !slices.Contains(defaultRetryMethods, req.Method) {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// delay 第 attempt 次失败后的等待时间：backoff * 2^(attempt-1)，在 [d/2, d) 内随机抖动
+func (rt *retryTransport) delay(attempt int) time.Duration {
+	backoff := defaultRetryBackoff
+	if rt.policy != nil && rt.policy.maxAttempts > 1 {
+		backoff = rt.policy.backoff
+	}
+	d := backoff << (attempt - 1)
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)))
+}
+
+func retryableResult(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter 解析 Retry-After（秒数或 HTTP 日期），未设置或无法解析时 ok 为 false；已过去的日期视为 0
+func retryAfter(resp *http.Response) (d time.Duration, ok bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
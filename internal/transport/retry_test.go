@@ -0,0 +1,208 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer 前 failures 次请求返回 503，之后返回 200 并回显 body
+func flakyServer(t *testing.T, failures int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetrySucceedsOnSecondAttempt(t *testing.T) {
+	srv, calls := flakyServer(t, 1)
+	tr := NewHTTPTransport(5*time.Second, WithRetry(3, time.Millisecond), WithHTTPLogging(false))
+	defer tr.Close()
+
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(context.Background(), srv.URL, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("status = %d after %d attempts, want 200 after 2", rec.Code, calls.Load())
+	}
+}
+
+func TestRetryReplaysBufferedBody(t *testing.T) {
+	srv, calls := flakyServer(t, 1)
+	tr := NewHTTPTransport(5*time.Second, WithRetry(3, time.Millisecond), WithMaxBufferedBody(1<<10),
+		WithRetryMethods(http.MethodPost), WithHTTPLogging(false))
+	defer tr.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload"))
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(context.Background(), srv.URL, req, rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != "payload" || calls.Load() != 2 {
+		t.Errorf("status = %d body = %q after %d attempts, want the body replayed on the second attempt",
+			rec.Code, rec.Body.String(), calls.Load())
+	}
+}
+
+func TestRetrySkipsNonRewindableBody(t *testing.T) {
+	srv, calls := flakyServer(t, 1)
+	tr := NewHTTPTransport(5*time.Second, WithRetry(3, time.Millisecond), WithHTTPLogging(false))
+	defer tr.Close()
+
+	// 未启用 WithMaxBufferedBody：PUT 的 body 无法重放，只尝试一次
+	req := httptest.NewRequest(http.MethodPut, "/", io.NopCloser(strings.NewReader("payload")))
+	req.GetBody = nil
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(context.Background(), srv.URL, req, rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("status = %d after %d attempts, want the first 503 without retrying", rec.Code, calls.Load())
+	}
+}
+
+func TestRetrySkipsNonIdempotentMethod(t *testing.T) {
+	srv, calls := flakyServer(t, 1)
+	tr := NewHTTPTransport(5*time.Second, WithRetry(3, time.Millisecond), WithHTTPLogging(false))
+	defer tr.Close()
+
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(context.Background(), srv.URL, httptest.NewRequest(http.MethodPost, "/", nil), rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("status = %d after %d attempts, want POST not retried by default", rec.Code, calls.Load())
+	}
+}
+
+func TestRetryExhaustedSurfacesLastResponse(t *testing.T) {
+	srv, calls := flakyServer(t, 10)
+	tr := NewHTTPTransport(5*time.Second, WithRetry(3, time.Millisecond), WithHTTPLogging(false))
+	defer tr.Close()
+
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(context.Background(), srv.URL, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusServiceUnavailable || calls.Load() != 3 {
+		t.Errorf("status = %d after %d attempts, want the last 503 after 3", rec.Code, calls.Load())
+	}
+}
+
+func TestRetryConnectionErrorUsesErrorHandler(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	target := srv.URL
+	srv.Close()
+
+	var observed atomic.Int32
+	tr := NewHTTPTransport(5*time.Second, WithRetry(2, time.Millisecond), WithHTTPLogging(false),
+		WithErrorObserver(func(*http.Request, int, error) { observed.Add(1) }))
+	defer tr.Close()
+
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(context.Background(), target, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusBadGateway || observed.Load() != 1 {
+		t.Errorf("status = %d, error handler calls = %d, want one 502 from the error handler", rec.Code, observed.Load())
+	}
+}
+
+func TestRequestPolicyOverridesRetry(t *testing.T) {
+	srv, calls := flakyServer(t, 2)
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false))
+	defer tr.Close()
+
+	ctx := WithRequestPolicy(context.Background(), RequestPolicy{MaxAttempts: 3})
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(ctx, srv.URL, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || calls.Load() != 3 {
+		t.Errorf("status = %d after %d attempts, want 200 after 3 without WithRetry", rec.Code, calls.Load())
+	}
+}
+
+// retryAfterServer 首次请求返回 503 与给定的 Retry-After，之后返回 200，记录两次请求的间隔
+func retryAfterServer(t *testing.T, retryAfter func() string) (*httptest.Server, *atomic.Int32, *atomic.Int64) {
+	t.Helper()
+	var calls atomic.Int32
+	var gap atomic.Int64
+	var first time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", retryAfter())
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		gap.Store(int64(time.Since(first)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, &gap
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		retryAfter func() string
+		minWait    time.Duration
+	}{
+		{"seconds", func() string { return "1" }, time.Second},
+		// HTTP 日期只精确到秒，至少等待到下一秒
+		{"http date", func() string { return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat) }, time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, calls, gap := retryAfterServer(t, tc.retryAfter)
+			// 固定退避远超截止时间：只有按 Retry-After 等待才会重试
+			tr := NewHTTPTransport(10*time.Second, WithRetry(2, time.Hour), WithHTTPLogging(false))
+			defer tr.Close()
+
+			rec := httptest.NewRecorder()
+			if err := tr.Proxy(context.Background(), srv.URL, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != http.StatusOK || calls.Load() != 2 {
+				t.Fatalf("status = %d after %d attempts, want 200 after waiting for Retry-After", rec.Code, calls.Load())
+			}
+			if wait := time.Duration(gap.Load()); wait < tc.minWait || wait > 3*time.Second {
+				t.Errorf("waited %v between attempts, want the Retry-After delay", wait)
+			}
+		})
+	}
+}
+
+func TestRetryAfterBeyondDeadlineSurfaces503(t *testing.T) {
+	srv, calls, _ := retryAfterServer(t, func() string { return "120" })
+	tr := NewHTTPTransport(10*time.Second, WithRetry(3, time.Millisecond), WithHTTPLogging(false))
+	defer tr.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	if err := tr.Proxy(ctx, srv.URL, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusServiceUnavailable || calls.Load() != 1 || rec.Header().Get("Retry-After") != "120" {
+		t.Errorf("status = %d Retry-After = %q after %d attempts, want the backend's 503 passed through",
+			rec.Code, rec.Header().Get("Retry-After"), calls.Load())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, want no wait when Retry-After exceeds the deadline", elapsed)
+	}
+}
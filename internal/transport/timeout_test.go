@@ -0,0 +1,76 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// slowBackend 等待 delay 后才返回响应，客户端断开时提前结束
+func slowBackend(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+			w.Write([]byte("late"))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRequestTimeoutReturns504(t *testing.T) {
+	srv := slowBackend(t, 2*time.Second)
+	// 等待响应头的超时远大于请求超时，超时只能来自 WithRequestTimeout
+	tr := NewHTTPTransport(10*time.Second, WithRequestTimeout(100*time.Millisecond), WithHTTPLogging(false))
+	defer tr.Close()
+
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	if err := tr.Proxy(context.Background(), srv.URL, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want 504", rec.Code)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, want the request cut off at the request timeout", elapsed)
+	}
+}
+
+func TestCallerDeadlineShorterThanRequestTimeout(t *testing.T) {
+	srv := slowBackend(t, 2*time.Second)
+	tr := NewHTTPTransport(10*time.Second, WithRequestTimeout(5*time.Second), WithHTTPLogging(false))
+	defer tr.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	if err := tr.Proxy(ctx, srv.URL, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want 504", rec.Code)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, want the caller's shorter deadline to apply", elapsed)
+	}
+}
+
+func TestRequestTimeoutAllowsFastBackend(t *testing.T) {
+	srv := slowBackend(t, 10*time.Millisecond)
+	tr := NewHTTPTransport(10*time.Second, WithRequestTimeout(time.Second), WithHTTPLogging(false))
+	defer tr.Close()
+
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(context.Background(), srv.URL, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != "late" {
+		t.Errorf("status = %d body = %q, want the backend response within the timeout", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,83 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTLSBackend(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.ServerName))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func proxyOnce(t *testing.T, tr *HTTPTransport, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	if err := tr.Proxy(context.Background(), target, httptest.NewRequest(http.MethodGet, "/", nil), rec); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestTLSConfigWithCustomCA(t *testing.T) {
+	srv := newTLSBackend(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	tr := NewHTTPTransport(5*time.Second, WithTLSConfig(&tls.Config{RootCAs: pool}), WithHTTPLogging(false))
+	defer tr.Close()
+
+	// httptest 证书签发给 example.com 与 127.0.0.1，按目标地址校验
+	rec := proxyOnce(t, tr, srv.URL)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d %s, want 200 with the custom CA", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTLSConfigRejectsUnknownCA(t *testing.T) {
+	srv := newTLSBackend(t)
+	tr := NewHTTPTransport(5*time.Second, WithHTTPLogging(false))
+	defer tr.Close()
+
+	if rec := proxyOnce(t, tr, srv.URL); rec.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want 502 for an untrusted certificate", rec.Code)
+	}
+}
+
+func TestTLSConfigServerNameFromTarget(t *testing.T) {
+	srv := newTLSBackend(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	// 证书包含 example.com：把 example.com 解析到测试服务，SNI 与校验都使用目标 host
+	tr := NewHTTPTransport(5*time.Second, WithTLSConfig(&tls.Config{RootCAs: pool}), WithHTTPLogging(false))
+	defer tr.Close()
+	tr.getTransport().DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+
+	rec := proxyOnce(t, tr, "https://example.com")
+	if rec.Code != http.StatusOK || rec.Body.String() != "example.com" {
+		t.Errorf("status = %d, server name = %q, want 200 with SNI example.com", rec.Code, rec.Body.String())
+	}
+}
+
+func TestInsecureSkipVerify(t *testing.T) {
+	srv := newTLSBackend(t)
+	tr := NewHTTPTransport(5*time.Second, WithInsecureSkipVerify(), WithHTTPLogging(false))
+	defer tr.Close()
+
+	if rec := proxyOnce(t, tr, srv.URL); rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 with verification disabled", rec.Code)
+	}
+}
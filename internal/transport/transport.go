@@ -13,8 +13,23 @@ type HTTPTransporter interface {
 	Close() error
 }
 
+// TargetRemover 可选接口：路由删除后释放到该 target 的缓存与连接
+type TargetRemover interface {
+	RemoveTarget(target string)
+}
+
 // GRPCTransporter gRPC 传输层接口
 type GRPCTransporter interface {
 	Proxy(ctx context.Context, target string, method string, req interface{}, reply interface{}, opts ...grpc.CallOption) error
 	Close() error
 }
+
+// RawGRPCTransporter 可选接口：以原始字节转发 gRPC Unary RPC，用于通用网关
+type RawGRPCTransporter interface {
+	ProxyRaw(ctx context.Context, target, method string, in []byte) ([]byte, error)
+}
+
+// StreamGRPCTransporter 可选接口：以原始字节双向转发 gRPC 流
+type StreamGRPCTransporter interface {
+	ProxyStream(ctx context.Context, target, method string, desc *grpc.StreamDesc, downstream grpc.ServerStream) error
+}
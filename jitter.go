@@ -0,0 +1,60 @@
+package color
+
+import (
+	"math/rand"
+	"time"
+)
+
+// maxJitter 抖动比例上限，保证每次间隔都大于 0
+const maxJitter = 0.9
+
+// jitterTicker 与 time.Ticker 用法相同的定时器
+// fraction 为 0 时就是 time.Ticker；大于 0 时首次触发随机落在 [0, interval) 内，
+// 之后每次间隔在 interval*(1±fraction) 内随机，使同时启动的实例错开访问后端
+type jitterTicker struct {
+	C <-chan time.Time
+
+	ticker *time.Ticker
+	stop   chan struct{}
+}
+
+func newJitterTicker(interval time.Duration, fraction float64) *jitterTicker {
+	if fraction <= 0 {
+		ticker := time.NewTicker(interval)
+		return &jitterTicker{C: ticker.C, ticker: ticker}
+	}
+
+	c := make(chan time.Time, 1)
+	t := &jitterTicker{C: c, stop: make(chan struct{})}
+	go func() {
+		timer := time.NewTimer(time.Duration(rand.Float64() * float64(interval)))
+		defer timer.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case now := <-timer.C:
+				// 与 time.Ticker 一致：接收方处理过慢时丢弃本次触发
+				select {
+				case c <- now:
+				default:
+				}
+				timer.Reset(jitterInterval(interval, fraction))
+			}
+		}
+	}()
+	return t
+}
+
+// jitterInterval 返回 interval*(1±fraction) 内的随机间隔
+func jitterInterval(interval time.Duration, fraction float64) time.Duration {
+	return time.Duration(float64(interval) * (1 + fraction*(2*rand.Float64()-1)))
+}
+
+func (t *jitterTicker) Stop() {
+	if t.ticker != nil {
+		t.ticker.Stop()
+		return
+	}
+	close(t.stop)
+}
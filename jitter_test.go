@@ -0,0 +1,56 @@
+package color
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitterIntervalWithinBounds(t *testing.T) {
+	const interval = 10 * time.Second
+	for _, fraction := range []float64{0.1, 0.5, maxJitter} {
+		lo := time.Duration(float64(interval) * (1 - fraction))
+		hi := time.Duration(float64(interval) * (1 + fraction))
+		seenLow, seenHigh := false, false
+		for i := 0; i < 10000; i++ {
+			d := jitterInterval(interval, fraction)
+			if d < lo || d > hi {
+				t.Fatalf("fraction %v: interval %v outside [%v, %v]", fraction, d, lo, hi)
+			}
+			// 抖动应覆盖区间两侧，而不是固定偏向一边
+			seenLow = seenLow || d < interval-time.Duration(float64(interval)*fraction/2)
+			seenHigh = seenHigh || d > interval+time.Duration(float64(interval)*fraction/2)
+		}
+		if !seenLow || !seenHigh {
+			t.Errorf("fraction %v: intervals not spread across both sides of %v", fraction, interval)
+		}
+	}
+}
+
+func TestJitterTickerFiresWithinBounds(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	ticker := newJitterTicker(interval, 0.5)
+	defer ticker.Stop()
+
+	// 首次触发在 [0, interval) 内随机，之后每次间隔在 [interval/2, interval*3/2] 内（留出调度误差）
+	prev := <-ticker.C
+	for i := 0; i < 5; i++ {
+		now := <-ticker.C
+		if gap := now.Sub(prev); gap < interval/2-2*time.Millisecond || gap > 3*interval/2+50*time.Millisecond {
+			t.Errorf("tick gap %v outside the jitter bounds of %v", gap, interval)
+		}
+		prev = now
+	}
+}
+
+func TestHeartbeatJitterClamped(t *testing.T) {
+	for fraction, want := range map[float64]float64{
+		-0.5: 0,
+		2:    maxJitter,
+		0.2:  0.2,
+	} {
+		p := newTestProxy(t, WithHeartbeatJitter(fraction))
+		if got := p.config.HeartbeatJitter; got != want {
+			t.Errorf("WithHeartbeatJitter(%v) = %v, want %v", fraction, got, want)
+		}
+	}
+}
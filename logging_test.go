@@ -0,0 +1,80 @@
+package color
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// captureLogger 记录所有日志，供断言是否有输出
+type captureLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *captureLogger) Info(msg string, args ...interface{})  { l.add("INFO "+msg, args) }
+func (l *captureLogger) Error(msg string, args ...interface{}) { l.add("ERROR "+msg, args) }
+
+func (l *captureLogger) add(msg string, args []interface{}) {
+	l.mu.Lock()
+	l.lines = append(l.lines, fmt.Sprintf(msg, args...))
+	l.mu.Unlock()
+}
+
+// take 返回并清空已记录的日志
+func (l *captureLogger) take() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lines := l.lines
+	l.lines = nil
+	return lines
+}
+
+// proxyOnceEach 先转发到可用后端，再转发到已关闭的后端（502）
+func proxyOnceEach(t *testing.T, p *Proxy) {
+	t.Helper()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	register(t, p, &backend.Route{Color: "up", Address: up.URL, Token: "t"})
+	register(t, p, &backend.Route{Color: "down", Address: down.URL, Token: "t"})
+
+	for color, want := range map[string]int{"up": http.StatusOK, "down": http.StatusBadGateway} {
+		req := httptest.NewRequest(http.MethodGet, "/api", nil)
+		req.Header.Set("color", color)
+		if rec := serve(p, req); rec.Code != want {
+			t.Fatalf("color %s: status = %d, want %d", color, rec.Code, want)
+		}
+	}
+}
+
+func TestHTTPLoggingDisabledProducesNoOutput(t *testing.T) {
+	logger := &captureLogger{}
+	p := newTestProxy(t, WithLogger(logger), WithHTTPLogging(false))
+	logger.take()
+
+	proxyOnceEach(t, p)
+	if lines := logger.take(); len(lines) != 0 {
+		t.Errorf("logged %q with HTTP logging disabled, want no output", lines)
+	}
+}
+
+func TestHTTPLoggingEnabledUsesLogger(t *testing.T) {
+	logger := &captureLogger{}
+	p := newTestProxy(t, WithLogger(logger))
+	logger.take()
+
+	proxyOnceEach(t, p)
+	lines := logger.take()
+	if len(lines) != 1 || !strings.Contains(lines[0], "[HTTPTransport] Proxy error") {
+		t.Errorf("logged %q, want one transport line for the failed request", lines)
+	}
+}
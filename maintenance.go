@@ -0,0 +1,110 @@
+package color
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maintenanceState 维护模式状态
+// all 为 true 时所有 color 都处于维护中；否则只有 colors 中的 color 处于维护中
+type maintenanceState struct {
+	mu     sync.RWMutex
+	all    bool
+	colors map[string]struct{}
+}
+
+// SetMaintenance 开启/关闭维护模式
+// 不传 colors 时作用于全部 color（关闭时同时清除所有按 color 设置的维护状态）；
+// 传入 colors 时只切换这些 color 的维护状态。
+func (p *Proxy) SetMaintenance(on bool, colors ...string) {
+	m := &p.maintenance
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(colors) == 0 {
+		m.all = on
+		if !on {
+			m.colors = nil
+		}
+	} else {
+		if m.colors == nil {
+			m.colors = make(map[string]struct{})
+		}
+		for _, color := range colors {
+			if on {
+				m.colors[color] = struct{}{}
+			} else {
+				delete(m.colors, color)
+			}
+		}
+	}
+
+	p.config.Logger.Info("maintenance mode updated: on=%v, colors=%v", on, colors)
+}
+
+// inMaintenance 判断指定 color 是否处于维护中
+func (p *Proxy) inMaintenance(color string) bool {
+	m := &p.maintenance
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.all {
+		return true
+	}
+	_, ok := m.colors[color]
+	return ok
+}
+
+// maintenanceSnapshot 返回当前维护状态（用于管理接口展示）
+func (p *Proxy) maintenanceSnapshot() (bool, []string) {
+	m := &p.maintenance
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	colors := make([]string, 0, len(m.colors))
+	for color := range m.colors {
+		colors = append(colors, color)
+	}
+	return m.all, colors
+}
+
+// writeMaintenance 输出维护响应
+func (p *Proxy) writeMaintenance(w http.ResponseWriter, color string) {
+	cfg := p.config
+	if cfg.MaintenanceRetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((cfg.MaintenanceRetryAfter+time.Second-1)/time.Second)))
+	}
+
+	if cfg.MaintenanceBody != "" {
+		body := []byte(cfg.MaintenanceBody)
+		w.Header().Set("Content-Type", http.DetectContentType(body))
+		w.WriteHeader(cfg.MaintenanceStatus)
+		w.Write(body)
+		return
+	}
+
+	p.writeError(w, cfg.MaintenanceStatus, "service under maintenance", "", jsonMap{"color": color})
+}
+
+func (p *Proxy) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		On     *bool    `json:"on"`
+		Colors []string `json:"colors"`
+	}
+
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, jsonMap{"error": err.Error()})
+		return
+	}
+	if req.On == nil {
+		writeJSON(w, 400, jsonMap{"error": "on is required"})
+		return
+	}
+
+	p.SetMaintenance(*req.On, req.Colors...)
+
+	all, colors := p.maintenanceSnapshot()
+	writeJSON(w, 200, jsonMap{"message": "maintenance updated", "all": all, "colors": colors})
+}
@@ -0,0 +1,249 @@
+package color
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+	"github.com/asam264/color/internal/strategy"
+	"github.com/asam264/color/managementpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RegisterManagementServer 在 gRPC server 上注册路由管理服务
+// 与 HTTP 管理端点共用同一个 backend；配置了 admin token 时通过 metadata 认证
+func RegisterManagementServer(s *grpc.Server, p *Proxy) {
+	managementpb.RegisterManagementServer(s, &managementServer{proxy: p})
+}
+
+type managementServer struct {
+	managementpb.UnimplementedManagementServer
+	proxy *Proxy
+}
+
+// authorize 校验 metadata 中的 admin token：默认为 authorization: Bearer <token>，
+// 配置了 WithAdminTokenHeader 时为同名 metadata（小写）中的 token，与 HTTP 管理端点一致
+func (s *managementServer) authorize(ctx context.Context) error {
+	if s.proxy.config.AdminToken == "" {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if name := s.proxy.customTokenHeader(); name != "" {
+		for _, v := range md.Get(strings.ToLower(name)) {
+			if s.proxy.validAdminToken(v) {
+				return nil
+			}
+		}
+		return status.Error(codes.Unauthenticated, "invalid admin token")
+	}
+	for _, v := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(v, "Bearer ")
+		if ok && s.proxy.validAdminToken(token) {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid admin token")
+}
+
+func (s *managementServer) Register(ctx context.Context, req *managementpb.RegisterRequest) (*managementpb.RegisterResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.GetColor() == "" || req.GetToken() == "" || (req.GetAddress() == "" && len(req.GetEndpoints()) == 0) {
+		return nil, status.Error(codes.InvalidArgument, "color, token and address or endpoints are required")
+	}
+	if err := validateRouteName(req.GetColor(), req.GetVersion()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := validateLabels(req.GetLabels()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ttl, err := routeTTL(req.GetTtlSeconds())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	address := req.GetAddress()
+	if address != "" {
+		if address, err = normalizeAddress(address); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	route := &backend.Route{
+		Color:   req.GetColor(),
+		Address: address,
+		Owner:   req.GetOwner(),
+		Token:   req.GetToken(),
+		TTL:     ttl,
+		Version: req.GetVersion(),
+		Labels:  req.GetLabels(),
+	}
+	for _, ep := range req.GetEndpoints() {
+		if ep.GetAddress() == "" || ep.GetWeight() < 0 {
+			return nil, status.Error(codes.InvalidArgument, "endpoint address is required and weight must be >= 0")
+		}
+		addr, err := normalizeAddress(ep.GetAddress())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		route.Endpoints = append(route.Endpoints, backend.Endpoint{Address: addr, Weight: int(ep.GetWeight())})
+	}
+	if err := s.proxy.registerRoute(ctx, route, req.GetExclusive() || s.proxy.config.ExclusiveRegister); err != nil {
+		return nil, toStatus(err)
+	}
+	s.proxy.emit(RouteRegistered, route.Key(), route.Address)
+	return &managementpb.RegisterResponse{Color: route.Color}, nil
+}
+
+func (s *managementServer) Heartbeat(ctx context.Context, req *managementpb.HeartbeatRequest) (*managementpb.HeartbeatResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.GetColor() == "" || req.GetAddress() == "" || req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "color, address and token are required")
+	}
+	if err := validateRouteName(req.GetColor(), req.GetVersion()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := validateLabels(req.GetLabels()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	// 与注册时的规范化保持一致
+	address, err := normalizeAddress(req.GetAddress())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	ttl, err := routeTTL(req.GetTtlSeconds())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// owner、labels、TTL 与 endpoints 用于路由已过期时按注册时的内容重新注册
+	route := &backend.Route{
+		Color:   req.GetColor(),
+		Address: address,
+		Owner:   req.GetOwner(),
+		Token:   req.GetToken(),
+		TTL:     ttl,
+		Version: req.GetVersion(),
+		Labels:  req.GetLabels(),
+	}
+	for _, ep := range req.GetEndpoints() {
+		if ep.GetAddress() == "" || ep.GetWeight() < 0 {
+			return nil, status.Error(codes.InvalidArgument, "endpoint address is required and weight must be >= 0")
+		}
+		addr, err := normalizeAddress(ep.GetAddress())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		route.Endpoints = append(route.Endpoints, backend.Endpoint{Address: addr, Weight: int(ep.GetWeight())})
+	}
+	reregistered, err := s.proxy.heartbeatRoute(ctx, route)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if !reregistered {
+		s.proxy.emit(RouteRenewed, route.Key(), address)
+	}
+	return &managementpb.HeartbeatResponse{Reregistered: reregistered}, nil
+}
+
+func (s *managementServer) List(ctx context.Context, req *managementpb.ListRequest) (*managementpb.ListResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	routes, err := s.proxy.listRoutes(ctx, req.GetOwner(), req.GetColor())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &managementpb.ListResponse{Routes: make([]*managementpb.Route, 0, len(routes))}
+	for _, route := range routes {
+		pr := &managementpb.Route{
+			Color:      route.Color,
+			Address:    route.Address,
+			Owner:      route.Owner,
+			ExpiresAt:  timestamppb.New(route.ExpiresAt),
+			TtlSeconds: int64(route.TTL / time.Second),
+			Version:    route.Version,
+			Labels:     route.Labels,
+		}
+		for _, ep := range route.EndpointList() {
+			pr.Endpoints = append(pr.Endpoints, &managementpb.Endpoint{Address: ep.Address, Weight: int32(ep.Weight)})
+		}
+		resp.Routes = append(resp.Routes, pr)
+	}
+	return resp, nil
+}
+
+// forceDelete metadata 中是否带有 x-force-delete: true
+func forceDelete(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("x-force-delete") {
+		if v == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *managementServer) Delete(ctx context.Context, req *managementpb.DeleteRequest) (*managementpb.DeleteResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.GetColor() == "" {
+		return nil, status.Error(codes.InvalidArgument, "color is required")
+	}
+
+	// 配置了 admin token 时通过 authorize 的调用即为 admin，还需 metadata x-force-delete: true 才强制删除
+	force := s.proxy.config.AdminToken != "" && forceDelete(ctx)
+	if err := s.proxy.deleteRoute(ctx, backend.RouteKey(req.GetColor(), req.GetVersion()), req.GetToken(), force); err != nil {
+		return nil, toStatus(err)
+	}
+	return &managementpb.DeleteResponse{Color: req.GetColor()}, nil
+}
+
+func (s *managementServer) Resolve(ctx context.Context, req *managementpb.ResolveRequest) (*managementpb.ResolveResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.GetColor() == "" {
+		return nil, status.Error(codes.InvalidArgument, "color is required")
+	}
+
+	// 与 HTTP 转发相同的选择流程：查询超时、版本路由与回退链
+	rr := strategy.ForColor(req.GetColor())
+	rr.Version = req.GetVersion()
+	target, route, local, err := s.proxy.resolveRoute(ctx, rr)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &managementpb.ResolveResponse{Color: req.GetColor(), Target: target, Route: route, Local: local}, nil
+}
+
+// toStatus 将 backend 错误转换为 gRPC status
+func toStatus(err error) error {
+	if isNoRoute(err) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, ErrLookupTimeout) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	if errors.Is(err, backend.ErrRouteConflict) {
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	if errors.Is(err, backend.ErrTokenMismatch) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,157 @@
+package color
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+	"github.com/asam264/color/managementpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// newManagementClient 在本地端口启动 gRPC 管理服务并返回客户端
+func newManagementClient(t *testing.T, p *Proxy) managementpb.ManagementClient {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	RegisterManagementServer(s, p)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return managementpb.NewManagementClient(conn)
+}
+
+func TestGRPCHeartbeatReregisters(t *testing.T) {
+	p := newTestProxy(t, WithHeartbeatReregister(true))
+	client := newManagementClient(t, p)
+
+	resp, err := client.Heartbeat(context.Background(), &managementpb.HeartbeatRequest{
+		Color: "blue", Address: "http://10.0.0.1:8080", Token: "t1",
+	})
+	if err != nil {
+		t.Fatalf("Heartbeat: %v", err)
+	}
+	if !resp.GetReregistered() {
+		t.Error("reregistered = false, want true for a missing route")
+	}
+	routes, err := p.backend.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 || routes[0].Address != "http://10.0.0.1:8080" {
+		t.Fatalf("routes = %+v, want the re-registered route", routes)
+	}
+
+	resp, err = client.Heartbeat(context.Background(), &managementpb.HeartbeatRequest{
+		Color: "blue", Address: "http://10.0.0.1:8080", Token: "t1",
+	})
+	if err != nil {
+		t.Fatalf("second Heartbeat: %v", err)
+	}
+	if resp.GetReregistered() {
+		t.Error("reregistered = true for an existing route")
+	}
+}
+
+func TestGRPCHeartbeatReregisterRestoresRegistration(t *testing.T) {
+	p := newTestProxy(t, WithHeartbeatReregister(true))
+	client := newManagementClient(t, p)
+
+	_, err := client.Heartbeat(context.Background(), &managementpb.HeartbeatRequest{
+		Color: "blue", Address: "http://10.0.0.1:8080", Token: "t1",
+		Owner: "team-a", TtlSeconds: 90, Labels: map[string]string{"region": "eu"},
+		Endpoints: []*managementpb.Endpoint{{Address: "http://10.0.0.2:8080", Weight: 3}},
+	})
+	if err != nil {
+		t.Fatalf("Heartbeat: %v", err)
+	}
+	routes, _ := p.backend.List(context.Background())
+	if len(routes) != 1 {
+		t.Fatalf("routes = %+v, want the re-registered route", routes)
+	}
+	if got := routes[0]; got.Owner != "team-a" || got.Labels["region"] != "eu" || got.TTL != 90*time.Second || len(got.Endpoints) != 1 {
+		t.Errorf("route = %+v, want owner, labels, TTL and endpoints from the heartbeat", got)
+	}
+
+	_, err = client.Heartbeat(context.Background(), &managementpb.HeartbeatRequest{
+		Color: "green", Address: "not an address", Token: "t1",
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("malformed address: err = %v, want InvalidArgument", err)
+	}
+}
+
+func TestGRPCResolveUsesFallbackAndVersion(t *testing.T) {
+	p := newTestProxy(t, WithFallback(map[string][]string{"canary": {"stable"}}))
+	register(t, p, &backend.Route{Color: "stable", Address: "http://10.0.0.1:8080", Token: "t"})
+	register(t, p, &backend.Route{Color: "stable", Version: "v2", Address: "http://10.0.0.2:8080", Token: "t"})
+	client := newManagementClient(t, p)
+
+	resp, err := client.Resolve(context.Background(), &managementpb.ResolveRequest{Color: "canary"})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if resp.GetTarget() != "http://10.0.0.1:8080" || resp.GetRoute() != "stable" {
+		t.Errorf("fallback resolve = %s via %s, want stable target", resp.GetTarget(), resp.GetRoute())
+	}
+
+	resp, err = client.Resolve(context.Background(), &managementpb.ResolveRequest{Color: "stable", Version: "v2"})
+	if err != nil {
+		t.Fatalf("Resolve version: %v", err)
+	}
+	if resp.GetTarget() != "http://10.0.0.2:8080" || resp.GetRoute() != "stable:v2" {
+		t.Errorf("version resolve = %s via %s, want v2 target", resp.GetTarget(), resp.GetRoute())
+	}
+
+	if _, err := client.Resolve(context.Background(), &managementpb.ResolveRequest{Color: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("missing color: code = %v, want NotFound", status.Code(err))
+	}
+}
+
+func TestGRPCAuthorizeCustomTokenHeader(t *testing.T) {
+	p := newTestProxy(t, WithAdminToken("secret"), WithAdminTokenHeader("X-Admin-Token"))
+	client := newManagementClient(t, p)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-admin-token", "secret")
+	if _, err := client.List(ctx, &managementpb.ListRequest{}); err != nil {
+		t.Fatalf("List with custom header: %v", err)
+	}
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	if _, err := client.List(ctx, &managementpb.ListRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("bearer token with custom header configured: code = %v, want Unauthenticated", status.Code(err))
+	}
+}
+
+func TestGRPCDeleteForceRequiresMetadata(t *testing.T) {
+	p := newTestProxy(t, WithAdminToken("secret"))
+	register(t, p, &backend.Route{Color: "blue", Address: "http://10.0.0.1:8080", Token: "owner"})
+	client := newManagementClient(t, p)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	if _, err := client.Delete(ctx, &managementpb.DeleteRequest{Color: "blue", Token: "intruder"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("admin delete with wrong token: code = %v, want PermissionDenied", status.Code(err))
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-force-delete", "true")
+	if _, err := client.Delete(ctx, &managementpb.DeleteRequest{Color: "blue"}); err != nil {
+		t.Fatalf("forced Delete: %v", err)
+	}
+	if routeExists(p, "blue") {
+		t.Error("route still registered after forced delete")
+	}
+}
@@ -0,0 +1,4 @@
+// Package managementpb 路由管理 gRPC 服务的协议定义与生成代码
+package managementpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative management.proto
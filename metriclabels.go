@@ -0,0 +1,112 @@
+package color
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// MaxMetricLabels WithMetricLabels 可选的标签数上限
+const MaxMetricLabels = 4
+
+// MetricLabelOwner 代表路由 owner 的指标标签名，其余标签名取路由的 Labels
+const MetricLabelOwner = "owner"
+
+// WithMetricLabels 在请求指标中加入命中路由的 owner（MetricLabelOwner）与指定的路由标签（如 region）
+//
+// 每个标签都会按取值数量成倍增加时间序列，只应选择取值有限的标签；
+// 数量超过 MaxMetricLabels、重复或与 color、status 同名时 New 返回错误。
+// 标签取值来自后台清理任务缓存的路由表，路由在其他实例注册后到下一轮清理前取值为空。
+func WithMetricLabels(keys []string) Option {
+	return func(c *Config) {
+		c.MetricLabels = keys
+	}
+}
+
+// validateMetricLabels 校验 WithMetricLabels 的标签名
+func validateMetricLabels(keys []string) error {
+	if len(keys) > MaxMetricLabels {
+		return fmt.Errorf("at most %d metric labels are allowed, got %d", MaxMetricLabels, len(keys))
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		switch {
+		case k == "":
+			return fmt.Errorf("metric label name must not be empty")
+		case k == "color" || k == "status":
+			return fmt.Errorf("metric label %q is reserved", k)
+		case seen[k]:
+			return fmt.Errorf("duplicate metric label %q", k)
+		}
+		seen[k] = true
+	}
+	return nil
+}
+
+// routeLabels 路由 key -> 指标标签取值的快照，由后台清理任务整体替换，本实例注册时单独更新
+// 读取无锁：每次转发都会查询，写入时复制整个 map
+type routeLabels struct {
+	keys []string
+
+	mu       sync.Mutex // 串行化写入
+	snapshot atomic.Pointer[map[string]map[string]string]
+}
+
+func newRouteLabels(keys []string) *routeLabels {
+	if len(keys) == 0 {
+		return nil
+	}
+	l := &routeLabels{keys: keys}
+	empty := make(map[string]map[string]string)
+	l.snapshot.Store(&empty)
+	return l
+}
+
+// values 返回路由的指标标签取值，没有的标签取值为空
+func (l *routeLabels) values(route *backend.Route) map[string]string {
+	values := make(map[string]string, len(l.keys))
+	for _, k := range l.keys {
+		if k == MetricLabelOwner {
+			values[k] = route.Owner
+		} else {
+			values[k] = route.Labels[k]
+		}
+	}
+	return values
+}
+
+// replace 以清理任务得到的路由表替换快照
+func (l *routeLabels) replace(routes []*backend.Route) {
+	now := time.Now()
+	m := make(map[string]map[string]string, len(routes))
+	for _, route := range routes {
+		if !route.ExpiresAt.IsZero() && now.After(route.ExpiresAt) {
+			continue
+		}
+		m[route.Key()] = l.values(route)
+	}
+	l.mu.Lock()
+	l.snapshot.Store(&m)
+	l.mu.Unlock()
+}
+
+// set 更新单个路由的取值
+func (l *routeLabels) set(route *backend.Route) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := *l.snapshot.Load()
+	m := make(map[string]map[string]string, len(old)+1)
+	for k, v := range old {
+		m[k] = v
+	}
+	m[route.Key()] = l.values(route)
+	l.snapshot.Store(&m)
+}
+
+// lookup 返回路由 key 的取值（只读），未知路由返回 nil
+func (l *routeLabels) lookup(key string) map[string]string {
+	return (*l.snapshot.Load())[key]
+}
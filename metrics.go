@@ -0,0 +1,85 @@
+package color
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/asam264/color/internal/transport"
+)
+
+// MetricsCollector 指标采集接口
+// 核心包只依赖该接口，Prometheus 实现位于 prommetrics 子包，未启用时不会引入客户端库
+//
+// 迁移说明：旧版签名为 ObserveRequest(color string, status int, duration time.Duration)，
+// 实现方改为读取 obs.Color、obs.Status、obs.Duration 即可；不需要连接池指标时 ObserveConnPool 留空即可。
+type MetricsCollector interface {
+	// ObserveRequest 记录一次按 color 转发（或被拦截）的请求
+	ObserveRequest(obs RequestObservation)
+	// ObserveError 记录一次代理侧错误，reason 如 lookup_timeout、proxy、upstream_timeout、upstream_error、circuit_open、rate_limited、response_too_large
+	ObserveError(color, reason string)
+	// ObserveConnPool 每个采样周期调用一次，stats 为当期各 host 的连接池状态（不再出现的 host 已无连接），
+	// 只在启用 WithConnPoolMetrics 时调用
+	ObserveConnPool(stats map[string]ConnPoolStat)
+}
+
+// RequestObservation 一次请求的观测数据，见 MetricsCollector.ObserveRequest
+type RequestObservation struct {
+	Color    string
+	Status   int
+	Duration time.Duration
+	// TTFB 从收到请求到写出响应头的时间（转发时包括连接与后端处理，不含响应体传输），未写出响应时为 0
+	TTFB time.Duration
+	// 同时启用链路追踪时为当前 span 的 trace ID（未采样时为空），可作为延迟直方图的 exemplar
+	TraceID string
+	// 命中的路由 key（带版本时为 "<color>:<version>"，走回退链时为回退到的 color），未选中路由时为空
+	Route string
+	// WithMetricLabels 指定的标签 -> 命中路由的取值（只读），未配置或路由未知时为 nil
+	Labels map[string]string
+}
+
+// observeRequest 记录请求指标，r 为携带 span context 的请求（已转发时为 startForward 返回的请求），
+// route 为命中的路由 key，未选中路由时为空
+func (p *Proxy) observeRequest(sw *statusWriter, r *http.Request, color, route string, start time.Time) {
+	p.counters.requests.Add(1)
+	if p.config.Metrics == nil {
+		return
+	}
+	obs := RequestObservation{
+		Color:    color,
+		Status:   sw.status,
+		Duration: time.Since(start),
+		TTFB:     sw.ttfb(start),
+		Route:    route,
+	}
+	if p.labels != nil {
+		obs.Labels = p.labels.lookup(route)
+	}
+	if p.config.Tracer != nil {
+		obs.TraceID = p.config.Tracer.TraceID(r.Context())
+	}
+	p.config.Metrics.ObserveRequest(obs)
+}
+
+func (p *Proxy) observeError(color, reason string) {
+	p.counters.addError(reason)
+	if p.config.Metrics == nil {
+		return
+	}
+	p.config.Metrics.ObserveError(color, reason)
+}
+
+// upstreamErrorReason 传输层错误分类
+func upstreamErrorReason(status int, err error) string {
+	if errors.Is(err, transport.ErrResponseTooLarge) {
+		return "response_too_large"
+	}
+	switch status {
+	case http.StatusGatewayTimeout:
+		return "upstream_timeout"
+	case http.StatusServiceUnavailable:
+		// 传输层只会在熔断打开时返回 503
+		return "circuit_open"
+	}
+	return "upstream_error"
+}
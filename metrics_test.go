@@ -0,0 +1,249 @@
+package color
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// recordingMetrics 记录收到的请求观测数据
+type recordingMetrics struct {
+	mu    sync.Mutex
+	reqs  []RequestObservation
+	pools []map[string]ConnPoolStat
+}
+
+func (m *recordingMetrics) ObserveRequest(obs RequestObservation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reqs = append(m.reqs, obs)
+}
+
+func (m *recordingMetrics) ObserveError(color, reason string) {}
+
+func (m *recordingMetrics) ObserveConnPool(stats map[string]ConnPoolStat) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pools = append(m.pools, stats)
+}
+
+// lastConnPool 返回最近一次连接池采样
+func (m *recordingMetrics) lastConnPool() (map[string]ConnPoolStat, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.pools) == 0 {
+		return nil, false
+	}
+	return m.pools[len(m.pools)-1], true
+}
+
+func (m *recordingMetrics) observations() []RequestObservation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]RequestObservation(nil), m.reqs...)
+}
+
+type traceIDKey struct{}
+
+// fakeTracer 把固定的 trace ID 放进转发请求的 context
+type fakeTracer struct{ id string }
+
+func (t fakeTracer) StartForward(r *http.Request, color, target string) (*http.Request, func(int, error)) {
+	return r.WithContext(context.WithValue(r.Context(), traceIDKey{}, t.id)), func(int, error) {}
+}
+
+func (fakeTracer) TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceIDKey{}).(string)
+	return id
+}
+
+func TestObserveRequestCarriesTraceID(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer up.Close()
+
+	m := &recordingMetrics{}
+	p := newTestProxy(t, WithMetrics(m), WithForwardTracer(fakeTracer{id: "4bf92f3577b34da6a3ce929d0e0e4736"}))
+	register(t, p, &backend.Route{Color: "blue", Address: up.URL})
+
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("color", "blue")
+	serve(p, req)
+
+	obs := m.observations()
+	if len(obs) != 1 {
+		t.Fatalf("observations = %d, want 1", len(obs))
+	}
+	if obs[0].TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("TraceID = %q, want the forward span's trace ID", obs[0].TraceID)
+	}
+	if obs[0].Color != "blue" || obs[0].Status != http.StatusOK {
+		t.Errorf("observation = %+v", obs[0])
+	}
+}
+
+func TestObserveRequestWithoutTracer(t *testing.T) {
+	m := &recordingMetrics{}
+	p := newTestProxy(t, WithMetrics(m), WithStrictRouting())
+
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("color", "blue")
+	serve(p, req)
+
+	obs := m.observations()
+	if len(obs) != 1 || obs[0].TraceID != "" || obs[0].Status != http.StatusServiceUnavailable {
+		t.Fatalf("observations = %+v, want one 503 without trace ID", obs)
+	}
+}
+
+func TestObserveRequestCarriesRouteLabels(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer up.Close()
+
+	m := &recordingMetrics{}
+	p := newTestProxy(t, WithMetrics(m), WithMetricLabels([]string{MetricLabelOwner, "region"}))
+	route := &backend.Route{Color: "blue", Address: up.URL, Owner: "team-a", Token: "t", Labels: map[string]string{"region": "eu", "rack": "r1"}}
+	if err := p.registerRoute(context.Background(), route, false); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("color", "blue")
+	serve(p, req)
+
+	obs := m.observations()
+	if len(obs) != 1 {
+		t.Fatalf("observations = %d, want 1", len(obs))
+	}
+	if obs[0].Route != "blue" {
+		t.Errorf("Route = %q, want blue", obs[0].Route)
+	}
+	want := map[string]string{"owner": "team-a", "region": "eu"}
+	if len(obs[0].Labels) != len(want) || obs[0].Labels["owner"] != "team-a" || obs[0].Labels["region"] != "eu" {
+		t.Errorf("Labels = %v, want %v", obs[0].Labels, want)
+	}
+}
+
+func TestRouteLabelsRefreshedFromRouteTable(t *testing.T) {
+	p := newTestProxy(t, WithMetricLabels([]string{"region"}))
+	waitRoutesLoaded(t, p)
+	p.refreshRoutes([]*backend.Route{
+		{Color: "blue", Labels: map[string]string{"region": "eu"}},
+		{Color: "green", Labels: map[string]string{"region": "us"}, ExpiresAt: time.Now().Add(-time.Second)},
+	})
+	if got := p.labels.lookup("blue"); got["region"] != "eu" {
+		t.Errorf("lookup(blue) = %v, want region=eu", got)
+	}
+	if got := p.labels.lookup("green"); got != nil {
+		t.Errorf("lookup(green) = %v, want expired route skipped", got)
+	}
+}
+
+func TestMetricLabelsValidated(t *testing.T) {
+	for _, keys := range [][]string{
+		{"a", "b", "c", "d", "e"},
+		{"region", "region"},
+		{"status"},
+		{""},
+	} {
+		if _, err := New(WithMemoryBackend(), WithLogger(nopLogger{}), WithMetricLabels(keys)); err == nil {
+			t.Errorf("WithMetricLabels(%q): New succeeded, want error", keys)
+		}
+	}
+}
+
+// recordingAccessLog 记录访问日志
+type recordingAccessLog struct {
+	mu      sync.Mutex
+	entries []AccessLogEntry
+}
+
+func (l *recordingAccessLog) LogAccess(e AccessLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, e)
+}
+
+func (l *recordingAccessLog) LogTransport(string) {}
+
+func TestObserveRequestSeparatesTTFB(t *testing.T) {
+	const think, transfer = 50 * time.Millisecond, 100 * time.Millisecond
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(think)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(transfer)
+		w.Write([]byte("body"))
+	}))
+	defer up.Close()
+
+	m := &recordingMetrics{}
+	logs := &recordingAccessLog{}
+	p := newTestProxy(t, WithMetrics(m), WithAccessLog(logs))
+	register(t, p, &backend.Route{Color: "blue", Address: up.URL})
+
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("color", "blue")
+	serve(p, req)
+
+	obs := m.observations()
+	if len(obs) != 1 {
+		t.Fatalf("observations = %d, want 1", len(obs))
+	}
+	if obs[0].TTFB < think || obs[0].TTFB >= obs[0].Duration-transfer/2 {
+		t.Errorf("TTFB = %v, Duration = %v: want TTFB to cover the backend think time but not the body transfer", obs[0].TTFB, obs[0].Duration)
+	}
+	if len(logs.entries) != 1 || logs.entries[0].TTFB != obs[0].TTFB {
+		t.Errorf("access log entries = %+v, want TTFB %v", logs.entries, obs[0].TTFB)
+	}
+}
+
+func TestInformationalResponseIsNotFirstByte(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sw := &statusWriter{ResponseWriter: rec, status: http.StatusOK}
+	start := time.Now()
+	sw.WriteHeader(http.StatusContinue)
+	if sw.wroteHeader || sw.ttfb(start) != 0 {
+		t.Fatal("100 Continue counted as the response header")
+	}
+	time.Sleep(10 * time.Millisecond)
+	sw.WriteHeader(http.StatusCreated)
+	if sw.status != http.StatusCreated || sw.ttfb(start) < 10*time.Millisecond {
+		t.Errorf("status = %d, ttfb = %v, want the final header to set both", sw.status, sw.ttfb(start))
+	}
+}
+
+func TestConnPoolMetricsSampled(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer up.Close()
+	host := strings.TrimPrefix(up.URL, "http://")
+
+	m := &recordingMetrics{}
+	p := newTestProxy(t, WithMetrics(m), WithConnPoolMetrics(10*time.Millisecond))
+	register(t, p, &backend.Route{Color: "blue", Address: up.URL})
+
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("color", "blue")
+	serve(p, req)
+
+	want := ConnPoolStat{Idle: 1, InUse: 0}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if stats, ok := m.lastConnPool(); ok && stats[host] == want {
+			break
+		}
+		if time.Now().After(deadline) {
+			stats, _ := m.lastConnPool()
+			t.Fatalf("last conn pool sample = %v, want %s: %+v", stats, host, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := p.Stats().ConnPool[host]; got != want {
+		t.Errorf("Stats().ConnPool[%s] = %+v, want %+v", host, got, want)
+	}
+}
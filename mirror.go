@@ -0,0 +1,217 @@
+package color
+
+import (
+	"context"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/asam264/color/internal/strategy"
+	"github.com/asam264/color/internal/transport"
+)
+
+// MirrorHeader 镜像请求携带的 header，值为 "<来源 color>;sig=<签名>"
+// 签名有效的请求不会再被转发，避免影子服务把镜像流量转回生产；签名无效的 header 在转发前被删除，
+// 客户端无法借此绕过路由。签名密钥为 admin token（未配置时为进程内随机密钥），
+// 影子服务需要配置相同的 admin token 才能识别其他实例发出的镜像请求
+const MirrorHeader = "X-Colorproxy-Mirror"
+
+const (
+	defaultMirrorTimeout   = 10 * time.Second
+	defaultMirrorBodyLimit = 1 << 20
+)
+
+// MirrorConfig 流量镜像配置
+type MirrorConfig struct {
+	To         string  // 镜像目标 color
+	SampleRate float64 // 镜像比例，0~1
+}
+
+// WithMirror 将转发到 fromColor 的请求按 sampleRate（0~1）的比例镜像到 toColor：
+// 镜像请求由异步任务池（见 WithAsyncWorkers）发送，使用缓冲的 body 副本与独立的超时，响应被丢弃，失败只记录日志，不影响客户端。
+// body 超过 WithMaxBufferedBody 的上限（默认 1MiB）或协议升级的请求不镜像
+func WithMirror(fromColor, toColor string, sampleRate float64) Option {
+	return func(c *Config) {
+		if c.Mirrors == nil {
+			c.Mirrors = make(map[string]MirrorConfig)
+		}
+		c.Mirrors[fromColor] = MirrorConfig{To: toColor, SampleRate: sampleRate}
+	}
+}
+
+// WithMirrorTimeout 镜像请求的超时时间（含路由选择），默认 10s
+func WithMirrorTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.MirrorTimeout = d
+	}
+}
+
+// roundTripperer 传输层可选接口：返回转发使用的 http.RoundTripper
+type roundTripperer interface {
+	RoundTripper() http.RoundTripper
+}
+
+// mirrorer 发送镜像请求
+type mirrorer struct {
+	client *http.Client
+}
+
+// newMirrorer 复用传输层的 RoundTripper（TLS 等配置与转发一致），
+// 自定义传输层未提供时使用 http.DefaultTransport 的副本
+func newMirrorer(timeout time.Duration, ht transport.HTTPTransporter) *mirrorer {
+	var rt http.RoundTripper
+	if r, ok := ht.(roundTripperer); ok {
+		rt = r.RoundTripper()
+	}
+	if rt == nil {
+		rt = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	return &mirrorer{
+		client: &http.Client{
+			Transport: rt,
+			Timeout:   timeout,
+			// 镜像只需要发出请求，不跟随重定向
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// startMirror 按配置为 color 的请求启动镜像，返回主请求转发结束后调用的 done
+// 缓冲的 body 在主请求与镜像请求都结束后才释放
+func (p *Proxy) startMirror(r *http.Request, color string) (done func()) {
+	noop := func() {}
+	m, ok := p.config.Mirrors[color]
+	if !ok || p.mirror == nil || rand.Float64() >= m.SampleRate || r.Header.Get("Upgrade") != "" {
+		return noop
+	}
+
+	limit := p.config.MaxBufferedBody
+	if limit <= 0 {
+		limit = defaultMirrorBodyLimit
+	}
+	release, err := transport.BufferBody(r, limit)
+	if err != nil {
+		p.config.Logger.Error("mirror skipped: read body: %v", err)
+		return noop
+	}
+	if r.Body != nil && r.Body != http.NoBody && r.GetBody == nil {
+		release()
+		p.config.Logger.Error("mirror skipped: body exceeds %d bytes, color=%s", limit, color)
+		return noop
+	}
+
+	var pending atomic.Int32
+	pending.Store(2)
+	done = func() {
+		if pending.Add(-1) == 0 {
+			release()
+		}
+	}
+
+	req := r.Clone(context.Background())
+	run := func() {
+		defer done()
+		p.sendMirror(req, color, m.To)
+	}
+	if !p.runAsync(color, run, done) {
+		p.config.Logger.Error("mirror skipped: async queue is full, color=%s", color)
+	}
+	return done
+}
+
+// sendMirror 选择 to 的目标并发送镜像请求，丢弃响应
+func (p *Proxy) sendMirror(r *http.Request, from, to string) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.MirrorTimeout)
+	defer cancel()
+
+	target, err := p.selectTarget(ctx, strategy.FromHTTP(to, r))
+	if err != nil {
+		p.config.Logger.Error("mirror %s -> %s failed: %v", from, to, err)
+		return
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		p.config.Logger.Error("mirror %s -> %s failed: invalid target %s: %v", from, to, target, err)
+		return
+	}
+
+	out := r.Clone(ctx)
+	out.RequestURI = ""
+	out.Host = ""
+	out.URL = &url.URL{
+		Scheme:   u.Scheme,
+		Host:     u.Host,
+		Path:     strings.TrimSuffix(u.Path, "/") + r.URL.Path,
+		RawQuery: r.URL.RawQuery,
+	}
+	out.Body, out.ContentLength = nil, 0
+	if r.GetBody != nil {
+		if out.Body, err = r.GetBody(); err != nil {
+			p.config.Logger.Error("mirror %s -> %s failed: %v", from, to, err)
+			return
+		}
+		out.ContentLength = r.ContentLength
+	}
+	transport.RemoveHopByHopHeaders(out.Header)
+	out.Header.Set(MirrorHeader, p.signMirror(from))
+
+	resp, err := p.mirror.client.Do(out)
+	if err != nil {
+		p.config.Logger.Error("mirror %s -> %s failed: target=%s: %v", from, to, target, err)
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// mirrorSignature 计算来源 color 的镜像签名
+func mirrorSignature(key []byte, from string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(from))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signMirror 返回镜像请求的 MirrorHeader 值
+func (p *Proxy) signMirror(from string) string {
+	return from + ";sig=" + mirrorSignature(p.mirrorKey, from)
+}
+
+// validMirror 校验 MirrorHeader 的值是否由持有相同密钥的代理签发
+func (p *Proxy) validMirror(v string) bool {
+	from, sig, ok := strings.Cut(v, ";sig=")
+	if !ok {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(mirrorSignature(p.mirrorKey, from)))
+}
+
+// isMirrored 判断请求是否为代理发出的镜像请求；header 签名无效时将其删除，不再透传给后端
+func (p *Proxy) isMirrored(r *http.Request) bool {
+	v := r.Header.Get(MirrorHeader)
+	if v == "" {
+		return false
+	}
+	if p.validMirror(v) {
+		return true
+	}
+	r.Header.Del(MirrorHeader)
+	return false
+}
+
+// newMirrorKey 返回镜像签名密钥：优先使用 admin token，使同一集群的实例互相识别
+func newMirrorKey(adminToken string) []byte {
+	if adminToken != "" {
+		return []byte(adminToken)
+	}
+	key := make([]byte, 32)
+	crand.Read(key)
+	return key
+}
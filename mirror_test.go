@@ -0,0 +1,92 @@
+package color
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+func TestForgedMirrorHeaderIsForwarded(t *testing.T) {
+	var got http.Header
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer up.Close()
+
+	p := newTestProxy(t, WithStrictRouting())
+	register(t, p, &backend.Route{Color: "blue", Address: up.URL})
+
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("color", "blue")
+	req.Header.Set(MirrorHeader, "blue")
+	if rec := serve(p, req); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want forwarded with 200", rec.Code)
+	}
+	if got == nil {
+		t.Fatal("request with forged mirror header was not forwarded")
+	}
+	if v := got.Get(MirrorHeader); v != "" {
+		t.Errorf("forged mirror header forwarded: %q", v)
+	}
+}
+
+func TestSignedMirrorHeaderHandledLocally(t *testing.T) {
+	hit := false
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hit = true }))
+	defer up.Close()
+
+	p := newTestProxy(t, WithAdminToken("secret"))
+	register(t, p, &backend.Route{Color: "blue", Address: up.URL})
+
+	// 另一个配置相同 admin token 的实例签发的镜像请求
+	other := newTestProxy(t, WithAdminToken("secret"))
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("color", "blue")
+	req.Header.Set(MirrorHeader, other.signMirror("green"))
+	if rec := serve(p, req); rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want local handling (404)", rec.Code)
+	}
+	if hit {
+		t.Error("mirrored request was forwarded")
+	}
+}
+
+func TestMirrorUsesTransportTLSConfig(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer primary.Close()
+
+	mirrored := make(chan string, 1)
+	shadow := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrored <- r.Header.Get(MirrorHeader)
+	}))
+	defer shadow.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(shadow.Certificate())
+	p := newTestProxy(t,
+		WithHTTPTLSConfig(&tls.Config{RootCAs: pool}),
+		WithMirror("blue", "shadow", 1),
+	)
+	register(t, p, &backend.Route{Color: "blue", Address: primary.URL})
+	register(t, p, &backend.Route{Color: "shadow", Address: shadow.URL})
+
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("color", "blue")
+	if rec := serve(p, req); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+
+	select {
+	case v := <-mirrored:
+		if !p.validMirror(v) {
+			t.Errorf("mirror header %q does not carry a valid signature", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("mirror request did not reach the TLS shadow backend")
+	}
+}
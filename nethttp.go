@@ -0,0 +1,169 @@
+package color
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultAdminPrefix 管理端点的默认前缀
+const defaultAdminPrefix = "/colorproxy"
+
+// jsonMap JSON 响应体
+type jsonMap = map[string]interface{}
+
+// managementRoute 管理端点定义，Gin、net/http 与 Echo 集成按同一张表注册
+// path 相对 Config.AdminPrefix，路径参数使用 net/http 的 {name} 语法
+type managementRoute struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+}
+
+// managementRoutes 返回全部管理端点；除探针外都经过 admin 认证
+func (p *Proxy) managementRoutes() []managementRoute {
+	var routes []managementRoute
+
+	// 探针供 Kubernetes 等调用，不做认证，也不包含路由数据
+	routes = append(routes,
+		managementRoute{http.MethodGet, "/healthz", p.handleReadiness},
+		managementRoute{http.MethodGet, "/readyz", p.handleReadiness},
+		managementRoute{http.MethodGet, "/livez", p.handleLiveness},
+	)
+
+	admin := []managementRoute{
+		{http.MethodPost, "/register", p.handleRegister},
+		{http.MethodPost, "/heartbeat", p.handleHeartbeat},
+		{http.MethodGet, "/routes", p.handleListRoutes},
+		{http.MethodGet, "/resolve", p.handleResolve},
+		{http.MethodDelete, "/routes/{color}", p.handleDeleteRoute},
+		{http.MethodPost, "/maintenance", p.handleMaintenance},
+		{http.MethodPost, "/trace", p.handleTrace},
+		{http.MethodGet, "/stats", p.handleStats},
+		{http.MethodGet, "/policies", p.handlePolicies},
+		{http.MethodGet, "/breakers", p.handleBreakers},
+	}
+	if p.config.AdminUI {
+		admin = append(admin, managementRoute{http.MethodGet, "/ui", p.handleUI})
+	}
+	for _, r := range admin {
+		r.handler = p.requireAdmin(r.handler)
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+// isAdminPath 判断路径是否位于管理端点前缀下，集成中间件据此跳过转发；按 WithPathNormalization 规范化后比较
+func (p *Proxy) isAdminPath(path string) bool {
+	return p.config.PathNormalization.hasPrefix(path, p.config.AdminPrefix)
+}
+
+// colonPath 把 {name} 路径参数转换为 Gin/Echo 的 :name 语法
+func colonPath(path string) string {
+	return strings.NewReplacer("{", ":", "}", "").Replace(path)
+}
+
+// Handler 返回基于 net/http 的完整处理器：管理端点前缀（默认 /colorproxy）下为管理端点，其余请求按 color 转发，
+// 不需要转发的请求返回 404。适用于没有本地业务逻辑的纯网关部署。
+func (p *Proxy) Handler() http.Handler {
+	return p.Middleware(http.NotFoundHandler())
+}
+
+// Middleware 返回基于 net/http 的中间件，行为与 AttachGin 一致：
+// 管理端点前缀下为管理端点，带 color 的请求转发到目标服务，其余请求交给 next
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("/ping", ping)
+//	http.ListenAndServe(":8080", proxy.Middleware(mux))
+func (p *Proxy) Middleware(next http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	for _, r := range p.managementRoutes() {
+		mux.HandleFunc(r.method+" "+p.config.AdminPrefix+r.path, r.handler)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.isAdminPath(r.URL.Path) {
+			mux.ServeHTTP(w, p.normalizeAdminRequest(r))
+			return
+		}
+		p.serveProxy(w, r, func() { next.ServeHTTP(w, r) })
+	})
+}
+
+// abortResponse 中断已写出响应头的响应，使客户端读取 body 时得到错误：
+// HTTP/1.x 写出已缓冲的数据后接管并关闭连接（chunked 响应不会写出结束块），
+// 不支持接管时（如 HTTP/2）以 http.ErrAbortHandler 交给 http.Server 重置流
+func abortResponse(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	rc.Flush()
+	if conn, _, err := rc.Hijack(); err == nil {
+		conn.Close()
+		return
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// writeJSON 输出 JSON 响应；已设置 Content-Type（如 problem+json）时保留
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// decodeJSON 解析 JSON 请求体
+func decodeJSON(r *http.Request, v interface{}) error {
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+// statusWriter 记录响应状态码，用于指标与错误处理判断
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	bytes       int64
+	firstByte   time.Time // 首次写出响应头的时间，用于 TTFB
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	// 1xx 信息响应（如 100 Continue）之后还会写出最终状态码，不计入状态与 TTFB；101 是最终响应
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+		w.firstByte = time.Now()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// ttfb 返回从 start 到首次写出响应的时间，尚未写出时返回 0
+func (w *statusWriter) ttfb(start time.Time) time.Duration {
+	if w.firstByte.IsZero() {
+		return 0
+	}
+	return w.firstByte.Sub(start)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter（Hijack、Flush）
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,97 @@
+// Package oteltracing 基于 OpenTelemetry 的 color.ForwardTracer 实现
+//
+// 独立成子包，只有启用时才会引入 otel 依赖：
+//
+//	proxy, err := color.New(
+//		color.WithRedis("localhost:6379", "", 0),
+//		oteltracing.WithTracing(otel.GetTracerProvider()),
+//	)
+//
+// 每次转发创建一个名为 colorproxy.forward 的 span：父 context 从请求的 W3C traceparent 中提取，
+// 传播 header 注入到发往后端的请求，span 带有 color、目标地址与响应状态码。
+// 同时启用 prommetrics 时，已采样 span 的 trace ID 作为 exemplar 附加到延迟直方图。
+package oteltracing
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/asam264/color"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanName 转发 span 的名称
+const SpanName = "colorproxy.forward"
+
+const instrumentationName = "github.com/asam264/color/oteltracing"
+
+// Tracer OpenTelemetry 转发追踪
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// New 创建追踪器，propagator 为 nil 时使用 W3C Trace Context + Baggage
+func New(tp trace.TracerProvider, propagator propagation.TextMapPropagator) *Tracer {
+	if propagator == nil {
+		propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+	return &Tracer{
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: propagator,
+	}
+}
+
+// WithTracing 启用 OpenTelemetry 转发追踪（W3C Trace Context 传播）
+func WithTracing(tp trace.TracerProvider) color.Option {
+	return color.WithForwardTracer(New(tp, nil))
+}
+
+// StartForward 实现 color.ForwardTracer
+func (t *Tracer) StartForward(r *http.Request, colorName, target string) (*http.Request, func(status int, err error)) {
+	ctx := t.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+	attrs := []attribute.KeyValue{
+		attribute.String("colorproxy.color", colorName),
+		attribute.String("http.request.method", r.Method),
+		attribute.String("url.path", r.URL.Path),
+	}
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		attrs = append(attrs, attribute.String("server.address", u.Host))
+	} else {
+		attrs = append(attrs, attribute.String("server.address", target))
+	}
+
+	ctx, span := t.tracer.Start(ctx, SpanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+
+	// 克隆请求，避免把传播 header 写回调用方持有的请求
+	out := r.Clone(ctx)
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(out.Header))
+
+	return out, func(status int, err error) {
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		span.End()
+	}
+}
+
+// TraceID 实现 color.ForwardTracer：返回 ctx 中已采样 span 的 trace ID
+func (t *Tracer) TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsSampled() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
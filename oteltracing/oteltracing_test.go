@@ -0,0 +1,40 @@
+package oteltracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func spanContext(t *testing.T, flags trace.TraceFlags) context.Context {
+	t.Helper()
+	tid, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sid, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid, SpanID: sid, TraceFlags: flags})
+	return trace.ContextWithSpanContext(context.Background(), sc)
+}
+
+func TestTraceIDSampled(t *testing.T) {
+	tr := New(noop.NewTracerProvider(), nil)
+	if got := tr.TraceID(spanContext(t, trace.FlagsSampled)); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("TraceID = %q", got)
+	}
+}
+
+func TestTraceIDNotSampled(t *testing.T) {
+	tr := New(noop.NewTracerProvider(), nil)
+	if got := tr.TraceID(spanContext(t, 0)); got != "" {
+		t.Errorf("TraceID = %q, want empty for unsampled span", got)
+	}
+	if got := tr.TraceID(context.Background()); got != "" {
+		t.Errorf("TraceID = %q, want empty without span", got)
+	}
+}
@@ -0,0 +1,100 @@
+package color
+
+import (
+	"net/http"
+	"strings"
+)
+
+// PathNormalization 路径匹配时的规范化方式，见 WithPathNormalization
+type PathNormalization struct {
+	// CaseInsensitive 忽略大小写（路径参数如 {color} 的值保持原样）
+	CaseInsensitive bool
+	// TrailingSlash 忽略末尾的 /，"/colorproxy/stats/" 与 "/colorproxy/stats" 相同
+	TrailingSlash bool
+}
+
+// WithPathNormalization 设置代理比较路径时的规范化方式，作用于所有路径比较：
+// 管理端点前缀的判断（决定请求不被转发，Gin、net/http 与 Echo 集成一致）与 Handler/Middleware 的管理端点匹配。
+// 规范化只影响匹配，转发到后端的路径保持原样；Gin 与 Echo 的管理端点由框架路由匹配，需使用框架自身的设置。
+func WithPathNormalization(n PathNormalization) Option {
+	return func(c *Config) {
+		c.PathNormalization = n
+	}
+}
+
+func (n PathNormalization) enabled() bool {
+	return n.CaseInsensitive || n.TrailingSlash
+}
+
+// trim 按 TrailingSlash 去掉末尾的 /，根路径保持为 /
+func (n PathNormalization) trim(path string) string {
+	if !n.TrailingSlash || len(path) <= 1 {
+		return path
+	}
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+		return trimmed
+	}
+	return "/"
+}
+
+func (n PathNormalization) equal(a, b string) bool {
+	if n.CaseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// hasPrefix path 等于 prefix 或位于 prefix 之下（按段匹配，"/ab" 不在 "/a" 之下）
+func (n PathNormalization) hasPrefix(path, prefix string) bool {
+	path, prefix = n.trim(path), n.trim(prefix)
+	if len(path) < len(prefix) || !n.equal(path[:len(prefix)], prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || path[len(prefix)] == '/'
+}
+
+// canonicalAdminPath 按规范化方式把请求路径匹配到管理端点，返回端点定义中的写法（路径参数取请求中的原值）
+// 没有匹配的端点时原样返回，交给 ServeMux 处理
+func (p *Proxy) canonicalAdminPath(path string) string {
+	n := p.config.PathNormalization
+	segments := strings.Split(n.trim(path), "/")
+	for _, r := range p.managementRoutes() {
+		pattern := strings.Split(p.config.AdminPrefix+r.path, "/")
+		if len(pattern) != len(segments) {
+			continue
+		}
+		canonical := make([]string, len(pattern))
+		matched := true
+		for i, seg := range pattern {
+			switch {
+			case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") && segments[i] != "":
+				canonical[i] = segments[i]
+			case n.equal(seg, segments[i]):
+				canonical[i] = seg
+			default:
+				matched = false
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched {
+			return strings.Join(canonical, "/")
+		}
+	}
+	return path
+}
+
+// normalizeAdminRequest 按规范化后的管理端点路径复制请求，路径未变化时返回原请求
+func (p *Proxy) normalizeAdminRequest(r *http.Request) *http.Request {
+	if !p.config.PathNormalization.enabled() {
+		return r
+	}
+	path := p.canonicalAdminPath(r.URL.Path)
+	if path == r.URL.Path {
+		return r
+	}
+	r2 := r.Clone(r.Context())
+	r2.URL.Path, r2.URL.RawPath = path, ""
+	return r2
+}
@@ -0,0 +1,86 @@
+package color
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+func TestIsAdminPathNormalization(t *testing.T) {
+	tests := []struct {
+		name string
+		norm PathNormalization
+		path string
+		want bool
+	}{
+		{"exact", PathNormalization{}, "/colorproxy/stats", true},
+		{"mixed case without normalization", PathNormalization{}, "/ColorProxy/stats", false},
+		{"mixed case", PathNormalization{CaseInsensitive: true}, "/ColorProxy/Stats", true},
+		{"trailing slash on prefix", PathNormalization{TrailingSlash: true}, "/colorproxy/", true},
+		{"trailing slashes", PathNormalization{TrailingSlash: true}, "/colorproxy//", true},
+		{"mixed case and trailing slash", PathNormalization{CaseInsensitive: true, TrailingSlash: true}, "/COLORPROXY/", true},
+		{"segment boundary", PathNormalization{CaseInsensitive: true}, "/ColorProxyX/stats", false},
+		{"other path", PathNormalization{CaseInsensitive: true, TrailingSlash: true}, "/api/users/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProxy(t, WithPathNormalization(tt.norm))
+			if got := p.isAdminPath(tt.path); got != tt.want {
+				t.Errorf("isAdminPath(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagementEndpointNormalization(t *testing.T) {
+	p := newTestProxy(t, WithPathNormalization(PathNormalization{CaseInsensitive: true, TrailingSlash: true}))
+
+	if rec := serve(p, httptest.NewRequest(http.MethodGet, "/ColorProxy/Stats/", nil)); rec.Code != http.StatusOK {
+		t.Errorf("GET /ColorProxy/Stats/ = %d, want 200", rec.Code)
+	}
+
+	// 路径参数保持原样：只删除 "Beta"，不影响 "beta"
+	register(t, p, &backend.Route{Color: "Beta", Address: "http://127.0.0.1:1", Token: "t"})
+	register(t, p, &backend.Route{Color: "beta", Address: "http://127.0.0.1:2", Token: "t"})
+	req := httptest.NewRequest(http.MethodDelete, "/COLORPROXY/Routes/Beta/", nil)
+	req.Header.Set("X-Route-Token", "t")
+	if rec := serve(p, req); rec.Code != http.StatusOK {
+		t.Fatalf("DELETE = %d %s, want 200", rec.Code, rec.Body.String())
+	}
+	if route, _ := p.backend.Get(context.Background(), "Beta"); route != nil {
+		t.Error("route Beta still registered")
+	}
+	if route, _ := p.backend.Get(context.Background(), "beta"); route == nil {
+		t.Error("route beta was deleted")
+	}
+}
+
+func TestManagementEndpointWithoutNormalization(t *testing.T) {
+	p := newTestProxy(t)
+	if rec := serve(p, httptest.NewRequest(http.MethodGet, "/colorproxy/stats/", nil)); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /colorproxy/stats/ = %d, want 404", rec.Code)
+	}
+}
+
+func TestPathNormalizationKeepsForwardedPath(t *testing.T) {
+	paths := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+	}))
+	defer srv.Close()
+
+	p := newTestProxy(t, WithPathNormalization(PathNormalization{CaseInsensitive: true, TrailingSlash: true}))
+	register(t, p, &backend.Route{Color: "beta", Address: srv.URL, Token: "t"})
+
+	req := httptest.NewRequest(http.MethodGet, "/API/Users/", nil)
+	req.Header.Set("color", "beta")
+	if rec := serve(p, req); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := <-paths; got != "/API/Users/" {
+		t.Errorf("forwarded path = %q, want /API/Users/", got)
+	}
+}
@@ -0,0 +1,173 @@
+package color
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asam264/color/internal/transport"
+	"golang.org/x/time/rate"
+)
+
+// ColorPolicy 单个 color 的转发策略，零值字段使用对应的全局设置
+type ColorPolicy struct {
+	// Timeout 单个转发请求的总时长上限，替代 WithRequestTimeout（等待响应头仍受 HTTP 超时限制）
+	Timeout time.Duration
+	// MaxAttempts 最大尝试次数（含首次），1 表示不重试；重试条件与 WithRetry 相同，未配置 WithRetry 时重试 GET/HEAD/PUT/DELETE
+	MaxAttempts int
+	// RateLimit 每秒请求数与突发量，替代 WithRateLimit（该 color 不再受 exempt 影响）
+	RateLimit rate.Limit
+	RateBurst int
+	// MaxInFlight 同时进行的转发数上限，替代 WithMaxConcurrency
+	MaxInFlight int
+	// AllowedMethods 允许转发的方法，为空表示不限制；其他方法返回 405
+	AllowedMethods []string
+	// SetHeaders 转发前设置的请求 header，RemoveHeaders 转发前删除的请求 header
+	SetHeaders    map[string]string
+	RemoveHeaders []string
+}
+
+// WithColorPolicy 为 color（请求解析出的 color）设置转发策略，多次调用同一 color 时以最后一次为准
+// Timeout 与 MaxAttempts 仅作用于内置 HTTP 传输层；当前生效的策略可通过 GET /policies 查看
+func WithColorPolicy(color string, p ColorPolicy) Option {
+	return func(c *Config) {
+		if c.ColorPolicies == nil {
+			c.ColorPolicies = make(map[string]ColorPolicy)
+		}
+		c.ColorPolicies[color] = p
+	}
+}
+
+// policyRateLimits 策略中设置了速率的 color
+func policyRateLimits(policies map[string]ColorPolicy) map[string]rateSpec {
+	specs := make(map[string]rateSpec)
+	for color, p := range policies {
+		if p.RateLimit > 0 {
+			specs[color] = rateSpec{limit: p.RateLimit, burst: p.RateBurst}
+		}
+	}
+	return specs
+}
+
+// policyConcurrency 策略中设置了并发上限的 color
+func policyConcurrency(policies map[string]ColorPolicy) map[string]int {
+	limits := make(map[string]int)
+	for color, p := range policies {
+		if p.MaxInFlight > 0 {
+			limits[color] = p.MaxInFlight
+		}
+	}
+	return limits
+}
+
+// methodAllowed color 的策略是否允许该方法
+func (p *Proxy) methodAllowed(color, method string) bool {
+	policy, ok := p.config.ColorPolicies[color]
+	return !ok || len(policy.AllowedMethods) == 0 || containsFold(policy.AllowedMethods, method)
+}
+
+// writeMethodNotAllowed 返回 405 与 Allow header
+func (p *Proxy) writeMethodNotAllowed(w http.ResponseWriter, color, method string) {
+	w.Header().Set("Allow", strings.Join(p.config.ColorPolicies[color].AllowedMethods, ", "))
+	p.writeError(w, http.StatusMethodNotAllowed, "method not allowed for color", "", jsonMap{"color": color, "method": method})
+}
+
+// applyPolicy 按 color 的策略改写转发请求：header 规则与传输层的超时、重试
+// 有 header 规则时复制请求，不修改调用方持有的 header
+func (p *Proxy) applyPolicy(r *http.Request, color string) *http.Request {
+	policy, ok := p.config.ColorPolicies[color]
+	if !ok {
+		return r
+	}
+	if len(policy.SetHeaders) > 0 || len(policy.RemoveHeaders) > 0 {
+		r = r.Clone(r.Context())
+		for _, h := range policy.RemoveHeaders {
+			r.Header.Del(h)
+		}
+		for h, v := range policy.SetHeaders {
+			r.Header.Set(h, v)
+		}
+	}
+	if policy.Timeout > 0 || policy.MaxAttempts > 0 {
+		ctx := transport.WithRequestPolicy(r.Context(), transport.RequestPolicy{
+			Timeout:     policy.Timeout,
+			MaxAttempts: policy.MaxAttempts,
+		})
+		r = r.WithContext(ctx)
+	}
+	return r
+}
+
+// defaultPolicy 全局设置对应的策略
+func (p *Proxy) defaultPolicy() ColorPolicy {
+	timeout := p.config.RequestTimeout
+	if timeout <= 0 {
+		timeout = p.config.HTTPTimeout
+	}
+	return ColorPolicy{
+		Timeout:     timeout,
+		MaxAttempts: max(p.config.RetryAttempts, 1),
+		RateLimit:   p.config.RateLimit,
+		RateBurst:   p.config.RateLimitBurst,
+		MaxInFlight: p.config.MaxConcurrency,
+	}
+}
+
+// effectivePolicy 按字段合并 color 的策略与全局设置
+func (p *Proxy) effectivePolicy(policy ColorPolicy) ColorPolicy {
+	def := p.defaultPolicy()
+	if policy.Timeout <= 0 {
+		policy.Timeout = def.Timeout
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = def.MaxAttempts
+	}
+	if policy.RateLimit <= 0 {
+		policy.RateLimit, policy.RateBurst = def.RateLimit, def.RateBurst
+	}
+	if policy.MaxInFlight <= 0 {
+		policy.MaxInFlight = def.MaxInFlight
+	}
+	return policy
+}
+
+func policyJSON(policy ColorPolicy) jsonMap {
+	methods := policy.AllowedMethods
+	if methods == nil {
+		methods = []string{}
+	}
+	removed := policy.RemoveHeaders
+	if removed == nil {
+		removed = []string{}
+	}
+	headers := policy.SetHeaders
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	return jsonMap{
+		"timeout":         policy.Timeout.String(),
+		"max_attempts":    policy.MaxAttempts,
+		"rate_limit":      float64(policy.RateLimit),
+		"rate_burst":      policy.RateBurst,
+		"max_in_flight":   policy.MaxInFlight,
+		"allowed_methods": methods,
+		"set_headers":     headers,
+		"remove_headers":  removed,
+	}
+}
+
+// handlePolicies 返回全局默认值与各 color 合并后的生效策略（0 表示不限制）
+func (p *Proxy) handlePolicies(w http.ResponseWriter, r *http.Request) {
+	colors := make([]string, 0, len(p.config.ColorPolicies))
+	for color := range p.config.ColorPolicies {
+		colors = append(colors, color)
+	}
+	sort.Strings(colors)
+
+	policies := make(jsonMap, len(colors))
+	for _, color := range colors {
+		policies[color] = policyJSON(p.effectivePolicy(p.config.ColorPolicies[color]))
+	}
+	writeJSON(w, http.StatusOK, jsonMap{"defaults": policyJSON(p.defaultPolicy()), "policies": policies})
+}
@@ -0,0 +1,174 @@
+package color
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// newPolicyProxy 注册 slow 与 fast 两个 color 指向同一后端
+func newPolicyProxy(t *testing.T, backendHandler http.HandlerFunc, opts ...Option) *Proxy {
+	t.Helper()
+	srv := httptest.NewServer(backendHandler)
+	t.Cleanup(srv.Close)
+	p := newTestProxy(t, opts...)
+	register(t, p, &backend.Route{Color: "slow", Address: srv.URL, Token: "t"})
+	register(t, p, &backend.Route{Color: "fast", Address: srv.URL, Token: "t"})
+	return p
+}
+
+func colorRequest(method, color string) *http.Request {
+	req := httptest.NewRequest(method, "/api", nil)
+	req.Header.Set("color", color)
+	return req
+}
+
+func TestColorPolicyTimeout(t *testing.T) {
+	p := newPolicyProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("color") == "slow" {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		}
+	},
+		WithRequestTimeout(5*time.Second),
+		WithColorPolicy("slow", ColorPolicy{Timeout: 50 * time.Millisecond}),
+	)
+
+	if rec := serve(p, colorRequest(http.MethodGet, "slow")); rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("slow status = %d, want 504 from the policy timeout", rec.Code)
+	}
+	if rec := serve(p, colorRequest(http.MethodGet, "fast")); rec.Code != http.StatusOK {
+		t.Errorf("fast status = %d, want 200 under the global timeout", rec.Code)
+	}
+}
+
+func TestColorPolicyRetry(t *testing.T) {
+	var calls atomic.Int32
+	p := newPolicyProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, WithColorPolicy("slow", ColorPolicy{MaxAttempts: 3}))
+
+	serve(p, colorRequest(http.MethodGet, "slow"))
+	if n := calls.Swap(0); n != 3 {
+		t.Errorf("policy color attempts = %d, want 3", n)
+	}
+	serve(p, colorRequest(http.MethodGet, "fast"))
+	if n := calls.Load(); n != 1 {
+		t.Errorf("default color attempts = %d, want 1 without WithRetry", n)
+	}
+}
+
+func TestColorPolicyRateLimit(t *testing.T) {
+	p := newPolicyProxy(t, func(w http.ResponseWriter, r *http.Request) {},
+		WithColorPolicy("slow", ColorPolicy{RateLimit: 1, RateBurst: 1}),
+	)
+
+	serve(p, colorRequest(http.MethodGet, "slow"))
+	if rec := serve(p, colorRequest(http.MethodGet, "slow")); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("second slow request = %d, want 429", rec.Code)
+	}
+	for i := 0; i < 5; i++ {
+		if rec := serve(p, colorRequest(http.MethodGet, "fast")); rec.Code != http.StatusOK {
+			t.Fatalf("fast request %d = %d, want 200 without a global limit", i, rec.Code)
+		}
+	}
+}
+
+func TestColorPolicyMaxInFlight(t *testing.T) {
+	release := make(chan struct{})
+	entered := make(chan struct{}, 1)
+	p := newPolicyProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("color") == "slow" {
+			entered <- struct{}{}
+			<-release
+		}
+	}, WithColorPolicy("slow", ColorPolicy{MaxInFlight: 1}))
+
+	done := make(chan struct{})
+	go func() {
+		serve(p, colorRequest(http.MethodGet, "slow"))
+		close(done)
+	}()
+	<-entered
+
+	if rec := serve(p, colorRequest(http.MethodGet, "slow")); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("second slow request = %d, want 503", rec.Code)
+	}
+	if rec := serve(p, colorRequest(http.MethodGet, "fast")); rec.Code != http.StatusOK {
+		t.Errorf("fast request = %d, want 200", rec.Code)
+	}
+	close(release)
+	<-done
+}
+
+func TestColorPolicyMethodsAndHeaders(t *testing.T) {
+	seen := make(chan http.Header, 1)
+	p := newPolicyProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		seen <- r.Header.Clone()
+	}, WithColorPolicy("slow", ColorPolicy{
+		AllowedMethods: []string{http.MethodGet},
+		SetHeaders:     map[string]string{"X-Tier": "slow"},
+		RemoveHeaders:  []string{"Authorization"},
+	}))
+
+	rec := serve(p, colorRequest(http.MethodPost, "slow"))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodGet {
+		t.Errorf("POST = %d Allow=%q, want 405 Allow=GET", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	req := colorRequest(http.MethodGet, "slow")
+	req.Header.Set("Authorization", "Bearer x")
+	if rec := serve(p, req); rec.Code != http.StatusOK {
+		t.Fatalf("GET = %d, want 200", rec.Code)
+	}
+	h := <-seen
+	if h.Get("X-Tier") != "slow" || h.Get("Authorization") != "" {
+		t.Errorf("backend headers X-Tier=%q Authorization=%q, want set and removed", h.Get("X-Tier"), h.Get("Authorization"))
+	}
+	if req.Header.Get("Authorization") == "" {
+		t.Error("policy modified the caller's request headers")
+	}
+
+	if rec := serve(p, colorRequest(http.MethodPost, "fast")); rec.Code != http.StatusOK {
+		t.Errorf("POST to color without a policy = %d, want 200", rec.Code)
+	}
+}
+
+func TestPoliciesEndpoint(t *testing.T) {
+	p := newTestProxy(t,
+		WithRequestTimeout(2*time.Second),
+		WithRetry(2, 10*time.Millisecond),
+		WithMaxConcurrency(10),
+		WithColorPolicy("slow", ColorPolicy{Timeout: time.Second, RateLimit: 5, RateBurst: 1}),
+	)
+
+	rec := serve(p, httptest.NewRequest(http.MethodGet, "/colorproxy/policies", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var body struct {
+		Defaults map[string]interface{}            `json:"defaults"`
+		Policies map[string]map[string]interface{} `json:"policies"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Defaults["timeout"] != "2s" || body.Defaults["max_attempts"] != float64(2) {
+		t.Errorf("defaults = %v, want timeout 2s and 2 attempts", body.Defaults)
+	}
+	slow := body.Policies["slow"]
+	if slow["timeout"] != "1s" || slow["rate_limit"] != float64(5) {
+		t.Errorf("slow = %v, want its own timeout and rate limit", slow)
+	}
+	if slow["max_attempts"] != float64(2) || slow["max_in_flight"] != float64(10) {
+		t.Errorf("slow = %v, want attempts and in-flight inherited from the defaults", slow)
+	}
+}
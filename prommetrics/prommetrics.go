@@ -0,0 +1,137 @@
+// Package prommetrics 基于 Prometheus 的 color.MetricsCollector 实现
+//
+// 独立成子包，只有启用时才会引入 Prometheus 客户端库：
+//
+//	proxy, err := color.New(
+//		color.WithRedis("localhost:6379", "", 0),
+//		prommetrics.WithPrometheus(prometheus.DefaultRegisterer),
+//	)
+//
+// 同时启用 oteltracing 时，延迟直方图的样本带有 trace_id exemplar；
+// exemplar 只在 OpenMetrics 格式中输出，需要以 promhttp.HandlerOpts{EnableOpenMetrics: true} 暴露指标。
+//
+// 按路由 owner 与标签细分请求量（见 color.WithMetricLabels）：
+//
+//	prommetrics.WithPrometheus(prometheus.DefaultRegisterer, color.MetricLabelOwner, "region")
+//
+// 额外标签只加在 colorproxy_requests_total 上，延迟直方图仍只按 color 区分，避免时间序列成倍增长。
+package prommetrics
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/asam264/color"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector Prometheus 指标采集器
+type Collector struct {
+	labels   []string
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	ttfb     *prometheus.HistogramVec
+
+	connsIdle  *prometheus.GaugeVec
+	connsInUse *prometheus.GaugeVec
+}
+
+// New 创建采集器并注册到 reg
+// labels 为请求计数额外携带的路由标签，需与 color.WithMetricLabels 一致，数量不超过 color.MaxMetricLabels
+func New(reg prometheus.Registerer, labels ...string) (*Collector, error) {
+	if len(labels) > color.MaxMetricLabels {
+		return nil, fmt.Errorf("at most %d metric labels are allowed, got %d", color.MaxMetricLabels, len(labels))
+	}
+	c := &Collector{
+		labels: labels,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "colorproxy_requests_total",
+			Help: "Total number of requests routed by color.",
+		}, append([]string{"color", "status"}, labels...)),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "colorproxy_errors_total",
+			Help: "Total number of proxy-side errors by color and reason.",
+		}, []string{"color", "reason"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "colorproxy_request_duration_seconds",
+			Help:    "Latency of requests routed by color.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"color"}),
+		ttfb: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "colorproxy_ttfb_seconds",
+			Help:    "Time from receiving a request to writing the response header, by color.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"color"}),
+		connsIdle: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "colorproxy_conns_idle",
+			Help: "Idle connections in the HTTP transport pool by backend host (sampled, see color.WithConnPoolMetrics).",
+		}, []string{"host"}),
+		connsInUse: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "colorproxy_conns_in_use",
+			Help: "In-use connections in the HTTP transport pool by backend host (sampled, see color.WithConnPoolMetrics).",
+		}, []string{"host"}),
+	}
+
+	for _, m := range []prometheus.Collector{c.requests, c.errors, c.duration, c.ttfb, c.connsIdle, c.connsInUse} {
+		if err := reg.Register(m); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// WithPrometheus 启用 Prometheus 指标，注册失败（如重复注册）时 panic，与 prometheus.MustRegister 一致
+// 指定 labels 时同时设置 color.WithMetricLabels
+func WithPrometheus(reg prometheus.Registerer, labels ...string) color.Option {
+	c, err := New(reg, labels...)
+	if err != nil {
+		panic(err)
+	}
+	return func(cfg *color.Config) {
+		if len(labels) > 0 {
+			color.WithMetricLabels(labels)(cfg)
+		}
+		color.WithMetrics(c)(cfg)
+	}
+}
+
+// exemplarLabel exemplar 中 trace ID 的标签名，与 OpenMetrics 惯例一致
+const exemplarLabel = "trace_id"
+
+func (c *Collector) ObserveRequest(obs color.RequestObservation) {
+	values := make([]string, 0, 2+len(c.labels))
+	values = append(values, obs.Color, strconv.Itoa(obs.Status))
+	for _, k := range c.labels {
+		values = append(values, obs.Labels[k])
+	}
+	c.requests.WithLabelValues(values...).Inc()
+	observe(c.duration.WithLabelValues(obs.Color), obs.Duration, obs.TraceID)
+	if obs.TTFB > 0 {
+		observe(c.ttfb.WithLabelValues(obs.Color), obs.TTFB, obs.TraceID)
+	}
+}
+
+// observe 记录直方图样本，有 trace ID 时附加为 exemplar
+func observe(o prometheus.Observer, d time.Duration, traceID string) {
+	if eo, ok := o.(prometheus.ExemplarObserver); ok && traceID != "" {
+		eo.ObserveWithExemplar(d.Seconds(), prometheus.Labels{exemplarLabel: traceID})
+		return
+	}
+	o.Observe(d.Seconds())
+}
+
+func (c *Collector) ObserveError(color, reason string) {
+	c.errors.WithLabelValues(color, reason).Inc()
+}
+
+// ObserveConnPool 以本次采样替换连接池 gauge，已没有连接的 host 不再输出
+func (c *Collector) ObserveConnPool(stats map[string]color.ConnPoolStat) {
+	c.connsIdle.Reset()
+	c.connsInUse.Reset()
+	for host, stat := range stats {
+		c.connsIdle.WithLabelValues(host).Set(float64(stat.Idle))
+		c.connsInUse.WithLabelValues(host).Set(float64(stat.InUse))
+	}
+}
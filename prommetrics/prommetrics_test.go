@@ -0,0 +1,191 @@
+package prommetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asam264/color"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// histogram 返回已注册的延迟直方图
+func histogram(t *testing.T, reg *prometheus.Registry) *dto.Histogram {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() == "colorproxy_request_duration_seconds" {
+			return f.GetMetric()[0].GetHistogram()
+		}
+	}
+	t.Fatal("duration histogram not registered")
+	return nil
+}
+
+// exemplars 返回直方图各桶上的 exemplar
+func exemplars(h *dto.Histogram) []*dto.Exemplar {
+	var list []*dto.Exemplar
+	for _, b := range h.GetBucket() {
+		if e := b.GetExemplar(); e != nil {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+func TestObserveRequestAttachesExemplar(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := New(reg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c.ObserveRequest(color.RequestObservation{
+		Color:    "blue",
+		Status:   200,
+		Duration: 30 * time.Millisecond,
+		TraceID:  "4bf92f3577b34da6a3ce929d0e0e4736",
+	})
+
+	list := exemplars(histogram(t, reg))
+	if len(list) != 1 {
+		t.Fatalf("exemplars = %d, want 1", len(list))
+	}
+	labels := list[0].GetLabel()
+	if len(labels) != 1 || labels[0].GetName() != "trace_id" || labels[0].GetValue() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("exemplar labels = %v", labels)
+	}
+	if v := list[0].GetValue(); v != 0.03 {
+		t.Errorf("exemplar value = %v, want 0.03", v)
+	}
+}
+
+func TestObserveRequestWithoutTraceID(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := New(reg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c.ObserveRequest(color.RequestObservation{Color: "blue", Status: 200, Duration: time.Millisecond})
+
+	h := histogram(t, reg)
+	if h.GetSampleCount() != 1 {
+		t.Errorf("sample count = %d, want 1", h.GetSampleCount())
+	}
+	if list := exemplars(h); len(list) != 0 {
+		t.Errorf("exemplars = %v, want none", list)
+	}
+}
+
+func TestObserveRequestWithRouteLabels(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := New(reg, color.MetricLabelOwner, "region")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c.ObserveRequest(color.RequestObservation{
+		Color:  "blue",
+		Status: 200,
+		Labels: map[string]string{"owner": "team-a", "region": "eu"},
+	})
+	// 路由未知时标签取值为空
+	c.ObserveRequest(color.RequestObservation{Color: "blue", Status: 503})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]map[string]string{}
+	for _, f := range families {
+		if f.GetName() != "colorproxy_requests_total" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			got[labels["status"]] = labels
+		}
+	}
+	if l := got["200"]; l["owner"] != "team-a" || l["region"] != "eu" {
+		t.Errorf("labels for 200 = %v", l)
+	}
+	if l, ok := got["503"]; !ok || l["owner"] != "" || l["region"] != "" {
+		t.Errorf("labels for 503 = %v, want empty owner and region", l)
+	}
+}
+
+func TestNewRejectsTooManyLabels(t *testing.T) {
+	if _, err := New(prometheus.NewRegistry(), "a", "b", "c", "d", "e"); err == nil {
+		t.Error("New with 5 labels succeeded, want error")
+	}
+}
+
+func TestObserveRequestRecordsTTFB(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := New(reg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c.ObserveRequest(color.RequestObservation{Color: "blue", Status: 200, Duration: 80 * time.Millisecond, TTFB: 20 * time.Millisecond})
+	// 未写出响应时不记录 TTFB
+	c.ObserveRequest(color.RequestObservation{Color: "blue", Status: 200, Duration: time.Millisecond})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != "colorproxy_ttfb_seconds" {
+			continue
+		}
+		h := f.GetMetric()[0].GetHistogram()
+		if h.GetSampleCount() != 1 || h.GetSampleSum() != 0.02 {
+			t.Errorf("ttfb count = %d, sum = %v, want 1 and 0.02", h.GetSampleCount(), h.GetSampleSum())
+		}
+		return
+	}
+	t.Fatal("colorproxy_ttfb_seconds not registered")
+}
+
+// gauges 返回指标名对应的 host -> 取值
+func gauges(t *testing.T, reg *prometheus.Registry, name string) map[string]float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			got[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+		}
+	}
+	return got
+}
+
+func TestObserveConnPoolReplacesGauges(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := New(reg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c.ObserveConnPool(map[string]color.ConnPoolStat{
+		"10.0.0.1:80": {Idle: 3, InUse: 2},
+		"10.0.0.2:80": {Idle: 1},
+	})
+	c.ObserveConnPool(map[string]color.ConnPoolStat{"10.0.0.1:80": {Idle: 4, InUse: 1}})
+
+	if got := gauges(t, reg, "colorproxy_conns_idle"); len(got) != 1 || got["10.0.0.1:80"] != 4 {
+		t.Errorf("colorproxy_conns_idle = %v, want only 10.0.0.1:80=4", got)
+	}
+	if got := gauges(t, reg, "colorproxy_conns_in_use"); len(got) != 1 || got["10.0.0.1:80"] != 1 {
+		t.Errorf("colorproxy_conns_in_use = %v, want only 10.0.0.1:80=1", got)
+	}
+}
@@ -0,0 +1,136 @@
+package color
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimiter 按 color 的令牌桶限流，limiter 在某个 color 第一次转发时创建
+// 只有路由存在的 color 才会创建 limiter，长时间未使用的 limiter 在清理任务中回收，避免 map 无限增长
+// overrides 中的 color（来自 ColorPolicy）使用各自的速率，不受 exempt 与全局速率影响；全局速率为 0 时其他 color 不限流
+type rateLimiter struct {
+	limit     rate.Limit
+	burst     int
+	exempt    map[string]bool
+	overrides map[string]rateSpec
+
+	mu       sync.Mutex
+	limiters map[string]*colorLimiter
+}
+
+// rateSpec 令牌桶的速率与突发量
+type rateSpec struct {
+	limit rate.Limit
+	burst int
+}
+
+type colorLimiter struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+func newRateLimiter(limit rate.Limit, burst int, exempt []string, overrides map[string]rateSpec) *rateLimiter {
+	rl := &rateLimiter{
+		limit:     limit,
+		burst:     burst,
+		exempt:    make(map[string]bool, len(exempt)),
+		overrides: overrides,
+		limiters:  make(map[string]*colorLimiter),
+	}
+	for _, c := range exempt {
+		rl.exempt[c] = true
+	}
+	return rl
+}
+
+// spec 返回 color 的速率，不限流时返回 false
+func (rl *rateLimiter) spec(color string) (rateSpec, bool) {
+	if o, ok := rl.overrides[color]; ok {
+		return o, true
+	}
+	if rl.exempt[color] || rl.limit <= 0 {
+		return rateSpec{}, false
+	}
+	return rateSpec{limit: rl.limit, burst: rl.burst}, true
+}
+
+// allow 消耗 color 的一个令牌；被限流时返回需要等待的时间
+func (rl *rateLimiter) allow(color string) (bool, time.Duration) {
+	spec, ok := rl.spec(color)
+	if !ok {
+		return true, 0
+	}
+
+	rl.mu.Lock()
+	cl, ok := rl.limiters[color]
+	if !ok {
+		cl = &colorLimiter{limiter: rate.NewLimiter(spec.limit, spec.burst)}
+		rl.limiters[color] = cl
+	}
+	now := time.Now()
+	cl.lastUsed = now
+	rl.mu.Unlock()
+
+	res := cl.limiter.ReserveN(now, 1)
+	if !res.OK() {
+		// burst 为 0 时永远无法获得令牌
+		return false, time.Second
+	}
+	if delay := res.DelayFrom(now); delay > 0 {
+		res.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// limited 判断 color 当前是否会被限流，不消耗令牌（用于 /trace）
+func (rl *rateLimiter) limited(color string) bool {
+	if _, ok := rl.spec(color); !ok {
+		return false
+	}
+	rl.mu.Lock()
+	cl, ok := rl.limiters[color]
+	rl.mu.Unlock()
+	return ok && cl.limiter.Tokens() < 1
+}
+
+// prune 回收 idle 时间内未使用的 limiter，令牌桶早已补满，删除后重新创建不影响限流效果
+func (rl *rateLimiter) prune(idle time.Duration) {
+	cutoff := time.Now().Add(-idle)
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for color, cl := range rl.limiters {
+		if cl.lastUsed.Before(cutoff) {
+			delete(rl.limiters, color)
+		}
+	}
+}
+
+// limiterIdle 回收 limiter 的空闲时间：至少为最慢的令牌桶从空补满所需的时间
+func (rl *rateLimiter) limiterIdle() time.Duration {
+	idle := 10 * time.Minute
+	specs := []rateSpec{{limit: rl.limit, burst: rl.burst}}
+	for _, o := range rl.overrides {
+		specs = append(specs, o)
+	}
+	for _, spec := range specs {
+		if spec.limit > 0 && spec.limit != rate.Inf {
+			refill := time.Duration(float64(spec.burst) / float64(spec.limit) * float64(time.Second))
+			if refill > idle {
+				idle = refill
+			}
+		}
+	}
+	return idle
+}
+
+// writeRateLimited 返回 429，Retry-After 向上取整到秒
+func (p *Proxy) writeRateLimited(w http.ResponseWriter, color string, delay time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+	p.writeError(w, http.StatusTooManyRequests, "rate limit exceeded", "", jsonMap{"color": color})
+}
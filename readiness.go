@@ -0,0 +1,31 @@
+package color
+
+import (
+	"context"
+	"net/http"
+)
+
+// Ready 检查代理是否可以接收流量：未开始关闭，且后端在 ReadinessTimeout 内可达
+func (p *Proxy) Ready(ctx context.Context) error {
+	if p.drain.closing() {
+		return ErrShuttingDown
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.config.ReadinessTimeout)
+	defer cancel()
+	return p.backend.Ping(ctx)
+}
+
+// handleReadiness 就绪探针：后端可达时返回 200，否则返回 503
+func (p *Proxy) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	if err := p.Ready(r.Context()); err != nil {
+		p.config.Logger.Error("readiness check failed: %v", err)
+		writeJSON(w, http.StatusServiceUnavailable, jsonMap{"status": "unavailable", "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, jsonMap{"status": "ok"})
+}
+
+// handleLiveness 存活探针：进程能处理请求即返回 200，不检查后端
+func (p *Proxy) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, jsonMap{"status": "ok"})
+}
@@ -0,0 +1,33 @@
+package color
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// downBackend Ping 失败的后端
+type downBackend struct {
+	*backend.MemoryBackend
+}
+
+func (downBackend) Ping(context.Context) error { return errors.New("connection refused") }
+
+func TestReadinessPingsBackend(t *testing.T) {
+	p := newTestProxy(t)
+	if rec := serve(p, httptest.NewRequest(http.MethodGet, "/colorproxy/readyz", nil)); rec.Code != http.StatusOK {
+		t.Errorf("readyz = %d, want 200", rec.Code)
+	}
+
+	down := newTestProxy(t, WithBackend(downBackend{backend.NewMemoryBackend()}))
+	if rec := serve(down, httptest.NewRequest(http.MethodGet, "/colorproxy/readyz", nil)); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz with unreachable backend = %d, want 503", rec.Code)
+	}
+	if rec := serve(down, httptest.NewRequest(http.MethodGet, "/colorproxy/livez", nil)); rec.Code != http.StatusOK {
+		t.Errorf("livez with unreachable backend = %d, want 200", rec.Code)
+	}
+}
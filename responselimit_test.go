@@ -0,0 +1,121 @@
+package color
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+const responseLimit = 1500
+
+// newLimitedProxy 代理以真实 http.Server 运行，backend 的响应经限制 responseLimit 字节后转发
+func newLimitedProxy(t *testing.T, handler http.HandlerFunc) (*Proxy, *httptest.Server) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	p := newTestProxy(t, WithMaxResponseBody(responseLimit))
+	register(t, p, &backend.Route{Color: "beta", Address: srv.URL, Token: "t"})
+	front := httptest.NewServer(p.Handler())
+	t.Cleanup(front.Close)
+	return p, front
+}
+
+func getColor(t *testing.T, url string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url+"/api", nil)
+	req.Header.Set("color", "beta")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// chunkedHandler 不声明长度，分 chunks 次写出 1KiB 数据并逐次 Flush
+func chunkedHandler(chunks int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chunk := bytes.Repeat([]byte("x"), 1024)
+		for i := 0; i < chunks; i++ {
+			w.Write(chunk)
+			w.(http.Flusher).Flush()
+		}
+	}
+}
+
+func TestMaxResponseBodyChunkedAbort(t *testing.T) {
+	p, front := newLimitedProxy(t, chunkedHandler(4))
+
+	resp := getColor(t, front.URL)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ContentLength != -1 {
+		t.Fatalf("status = %d, content-length = %d, want a streamed 200", resp.StatusCode, resp.ContentLength)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err == nil {
+		t.Fatalf("read %d bytes without error, want the truncated stream to fail", len(body))
+	}
+	if len(body) != responseLimit {
+		t.Errorf("read %d bytes, want the %d bytes within the limit", len(body), responseLimit)
+	}
+	if n := p.Stats().Errors["response_too_large"]; n != 1 {
+		t.Errorf("errors[response_too_large] = %d, want 1", n)
+	}
+
+	// 中断的连接不影响后续请求
+	next := getColor(t, front.URL)
+	io.Copy(io.Discard, next.Body)
+	next.Body.Close()
+	if next.StatusCode != http.StatusOK {
+		t.Errorf("next status = %d, want 200", next.StatusCode)
+	}
+}
+
+func TestMaxResponseBodyChunkedWithinLimit(t *testing.T) {
+	_, front := newLimitedProxy(t, chunkedHandler(1))
+
+	resp := getColor(t, front.URL)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || len(body) != 1024 {
+		t.Errorf("read %d bytes, err = %v, want the complete 1024-byte body", len(body), err)
+	}
+}
+
+func TestMaxResponseBodyContentLength(t *testing.T) {
+	p, front := newLimitedProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(2*responseLimit))
+		w.Write(bytes.Repeat([]byte("x"), 2*responseLimit))
+	})
+
+	resp := getColor(t, front.URL)
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("status = %d, want 502 before any body is sent", resp.StatusCode)
+	}
+	if n := p.Stats().Errors["response_too_large"]; n != 1 {
+		t.Errorf("errors[response_too_large] = %d, want 1", n)
+	}
+}
+
+func TestMaxResponseBodyHead(t *testing.T) {
+	_, front := newLimitedProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(2*responseLimit))
+	})
+
+	req, _ := http.NewRequest(http.MethodHead, front.URL+"/api", nil)
+	req.Header.Set("color", "beta")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("HEAD status = %d, want 200", resp.StatusCode)
+	}
+}
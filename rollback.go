@@ -0,0 +1,180 @@
+package color
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+	"github.com/asam264/color/internal/strategy"
+)
+
+// rollbackBuckets 滑动窗口的分桶数，窗口按桶整体过期
+const rollbackBuckets = 10
+
+// rollbackMinRequests 窗口内的请求数达到该值才判断错误率，避免少量请求触发回滚
+const rollbackMinRequests = 20
+
+// WithAutoRollback 金丝雀自动回滚：在 window 滑动窗口内转发到 canaryColor 的请求中，
+// 5xx（含转发失败）比例超过 errorRateThreshold（0-1）时把 canary 的流量比例降为 0，并发出 CanaryRolledBack 事件
+// 需要配合 WithCanaryStrategy 使用，且 canaryColor 与其 canary 一致；窗口内请求不足 20 个时不判断
+// 回滚后保持为 0，恢复放量需重启（或重新部署）代理
+func WithAutoRollback(canaryColor string, errorRateThreshold float64, window time.Duration) Option {
+	return func(c *Config) {
+		c.AutoRollback = &AutoRollbackConfig{
+			Color:     canaryColor,
+			Threshold: errorRateThreshold,
+			Window:    window,
+		}
+	}
+}
+
+// AutoRollbackConfig 金丝雀自动回滚配置，见 WithAutoRollback
+type AutoRollbackConfig struct {
+	Color     string
+	Threshold float64
+	Window    time.Duration
+}
+
+func (c *AutoRollbackConfig) validate(cfg *Config) error {
+	switch {
+	case cfg.CanaryStable == "" || c.Color != cfg.CanaryColor:
+		return fmt.Errorf("auto rollback: color %q is not the canary of WithCanaryStrategy", c.Color)
+	case c.Threshold <= 0 || c.Threshold > 1:
+		return fmt.Errorf("auto rollback: error rate threshold %v out of range (0, 1]", c.Threshold)
+	case c.Window <= 0:
+		return fmt.Errorf("auto rollback: window must be positive")
+	}
+	return nil
+}
+
+// rollbackMonitor 统计转发到 canary 地址的请求与 5xx，超过阈值时回滚
+type rollbackMonitor struct {
+	cfg    AutoRollbackConfig
+	canary *strategy.CanaryStrategy
+	window *rateWindow
+
+	mu         sync.RWMutex
+	targets    map[string]bool // canary 路由的地址
+	rolledBack bool
+}
+
+func newRollbackMonitor(cfg AutoRollbackConfig, canary *strategy.CanaryStrategy) *rollbackMonitor {
+	return &rollbackMonitor{
+		cfg:     cfg,
+		canary:  canary,
+		window:  newRateWindow(cfg.Window, rollbackBuckets),
+		targets: make(map[string]bool),
+	}
+}
+
+// updateTargets 以路由表中 canary 路由的地址更新判断依据
+func (m *rollbackMonitor) updateTargets(routes []*backend.Route) {
+	for _, route := range routes {
+		if route.Key() == m.cfg.Color {
+			m.setTargets(route)
+			return
+		}
+	}
+	m.mu.Lock()
+	m.targets = make(map[string]bool)
+	m.mu.Unlock()
+}
+
+func (m *rollbackMonitor) setTargets(route *backend.Route) {
+	targets := make(map[string]bool)
+	for _, addr := range endpointAddresses(route) {
+		targets[addr] = true
+	}
+	m.mu.Lock()
+	m.targets = targets
+	m.mu.Unlock()
+}
+
+// observe 记录一次转发；target 不属于 canary 时忽略，触发回滚时返回窗口内的请求数与错误率
+func (m *rollbackMonitor) observe(target string, status int, now time.Time) (requests uint64, rate float64, tripped bool) {
+	m.mu.RLock()
+	isCanary, done := m.targets[target], m.rolledBack
+	m.mu.RUnlock()
+	if !isCanary || done {
+		return 0, 0, false
+	}
+
+	total, failed := m.window.add(now, status >= 500)
+	if total < rollbackMinRequests {
+		return 0, 0, false
+	}
+	rate = float64(failed) / float64(total)
+	if rate <= m.cfg.Threshold {
+		return 0, 0, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rolledBack {
+		return 0, 0, false
+	}
+	m.rolledBack = true
+	m.canary.SetPercent(0)
+	return total, rate, true
+}
+
+// observeCanary 转发结束后检查 canary 错误率，超过阈值时回滚并发出事件
+func (p *Proxy) observeCanary(target string, status int) {
+	if p.rollback == nil {
+		return
+	}
+	requests, rate, tripped := p.rollback.observe(target, status, time.Now())
+	if !tripped {
+		return
+	}
+	cfg := p.rollback.cfg
+	p.config.Logger.Error("canary %s rolled back: error rate %.2f > %.2f over %v (%d requests)",
+		cfg.Color, rate, cfg.Threshold, cfg.Window, requests)
+	p.emitEvent(RouteEvent{Type: CanaryRolledBack, Color: cfg.Color, Address: target, ErrorRate: rate, Requests: requests})
+}
+
+// rateWindow 按桶计数的滑动窗口：总数与失败数
+type rateWindow struct {
+	width time.Duration // 每个桶的时长
+
+	mu      sync.Mutex
+	buckets []rateBucket
+}
+
+type rateBucket struct {
+	slot          int64 // 桶对应的时间片序号，过期的桶在复用时清零
+	total, failed uint64
+}
+
+func newRateWindow(window time.Duration, n int) *rateWindow {
+	width := window / time.Duration(n)
+	if width <= 0 {
+		width = 1
+	}
+	return &rateWindow{width: width, buckets: make([]rateBucket, n)}
+}
+
+// add 记录一次结果，返回窗口内的总数与失败数
+func (w *rateWindow) add(now time.Time, failed bool) (total, failures uint64) {
+	slot := now.UnixNano() / int64(w.width)
+	n := int64(len(w.buckets))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	b := &w.buckets[slot%n]
+	if b.slot != slot {
+		*b = rateBucket{slot: slot}
+	}
+	b.total++
+	if failed {
+		b.failed++
+	}
+	for _, b := range w.buckets {
+		if slot-b.slot < n {
+			total += b.total
+			failures += b.failed
+		}
+	}
+	return total, failures
+}
@@ -0,0 +1,112 @@
+package color
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// newCanaryProxy stable 返回 200、canary 返回 canaryStatus，canary 分到全部流量
+func newCanaryProxy(t *testing.T, canaryStatus int, events chan RouteEvent, threshold float64) *Proxy {
+	t.Helper()
+	stable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(stable.Close)
+	canary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(canaryStatus)
+	}))
+	t.Cleanup(canary.Close)
+
+	p := newTestProxy(t,
+		WithCanaryStrategy("stable", "canary", 100),
+		WithAutoRollback("canary", threshold, time.Minute),
+		WithRouteListener(func(ev RouteEvent) { events <- ev }),
+	)
+	for _, route := range []*backend.Route{
+		{Color: "stable", Address: stable.URL, Token: "t"},
+		{Color: "canary", Address: canary.URL, Token: "t"},
+	} {
+		if err := p.registerRoute(context.Background(), route, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p
+}
+
+func serveColor(p *Proxy, color string) int {
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("color", color)
+	return serve(p, req).Code
+}
+
+func TestAutoRollbackOnCanaryErrors(t *testing.T) {
+	events := make(chan RouteEvent, 8)
+	p := newCanaryProxy(t, http.StatusInternalServerError, events, 0.5)
+
+	for i := 0; i < rollbackMinRequests; i++ {
+		if code := serveColor(p, "stable"); code != http.StatusInternalServerError {
+			t.Fatalf("request %d: status = %d, want canary's 500 before rollback", i, code)
+		}
+	}
+	if code := serveColor(p, "stable"); code != http.StatusOK {
+		t.Errorf("status after rollback = %d, want stable's 200", code)
+	}
+	if percent := p.rollback.canary.Percent(); percent != 0 {
+		t.Errorf("canary percent = %d, want 0", percent)
+	}
+
+	select {
+	case ev := <-events:
+		for ev.Type != CanaryRolledBack {
+			ev = <-events
+		}
+		if ev.Color != "canary" || ev.ErrorRate != 1 || ev.Requests != rollbackMinRequests {
+			t.Errorf("event = %+v, want canary rolled back at rate 1 over %d requests", ev, rollbackMinRequests)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no rollback event")
+	}
+}
+
+func TestAutoRollbackIgnoresHealthyCanary(t *testing.T) {
+	events := make(chan RouteEvent, 8)
+	p := newCanaryProxy(t, http.StatusNotFound, events, 0.5)
+
+	for i := 0; i < 2*rollbackMinRequests; i++ {
+		serveColor(p, "stable")
+	}
+	if percent := p.rollback.canary.Percent(); percent != 100 {
+		t.Errorf("canary percent = %d, want 100: 4xx must not count as errors", percent)
+	}
+}
+
+func TestAutoRollbackValidated(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"no canary":      {WithAutoRollback("canary", 0.1, time.Minute)},
+		"other color":    {WithCanaryStrategy("stable", "canary", 10), WithAutoRollback("blue", 0.1, time.Minute)},
+		"threshold":      {WithCanaryStrategy("stable", "canary", 10), WithAutoRollback("canary", 1.5, time.Minute)},
+		"window":         {WithCanaryStrategy("stable", "canary", 10), WithAutoRollback("canary", 0.1, 0)},
+		"zero threshold": {WithCanaryStrategy("stable", "canary", 10), WithAutoRollback("canary", 0, time.Minute)},
+	} {
+		if _, err := New(append([]Option{WithMemoryBackend(), WithLogger(nopLogger{})}, opts...)...); err == nil {
+			t.Errorf("%s: New succeeded, want error", name)
+		}
+	}
+}
+
+func TestRateWindowExpiresOldBuckets(t *testing.T) {
+	w := newRateWindow(10*time.Second, 10)
+	start := time.Unix(1000, 0)
+	for i := 0; i < 5; i++ {
+		w.add(start, true)
+	}
+	if total, failed := w.add(start.Add(5*time.Second), false); total != 6 || failed != 5 {
+		t.Errorf("within window: total = %d, failed = %d, want 6 and 5", total, failed)
+	}
+	if total, failed := w.add(start.Add(11*time.Second), false); total != 2 || failed != 0 {
+		t.Errorf("after window: total = %d, failed = %d, want 2 and 0", total, failed)
+	}
+}
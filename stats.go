@@ -0,0 +1,123 @@
+package color
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+	"github.com/asam264/color/internal/transport"
+)
+
+// ProxyStats 代理运行状态快照，见 Proxy.Stats
+type ProxyStats struct {
+	// 路由数据来自后台清理任务最近一轮得到的路由表，RoutesUpdatedAt 为零表示尚未加载
+	Routes          int            `json:"routes"`          // 路由数（带版本的路由按 key 分别计数）
+	Endpoints       int            `json:"endpoints"`       // 所有路由的地址总数
+	RoutesByColor   map[string]int `json:"routes_by_color"` // color -> 路由数（含各版本）
+	RoutesUpdatedAt time.Time      `json:"routes_updated_at"`
+
+	InFlight  int64             `json:"in_flight"` // 进行中的请求数（含本地处理）
+	Requests  uint64            `json:"requests"`  // 由代理处理的请求数（转发或直接返回错误）
+	Forwarded uint64            `json:"forwarded"` // 实际转发到后端的请求数
+	Errors    map[string]uint64 `json:"errors"`    // 错误原因 -> 次数，原因与 Metrics.ObserveError 一致
+
+	// 异步任务池（流量镜像）排队中的任务数与因队列已满被丢弃的任务数
+	AsyncQueued  int    `json:"async_queued"`
+	AsyncDropped uint64 `json:"async_dropped"`
+
+	// 启用熔断时各 target 的熔断状态（closed / open / half-open）
+	Breakers map[string]string `json:"breakers,omitempty"`
+	// 启用健康检查时最近一轮探测不健康的地址（路由 key -> 地址）
+	Unhealthy map[string][]string `json:"unhealthy,omitempty"`
+	// 启用 WithConnPoolMetrics 时各后端 host 的连接池状态
+	ConnPool map[string]ConnPoolStat `json:"conn_pool,omitempty"`
+}
+
+// proxyCounters 代理自身维护的计数器，不依赖是否配置了 Metrics
+type proxyCounters struct {
+	requests  atomic.Uint64
+	forwarded atomic.Uint64
+	errors    sync.Map // reason -> *atomic.Uint64
+}
+
+func (c *proxyCounters) addError(reason string) {
+	v, ok := c.errors.Load(reason)
+	if !ok {
+		v, _ = c.errors.LoadOrStore(reason, new(atomic.Uint64))
+	}
+	v.(*atomic.Uint64).Add(1)
+}
+
+// breakerStater 传输层可选接口：返回各 target 的熔断状态
+type breakerStater interface {
+	BreakerStates() map[string]transport.BreakerStatus
+}
+
+// Stats 返回代理运行状态快照：计数器为原子读取，路由数据来自后台任务缓存的路由表，不访问后端，可并发调用
+func (p *Proxy) Stats() ProxyStats {
+	stats := ProxyStats{
+		RoutesByColor: make(map[string]int),
+		InFlight:      p.inflight.Load(),
+		Requests:      p.counters.requests.Load(),
+		Forwarded:     p.counters.forwarded.Load(),
+		Errors:        make(map[string]uint64),
+		AsyncQueued:   len(p.async.tasks),
+		AsyncDropped:  p.async.dropped.Load(),
+	}
+
+	p.events.mu.Lock()
+	stats.Routes = len(p.events.known)
+	stats.RoutesUpdatedAt = p.events.updated
+	for key, addresses := range p.events.known {
+		color, _, _ := strings.Cut(key, backend.VersionSeparator)
+		stats.RoutesByColor[color]++
+		stats.Endpoints += len(addresses)
+	}
+	p.events.mu.Unlock()
+
+	p.counters.errors.Range(func(key, value interface{}) bool {
+		stats.Errors[key.(string)] = value.(*atomic.Uint64).Load()
+		return true
+	})
+
+	if bs, ok := p.http.(breakerStater); ok {
+		if states := bs.BreakerStates(); len(states) > 0 {
+			stats.Breakers = make(map[string]string, len(states))
+			for target, state := range states {
+				stats.Breakers[target] = state.State.String()
+			}
+		}
+	}
+
+	if p.config.ConnPoolInterval > 0 {
+		if conns := p.connPoolStats(); len(conns) > 0 {
+			stats.ConnPool = conns
+		}
+	}
+
+	if p.health != nil {
+		p.health.mu.RLock()
+		for key, addresses := range p.health.unhealthy {
+			for address := range addresses {
+				if stats.Unhealthy == nil {
+					stats.Unhealthy = make(map[string][]string)
+				}
+				stats.Unhealthy[key] = append(stats.Unhealthy[key], address)
+			}
+		}
+		p.health.mu.RUnlock()
+		for _, addresses := range stats.Unhealthy {
+			sort.Strings(addresses)
+		}
+	}
+	return stats
+}
+
+// handleStats 以 JSON 返回 Stats 快照
+func (p *Proxy) handleStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, p.Stats())
+}
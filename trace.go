@@ -0,0 +1,157 @@
+package color
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// routeTrace 路由决策过程记录（dry-run，不转发）
+type routeTrace struct {
+	Steps []traceStep `json:"steps"`
+}
+
+type traceStep struct {
+	Stage  string `json:"stage"`
+	Result string `json:"result"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// add 记录一步决策；tr 为 nil 时不做任何事，便于在请求路径上复用同一套解析逻辑
+func (tr *routeTrace) add(stage, result, detail string) {
+	if tr == nil {
+		return
+	}
+	tr.Steps = append(tr.Steps, traceStep{Stage: stage, Result: result, Detail: detail})
+}
+
+// handleTrace 按样例请求演练完整的路由解析流程并返回决策过程，不实际转发
+func (p *Proxy) handleTrace(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Method  string            `json:"method"`
+		Path    string            `json:"path"`
+		Headers map[string]string `json:"headers"`
+	}
+
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, jsonMap{"error": err.Error()})
+		return
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	if req.Path == "" {
+		req.Path = "/"
+	}
+
+	u, err := url.ParseRequestURI(req.Path)
+	if err != nil {
+		writeJSON(w, 400, jsonMap{"error": err.Error()})
+		return
+	}
+	sample := (&http.Request{Method: req.Method, URL: u, Header: make(http.Header)}).WithContext(r.Context())
+	for k, v := range req.Headers {
+		sample.Header.Set(k, v)
+	}
+	// 一致性哈希在没有 key 时按客户端 IP 选择，演练时使用调用方的地址
+	sample.RemoteAddr = r.RemoteAddr
+
+	tr := &routeTrace{}
+	action, color, target := p.traceRoute(sample, tr)
+
+	writeJSON(w, 200, jsonMap{
+		"action": action,
+		"color":  color,
+		"target": target,
+		"steps":  tr.Steps,
+	})
+}
+
+// traceRoute 与 serveProxy 保持相同的判定顺序，返回最终动作、color 与目标
+func (p *Proxy) traceRoute(req *http.Request, tr *routeTrace) (action, color, target string) {
+	if p.localPreflight(req) {
+		tr.add("cors", req.Header.Get("Origin"), "preflight answered locally")
+		return "preflight", "", ""
+	}
+
+	if target := p.overrideTarget(req); target != "" {
+		tr.add("override", target, "trusted target override header")
+		return "override", "", target
+	}
+
+	color = p.requestColor(req, tr)
+	if color == "" {
+		tr.add("color", "", "no color resolved")
+		if p.config.StrictRouting {
+			return "error", "", ""
+		}
+		return "local", "", ""
+	}
+
+	if p.config.LocalColor != "" && color == p.config.LocalColor {
+		tr.add("local", color, "matches local color")
+		return "local", color, ""
+	}
+
+	if p.validMirror(req.Header.Get(MirrorHeader)) {
+		tr.add("local", color, "mirrored request")
+		return "local", color, ""
+	}
+
+	if p.inMaintenance(color) {
+		tr.add("maintenance", color, "color is in maintenance")
+		return "maintenance", color, ""
+	}
+
+	rreq := p.routingRequest(color, req)
+	target, used, err := p.selectWithFallback(req.Context(), rreq)
+	if rreq.Version != "" && used == backend.RouteKey(color, rreq.Version) {
+		tr.add("version", used, "matched version "+rreq.Version)
+	} else if used != color {
+		tr.add("fallback", used, "no route for "+color)
+	}
+	if err != nil {
+		tr.add("strategy", "", fmt.Sprintf("%T: %v", p.strategy, err))
+		if errors.Is(err, ErrLookupTimeout) && p.config.LookupTimeoutStatus != 0 {
+			return "error", color, ""
+		}
+		if p.config.StrictRouting && !errors.Is(err, errFallbackLocal) {
+			return "error", color, ""
+		}
+		return "local", color, ""
+	}
+	tr.add("strategy", target, fmt.Sprintf("%T", p.strategy))
+
+	if !p.methodAllowed(color, req.Method) {
+		tr.add("method", req.Method, "not allowed by color policy")
+		return "method_not_allowed", color, target
+	}
+
+	if p.limiter != nil && p.limiter.limited(color) {
+		tr.add("ratelimit", color, "rate limit exceeded")
+		return "rate_limited", color, target
+	}
+
+	if p.concurrency != nil {
+		if limit := p.concurrency.limitFor(color); limit > 0 {
+			n := p.concurrency.inflight(color)
+			tr.add("concurrency", color, fmt.Sprintf("%d/%d in flight", n, limit))
+			if n >= limit && p.concurrency.queueTimeout <= 0 {
+				return "concurrency_limited", color, target
+			}
+		}
+	}
+
+	if p.config.ProxyAuthorizer != nil {
+		tr.add("authorizer", "skipped", "authorizer is not evaluated in dry run")
+	}
+
+	if m, ok := p.config.Mirrors[color]; ok {
+		tr.add("mirror", m.To, fmt.Sprintf("sample rate %g", m.SampleRate))
+	}
+
+	return "forward", color, target
+}
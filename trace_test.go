@@ -0,0 +1,37 @@
+package color
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+// traceAction 调用 /colorproxy/trace 演练样例请求，返回最终动作
+func traceAction(t *testing.T, p *Proxy, body string) string {
+	t.Helper()
+	rec := serve(p, httptest.NewRequest(http.MethodPost, "/colorproxy/trace", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("trace status = %d, body = %s", rec.Code, rec.Body)
+	}
+	var resp struct{ Action string }
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Action
+}
+
+func TestTraceReportsMethodNotAllowed(t *testing.T) {
+	p := newTestProxy(t, WithColorPolicy("blue", ColorPolicy{AllowedMethods: []string{http.MethodGet}}))
+	register(t, p, &backend.Route{Color: "blue", Address: "http://10.0.0.1:8080", Token: "t"})
+
+	if action := traceAction(t, p, `{"method":"POST","path":"/api","headers":{"color":"blue"}}`); action != "method_not_allowed" {
+		t.Errorf("POST action = %q, want method_not_allowed as serveProxy answers 405", action)
+	}
+	if action := traceAction(t, p, `{"method":"GET","path":"/api","headers":{"color":"blue"}}`); action != "forward" {
+		t.Errorf("GET action = %q, want forward", action)
+	}
+}
@@ -0,0 +1,30 @@
+package color
+
+import (
+	"context"
+	"net/http"
+)
+
+// ForwardTracer 转发链路追踪接口
+// 核心包只依赖该接口，OpenTelemetry 实现位于 oteltracing 子包，未启用时不会引入 otel 依赖
+type ForwardTracer interface {
+	// StartForward 在转发前调用，返回发往后端的请求（携带 span 的 context 与注入的传播 header）
+	// 以及转发结束时的回调，status 为写回客户端的状态码，err 为传输层返回的错误
+	StartForward(r *http.Request, color, target string) (*http.Request, func(status int, err error))
+	// TraceID 返回 ctx 中已采样 span 的 trace ID，没有时返回空；同时启用指标时作为延迟直方图的 exemplar
+	TraceID(ctx context.Context) string
+}
+
+// WithForwardTracer 为每次转发创建追踪 span
+func WithForwardTracer(t ForwardTracer) Option {
+	return func(c *Config) {
+		c.Tracer = t
+	}
+}
+
+func (p *Proxy) startForward(r *http.Request, color, target string) (*http.Request, func(status int, err error)) {
+	if p.config.Tracer == nil {
+		return r, func(int, error) {}
+	}
+	return p.config.Tracer.StartForward(r, color, target)
+}
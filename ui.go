@@ -0,0 +1,29 @@
+package color
+
+import (
+	"bytes"
+	"embed"
+	"html"
+	"net/http"
+)
+
+//go:embed ui/index.html
+var uiFS embed.FS
+
+// handleUI 返回内嵌的路由管理页面
+func (p *Proxy) handleUI(w http.ResponseWriter, r *http.Request) {
+	page, err := uiFS.ReadFile("ui/index.html")
+	if err != nil {
+		writeJSON(w, 500, jsonMap{"error": err.Error()})
+		return
+	}
+	// 页面通过 meta 标签得知 admin token 所在的 header
+	if name := p.config.AdminTokenHeader; name != "" {
+		page = bytes.Replace(page,
+			[]byte(`<meta name="colorproxy-token-header" content="Authorization">`),
+			[]byte(`<meta name="colorproxy-token-header" content="`+html.EscapeString(name)+`">`), 1)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page)
+}
@@ -0,0 +1,141 @@
+package color
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asam264/color/internal/backend"
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// websocketEcho 完成 WebSocket 握手后按行回显；代理只透传字节，不需要解析帧
+func websocketEcho(w http.ResponseWriter, r *http.Request) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		w.Write([]byte("plain"))
+		return
+	}
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		websocketAccept(r.Header.Get("Sec-WebSocket-Key")))
+	rw.Flush()
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		rw.WriteString("echo " + line)
+		rw.Flush()
+	}
+}
+
+// dialWebSocket 经代理发起升级请求，返回升级后的连接
+func dialWebSocket(t *testing.T, proxyURL, color string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	u, _ := url.Parse(proxyURL)
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	const key = "dGhlIHNhbXBsZSBub25jZQ=="
+	fmt.Fprintf(conn, "GET /ws HTTP/1.1\r\nHost: %s\r\ncolor: %s\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: %s\r\n\r\n", u.Host, color, key)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("status = %d %s, want 101", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != websocketAccept(key) {
+		t.Errorf("Sec-WebSocket-Accept = %q, want %q", got, websocketAccept(key))
+	}
+	return conn, br
+}
+
+func TestWebSocketPassthrough(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(websocketEcho))
+	defer up.Close()
+	p := newTestProxy(t)
+	register(t, p, &backend.Route{Color: "blue", Address: up.URL, Token: "t"})
+	front := httptest.NewServer(p.Handler())
+	defer front.Close()
+
+	conn, br := dialWebSocket(t, front.URL, "blue")
+	for _, msg := range []string{"hello", "world", "again"} {
+		fmt.Fprintf(conn, "%s\n", msg)
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != "echo "+msg+"\n" {
+			t.Errorf("reply = %q, want %q", line, "echo "+msg+"\n")
+		}
+	}
+}
+
+func TestWebSocketDoesNotCorruptPooledConnections(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(websocketEcho))
+	defer up.Close()
+	p := newTestProxy(t)
+	register(t, p, &backend.Route{Color: "blue", Address: up.URL, Token: "t"})
+	front := httptest.NewServer(p.Handler())
+	defer front.Close()
+
+	// 先建立普通请求的空闲连接，再升级，之后的普通请求与第二次升级都不受影响
+	get := func() {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, front.URL+"/plain", nil)
+		req.Header.Set("color", "blue")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "plain" {
+			t.Errorf("plain response = %q, want plain", body)
+		}
+	}
+	get()
+
+	first, firstReader := dialWebSocket(t, front.URL, "blue")
+	get()
+	second, secondReader := dialWebSocket(t, front.URL, "blue")
+
+	fmt.Fprintf(first, "a\n")
+	fmt.Fprintf(second, "b\n")
+	if line, _ := secondReader.ReadString('\n'); line != "echo b\n" {
+		t.Errorf("second reply = %q, want echo b", line)
+	}
+	if line, _ := firstReader.ReadString('\n'); line != "echo a\n" {
+		t.Errorf("first reply = %q, want echo a", line)
+	}
+	first.Close()
+	get()
+}